@@ -0,0 +1,396 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// deleteBatchSize is the maximum number of session keys removed per DELETE statement
+const deleteBatchSize = 500
+
+// DeleteSessionsForUser deletes every session belonging to the given user ("logout everywhere").
+// The table is streamed row by row and only matching keys are kept in memory, so this is
+// safe to run against very large django_session tables. Returns the number of deleted rows.
+func (c *Client) DeleteSessionsForUser(ctx context.Context, userID string) (int64, error) {
+	keys, err := c.sessionKeysForUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(keys))
+
+		tag, err := c.db.Exec(ctx, `DELETE FROM django_session WHERE session_key = ANY($1)`, keys[start:end])
+		if err != nil {
+			return deleted, fmt.Errorf("database delete failed: %w", err)
+		}
+		deleted += tag.RowsAffected()
+	}
+
+	return deleted, nil
+}
+
+// ListSessionsForUser returns all unexpired sessions belonging to the given user.
+// Rows are decoded one at a time and ctx is checked between rows, so long scans can be cancelled.
+func (c *Client) ListSessionsForUser(ctx context.Context, userID string) ([]*RawSession, error) {
+	query := `SELECT session_key, session_data, expire_date
+	          FROM django_session
+	          WHERE expire_date > now()`
+
+	return c.sessionsForUser(ctx, c.readDB, userID, query)
+}
+
+// sessionKeysForUser streams all sessions and returns the keys whose payload decodes to userID.
+// It reads from the primary so sessions not yet replicated are still found for deletion.
+func (c *Client) sessionKeysForUser(ctx context.Context, userID string) ([]string, error) {
+	sessions, err := c.sessionsForUser(ctx, c.db, userID, `SELECT session_key, session_data, expire_date FROM django_session`)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(sessions))
+	for i, session := range sessions {
+		keys[i] = session.SessionKey
+	}
+	return keys, nil
+}
+
+// sessionsForUser streams the rows returned by query (with args) and keeps only sessions
+// whose payload decodes to userID. Rows that fail to decode are skipped. Returns ctx.Err()
+// if the context is cancelled mid-scan.
+func (c *Client) sessionsForUser(ctx context.Context, db DBTX, userID, query string, args ...interface{}) ([]*RawSession, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*RawSession
+	for rows.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var session RawSession
+		if err := rows.Scan(&session.SessionKey, sessionDataText{&session.SessionData}, utcTimestamp{&session.ExpireDate}); err != nil {
+			return nil, fmt.Errorf("database scan failed: %w", err)
+		}
+
+		// Decode lazily, one row at a time
+		decodedID, err := c.decodeSessionData(session.SessionData)
+		if err != nil || decodedID != userID {
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DecodedSession is a session row together with its decoded payload
+type DecodedSession struct {
+	RawSession
+	UserID   string                 // Decoded _auth_user_id
+	Username string                 // auth_user.username (only set when usernames are resolved)
+	Data     map[string]interface{} // Full decoded payload
+}
+
+// ActiveSessionsPage is one page of unexpired sessions with the rows that were skipped
+type ActiveSessionsPage struct {
+	Sessions  []DecodedSession // Authenticated sessions, verified and decoded
+	Anonymous int              // Rows that verified but have no _auth_user_id
+	Invalid   int              // Rows that failed verification or decoding
+}
+
+// ListActiveSessions returns a page of unexpired authenticated sessions ordered by
+// expire_date, with signatures verified and payloads decoded. Anonymous sessions and rows
+// that fail verification are skipped; use ListActiveSessionsPage to count them.
+func (c *Client) ListActiveSessions(ctx context.Context, limit, offset int) ([]DecodedSession, error) {
+	page, err := c.ListActiveSessionsPage(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return page.Sessions, nil
+}
+
+// ListActiveSessionsPage is ListActiveSessions that also reports how many rows of the
+// page were skipped as anonymous or invalid. Limit and offset apply to rows, not sessions.
+func (c *Client) ListActiveSessionsPage(ctx context.Context, limit, offset int) (*ActiveSessionsPage, error) {
+	if limit <= 0 || offset < 0 {
+		return nil, errors.New("limit must be positive and offset non-negative")
+	}
+
+	query := `SELECT session_key, session_data, expire_date
+	          FROM django_session
+	          WHERE expire_date > now()
+	          ORDER BY expire_date, session_key
+	          LIMIT $1 OFFSET $2`
+
+	rows, err := c.readDB.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	page := &ActiveSessionsPage{}
+	for rows.Next() {
+		var session DecodedSession
+		if err := rows.Scan(&session.SessionKey, sessionDataText{&session.SessionData}, utcTimestamp{&session.ExpireDate}); err != nil {
+			return nil, fmt.Errorf("database scan failed: %w", err)
+		}
+
+		data, userID, err := c.decodeSessionMap(session.SessionData)
+		switch {
+		case errors.Is(err, ErrNoAuthUserID):
+			page.Anonymous++
+			continue
+		case err != nil:
+			page.Invalid++
+			continue
+		}
+		session.Data = data
+		session.UserID = userID
+		page.Sessions = append(page.Sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+
+	return page, nil
+}
+
+// SessionsExpiringBefore returns up to limit unexpired sessions whose expire_date is
+// before t, soonest first, e.g. for "you'll be logged out soon" notifications. Payloads
+// are neither verified nor decoded.
+func (c *Client) SessionsExpiringBefore(ctx context.Context, t time.Time, limit int) ([]*RawSession, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit must be positive")
+	}
+
+	query := `SELECT session_key, session_data, expire_date
+	          FROM django_session
+	          WHERE expire_date > now() AND expire_date < $1
+	          ORDER BY expire_date, session_key
+	          LIMIT $2`
+
+	rows, err := c.readDB.Query(ctx, query, t.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*RawSession
+	for rows.Next() {
+		var session RawSession
+		if err := rows.Scan(&session.SessionKey, sessionDataText{&session.SessionData}, utcTimestamp{&session.ExpireDate}); err != nil {
+			return nil, fmt.Errorf("database scan failed: %w", err)
+		}
+		session.EncodedSize = len(session.SessionData)
+		sessions = append(sessions, &session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// ListActiveSessionsWithUsernames is ListActiveSessions with Username resolved from auth_user
+// using a single lookup per page
+func (c *Client) ListActiveSessionsWithUsernames(ctx context.Context, limit, offset int) ([]DecodedSession, error) {
+	sessions, err := c.ListActiveSessions(ctx, limit, offset)
+	if err != nil || len(sessions) == 0 {
+		return sessions, err
+	}
+
+	userIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		userIDs = append(userIDs, session.UserID)
+	}
+	usernames, err := c.usernamesByID(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Username = usernames[c.normalizeUserID(sessions[i].UserID)]
+	}
+	return sessions, nil
+}
+
+// usernamesByID loads auth_user.username for the given IDs, keyed by normalizeUserID.
+// IDs are bound according to ClientConfig.UserIDType so the primary key index is used;
+// IDs that cannot be of that type are left out, as in GetUser.
+func (c *Client) usernamesByID(ctx context.Context, userIDs []string) (map[string]string, error) {
+	var param interface{}
+	var query string
+
+	switch c.userIDType {
+	case UserIDUUID:
+		ids := make([]string, 0, len(userIDs))
+		for _, userID := range userIDs {
+			if uuidPattern.MatchString(userID) {
+				ids = append(ids, userID)
+			}
+		}
+		param = ids
+		query = `SELECT id::text, username FROM auth_user WHERE id = ANY($1::uuid[])`
+	case UserIDString:
+		param = userIDs
+		query = `SELECT id, username FROM auth_user WHERE id = ANY($1)`
+	default:
+		ids := make([]int64, 0, len(userIDs))
+		for _, userID := range userIDs {
+			if id, err := strconv.ParseInt(userID, 10, 64); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		param = ids
+		query = `SELECT id::text, username FROM auth_user WHERE id = ANY($1)`
+	}
+
+	rows, err := c.readDB.Query(ctx, query, param)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	usernames := make(map[string]string)
+	for rows.Next() {
+		var id, username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, fmt.Errorf("database scan failed: %w", err)
+		}
+		usernames[c.normalizeUserID(id)] = username
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	return usernames, nil
+}
+
+// normalizeUserID returns the form of userID that Postgres renders for the same key, so
+// "042" matches integer 42 and upper-case UUIDs match their canonical text
+func (c *Client) normalizeUserID(userID string) string {
+	switch c.userIDType {
+	case UserIDUUID:
+		return strings.ToLower(userID)
+	case UserIDString:
+		return userID
+	default:
+		if id, err := strconv.ParseInt(userID, 10, 64); err == nil {
+			return strconv.FormatInt(id, 10)
+		}
+		return userID
+	}
+}
+
+// resignBatchSize is the number of sessions read and rewritten per ResignAllSessions batch
+const resignBatchSize = 500
+
+// ResignAllSessions re-signs every unexpired session from oldKey to newKey, so a retired
+// SECRET_KEY can be dropped from SecretKeyFallbacks. Rows are paged by session_key and
+// each page is rewritten by a single UPDATE, which Postgres applies atomically. The payload
+// and its signing timestamp are kept, so MaxAge still counts from the original login.
+// A row is only rewritten if its data is unchanged since it was read, so concurrent Django
+// writes win. Sessions already valid under newKey, and sessions that do not verify under
+// oldKey (corrupt or foreign data), are counted as skipped.
+func (c *Client) ResignAllSessions(ctx context.Context, oldKey, newKey string) (migrated, skipped int64, err error) {
+	if oldKey == "" || newKey == "" {
+		return 0, 0, errors.New("old and new secret keys are required")
+	}
+	if oldKey == newKey {
+		return 0, 0, errors.New("old and new secret keys must differ")
+	}
+
+	oldSigner := *c.signer
+	oldSigner.SecretKey = oldKey
+	oldSigner.FallbackKeys = nil
+	newSigner := oldSigner
+	newSigner.SecretKey = newKey
+	newSigner.LegacyAlgorithm = "" // always move rows onto the current algorithm
+
+	query := `SELECT session_key, session_data
+	          FROM django_session
+	          WHERE expire_date > now() AND session_key > $1
+	          ORDER BY session_key
+	          LIMIT $2`
+
+	lastKey := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return migrated, skipped, err
+		}
+
+		page, err := c.sessionDataPage(ctx, query, lastKey)
+		if err != nil {
+			return migrated, skipped, err
+		}
+
+		var keys, oldData, newData []string
+		for _, session := range page {
+			if _, err := newSigner.Unsign(session.SessionData); err == nil {
+				skipped++
+				continue
+			}
+			if _, err := oldSigner.UnsignObject(session.SessionData, nil); err != nil {
+				skipped++
+				continue
+			}
+			value, _ := oldSigner.Unsign(session.SessionData)
+
+			keys = append(keys, session.SessionKey)
+			oldData = append(oldData, session.SessionData)
+			newData = append(newData, value+newSigner.Sep+newSigner.signature(value))
+		}
+
+		if len(keys) > 0 {
+			tag, err := c.db.Exec(ctx, `UPDATE django_session AS s
+			          SET session_data = u.new_data
+			          FROM unnest($1::text[], $2::text[], $3::text[]) AS u(session_key, old_data, new_data)
+			          WHERE s.session_key = u.session_key AND s.session_data = u.old_data`,
+				keys, oldData, newData)
+			if err != nil {
+				return migrated, skipped, fmt.Errorf("database update failed: %w", err)
+			}
+			migrated += tag.RowsAffected()
+			skipped += int64(len(keys)) - tag.RowsAffected()
+		}
+
+		if len(page) < resignBatchSize {
+			return migrated, skipped, nil
+		}
+		lastKey = page[len(page)-1].SessionKey
+	}
+}
+
+// sessionDataPage reads up to resignBatchSize session keys and data after the given key.
+// The rows are fully read before returning so the connection is free for the update.
+func (c *Client) sessionDataPage(ctx context.Context, query, after string) ([]RawSession, error) {
+	rows, err := c.db.Query(ctx, query, after, resignBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var page []RawSession
+	for rows.Next() {
+		var session RawSession
+		if err := rows.Scan(&session.SessionKey, sessionDataText{&session.SessionData}); err != nil {
+			return nil, fmt.Errorf("database scan failed: %w", err)
+		}
+		page = append(page, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+	return page, nil
+}
@@ -0,0 +1,516 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRows is a pgx.Rows implementation that produces rows on demand from a generator,
+// so large result sets never exist in memory at once
+type MockRows struct {
+	total   int
+	current int
+	row     func(i int) []interface{}
+	onNext  func(i int)
+	err     error
+	closed  bool
+	scanned int
+}
+
+func (r *MockRows) Close()                                       { r.closed = true }
+func (r *MockRows) Err() error                                   { return r.err }
+func (r *MockRows) CommandTag() pgconn.CommandTag                { return pgconn.NewCommandTag("SELECT") }
+func (r *MockRows) FieldDescriptions() []pgconn.FieldDescription { return nil }
+func (r *MockRows) RawValues() [][]byte                          { return nil }
+func (r *MockRows) Conn() *pgx.Conn                              { return nil }
+
+func (r *MockRows) Next() bool {
+	if r.closed || r.current >= r.total {
+		return false
+	}
+	r.current++
+	if r.onNext != nil {
+		r.onNext(r.current)
+	}
+	return true
+}
+
+func (r *MockRows) Values() ([]interface{}, error) {
+	return r.row(r.current - 1), nil
+}
+
+func (r *MockRows) Scan(dest ...interface{}) error {
+	values := r.row(r.current - 1)
+	if len(values) != len(dest) {
+		return fmt.Errorf("expected %d destinations, got %d", len(values), len(dest))
+	}
+	for i, v := range values {
+		switch d := dest[i].(type) {
+		case *string:
+			*d = v.(string)
+		case *time.Time:
+			*d = v.(time.Time)
+		case utcTimestamp:
+			scanValue(d, v)
+		case sessionDataText:
+			scanValue(d, v)
+		default:
+			return fmt.Errorf("unsupported scan destination %T", d)
+		}
+	}
+	r.scanned++
+	return nil
+}
+
+// TestDeleteSessionsForUser tests streaming enumeration and chunked deletion
+func TestDeleteSessionsForUser(t *testing.T) {
+	secretKey := "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j"
+
+	targetSession, err := EncodeSessionData("7", secretKey, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test session: %v", err)
+	}
+	otherSession, err := EncodeSessionData("8", secretKey, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test session: %v", err)
+	}
+
+	// Every other row belongs to the target user, every fifth row is corrupt
+	const totalRows = 3000
+	rows := &MockRows{
+		total: totalRows,
+		row: func(i int) []interface{} {
+			key := fmt.Sprintf("key%d", i)
+			expires := time.Now().Add(time.Hour)
+			switch {
+			case i%5 == 0:
+				return []interface{}{key, "corrupt", expires}
+			case i%2 == 0:
+				return []interface{}{key, targetSession, expires}
+			default:
+				return []interface{}{key, otherSession, expires}
+			}
+		},
+	}
+
+	expectedKeys := make(map[string]bool)
+	for i := 0; i < totalRows; i++ {
+		if i%5 != 0 && i%2 == 0 {
+			expectedKeys[fmt.Sprintf("key%d", i)] = true
+		}
+	}
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil)
+
+	deletedKeys := make(map[string]bool)
+	var batches int
+	db.On("Exec", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		keys := args.Get(2).([]interface{})[0].([]string)
+		if len(keys) > deleteBatchSize {
+			t.Errorf("Batch of %d keys exceeds limit %d", len(keys), deleteBatchSize)
+		}
+		for _, k := range keys {
+			deletedKeys[k] = true
+		}
+		batches++
+	}).Return(pgconn.NewCommandTag("DELETE 500"), nil)
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	deleted, err := client.DeleteSessionsForUser(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("DeleteSessionsForUser() error = %v", err)
+	}
+
+	if rows.scanned != totalRows {
+		t.Errorf("Expected all %d rows to be scanned, got %d", totalRows, rows.scanned)
+	}
+	if !rows.closed {
+		t.Error("Expected rows to be closed")
+	}
+	if len(deletedKeys) != len(expectedKeys) {
+		t.Errorf("Deleted %d keys, want %d", len(deletedKeys), len(expectedKeys))
+	}
+	for k := range deletedKeys {
+		if !expectedKeys[k] {
+			t.Errorf("Unexpected key deleted: %s", k)
+		}
+	}
+	wantBatches := (len(expectedKeys) + deleteBatchSize - 1) / deleteBatchSize
+	if batches != wantBatches {
+		t.Errorf("Expected %d delete batches, got %d", wantBatches, batches)
+	}
+	if deleted != int64(batches*500) {
+		t.Errorf("DeleteSessionsForUser() = %d, want %d", deleted, batches*500)
+	}
+}
+
+// TestDeleteSessionsForUserQueryError tests that query failures are propagated
+func TestDeleteSessionsForUserQueryError(t *testing.T) {
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return((*MockRows)(nil), errors.New("connection refused"))
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.DeleteSessionsForUser(context.Background(), "7"); err == nil {
+		t.Error("DeleteSessionsForUser() expected error but got none")
+	}
+	db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestListSessionsForUser tests that only the user's sessions are returned
+func TestListSessionsForUser(t *testing.T) {
+	secretKey := "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j"
+
+	targetSession, _ := EncodeSessionData("7", secretKey, nil)
+	otherSession, _ := EncodeSessionData("8", secretKey, nil)
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	data := []string{targetSession, otherSession, targetSession, "corrupt"}
+	rows := &MockRows{
+		total: len(data),
+		row: func(i int) []interface{} {
+			return []interface{}{fmt.Sprintf("key%d", i), data[i], expires}
+		},
+	}
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil)
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	sessions, err := client.ListSessionsForUser(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("ListSessionsForUser() error = %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("ListSessionsForUser() returned %d sessions, want 2", len(sessions))
+	}
+	if sessions[0].SessionKey != "key0" || sessions[1].SessionKey != "key2" {
+		t.Errorf("Unexpected session keys: %s, %s", sessions[0].SessionKey, sessions[1].SessionKey)
+	}
+	if !sessions[0].ExpireDate.Equal(expires) {
+		t.Errorf("ExpireDate = %v, want %v", sessions[0].ExpireDate, expires)
+	}
+}
+
+// TestListSessionsForUserCancelled tests that cancelling the context stops the scan early
+func TestListSessionsForUserCancelled(t *testing.T) {
+	secretKey := "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j"
+	sessionData, _ := EncodeSessionData("7", secretKey, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const totalRows = 10000
+	rows := &MockRows{
+		total: totalRows,
+		row: func(i int) []interface{} {
+			return []interface{}{fmt.Sprintf("key%d", i), sessionData, time.Now().Add(time.Hour)}
+		},
+		onNext: func(i int) {
+			// Cancel partway through the result set
+			if i == 100 {
+				cancel()
+			}
+		},
+	}
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil)
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	sessions, err := client.ListSessionsForUser(ctx, "7")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ListSessionsForUser() error = %v, want context.Canceled", err)
+	}
+	if sessions != nil {
+		t.Errorf("Expected nil sessions, got %d", len(sessions))
+	}
+	if rows.scanned >= totalRows {
+		t.Errorf("Expected scan to stop early, scanned %d rows", rows.scanned)
+	}
+	if !rows.closed {
+		t.Error("Expected rows to be closed")
+	}
+}
+
+// TestListActiveSessions tests pagination arguments and skipping of anonymous and invalid rows
+func TestListActiveSessions(t *testing.T) {
+	secretKey := "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j"
+
+	session1, _ := EncodeSessionData("1", secretKey, nil)
+	session2, _ := EncodeSessionData("2", secretKey, nil)
+	anonymous, _ := (&DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}).
+		SignObject(map[string]interface{}{"cart": []int{3}}, true)
+	tampered := session1[:len(session1)-2] + "xx"
+	expires := time.Now().Add(time.Hour)
+
+	data := []string{session1, "corrupt", anonymous, session2, tampered}
+	newPage := func() *MockRows {
+		return &MockRows{
+			total: len(data),
+			row: func(i int) []interface{} {
+				return []interface{}{fmt.Sprintf("key%d", i), data[i], expires}
+			},
+		}
+	}
+
+	t.Run("without usernames", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Query", mock.Anything, sqlContains("ORDER BY expire_date, session_key"), []interface{}{5, 8}).Return(newPage(), nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		sessions, err := client.ListActiveSessions(context.Background(), 5, 8)
+		if err != nil {
+			t.Fatalf("ListActiveSessions() error = %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("ListActiveSessions() returned %d sessions, want 2", len(sessions))
+		}
+		if sessions[0].UserID != "1" || sessions[1].UserID != "2" {
+			t.Errorf("Unexpected user IDs: %s, %s", sessions[0].UserID, sessions[1].UserID)
+		}
+		if sessions[0].SessionKey != "key0" || sessions[1].SessionKey != "key3" {
+			t.Errorf("Unexpected session keys: %s, %s", sessions[0].SessionKey, sessions[1].SessionKey)
+		}
+		if sessions[0].Username != "" {
+			t.Errorf("Expected no username, got %s", sessions[0].Username)
+		}
+		db.AssertExpectations(t)
+	})
+
+	t.Run("page counts anonymous and invalid rows separately", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Query", mock.Anything, sqlContains("django_session"), []interface{}{5, 0}).Return(newPage(), nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		page, err := client.ListActiveSessionsPage(context.Background(), 5, 0)
+		if err != nil {
+			t.Fatalf("ListActiveSessionsPage() error = %v", err)
+		}
+		if len(page.Sessions) != 2 || page.Anonymous != 1 || page.Invalid != 2 {
+			t.Errorf("Got %d sessions, %d anonymous and %d invalid, want 2, 1 and 2",
+				len(page.Sessions), page.Anonymous, page.Invalid)
+		}
+	})
+
+	t.Run("with usernames", func(t *testing.T) {
+		users := [][]interface{}{{"1", "alice"}, {"2", "bob"}}
+		db := &MockDBTX{}
+		db.On("Query", mock.Anything, sqlContains("django_session"), []interface{}{10, 0}).Return(newPage(), nil)
+		db.On("Query", mock.Anything, sqlContains("WHERE id = ANY($1)"), []interface{}{[]int64{1, 2}}).Return(&MockRows{
+			total: len(users),
+			row:   func(i int) []interface{} { return users[i] },
+		}, nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		sessions, err := client.ListActiveSessionsWithUsernames(context.Background(), 10, 0)
+		if err != nil {
+			t.Fatalf("ListActiveSessionsWithUsernames() error = %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("Got %d sessions, want 2", len(sessions))
+		}
+		if sessions[0].Username != "alice" || sessions[1].Username != "bob" {
+			t.Errorf("Unexpected usernames: %s, %s", sessions[0].Username, sessions[1].Username)
+		}
+		db.AssertExpectations(t)
+	})
+
+	t.Run("with UUID usernames", func(t *testing.T) {
+		const upper = "0F8FAD5B-D9CB-469F-A165-70867728950E"
+		uuidSession, _ := EncodeSessionData(upper, secretKey, nil)
+		db := &MockDBTX{}
+		db.On("Query", mock.Anything, sqlContains("django_session"), []interface{}{10, 0}).Return(&MockRows{
+			total: 1,
+			row:   func(int) []interface{} { return []interface{}{"key0", uuidSession, expires} },
+		}, nil)
+		db.On("Query", mock.Anything, sqlContains("ANY($1::uuid[])"), []interface{}{[]string{upper}}).Return(&MockRows{
+			total: 1,
+			row:   func(int) []interface{} { return []interface{}{strings.ToLower(upper), "carol"} },
+		}, nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey, UserIDType: UserIDUUID})
+
+		sessions, err := client.ListActiveSessionsWithUsernames(context.Background(), 10, 0)
+		if err != nil {
+			t.Fatalf("ListActiveSessionsWithUsernames() error = %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].Username != "carol" {
+			t.Errorf("ListActiveSessionsWithUsernames() = %+v, want carol", sessions)
+		}
+		db.AssertExpectations(t)
+	})
+
+	t.Run("invalid pagination", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		if _, err := client.ListActiveSessions(context.Background(), 0, 0); err == nil {
+			t.Error("ListActiveSessions() expected error for zero limit")
+		}
+		if _, err := client.ListActiveSessions(context.Background(), 10, -1); err == nil {
+			t.Error("ListActiveSessions() expected error for negative offset")
+		}
+	})
+}
+
+// TestResignAllSessions tests re-signing a mix of old-key, new-key and corrupt sessions
+func TestResignAllSessions(t *testing.T) {
+	oldKey, newKey := "old-secret", "new-secret"
+
+	oldSession, _ := EncodeSessionData("7", oldKey, nil)
+	newSession, _ := EncodeSessionData("8", newKey, nil)
+	sessions := [][]interface{}{
+		{"a", oldSession},
+		{"b", newSession},
+		{"c", "corrupt"},
+		{"d", oldSession},
+	}
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, sqlContains("session_key > $1"), []interface{}{"", resignBatchSize}).
+		Return(&MockRows{total: len(sessions), row: func(i int) []interface{} { return sessions[i] }}, nil)
+
+	var updated []interface{}
+	db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(2).([]interface{})
+	}).Return(pgconn.NewCommandTag("UPDATE 2"), nil)
+
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: newKey, SecretKeyFallbacks: []string{oldKey}})
+	migrated, skipped, err := client.ResignAllSessions(context.Background(), oldKey, newKey)
+	if err != nil {
+		t.Fatalf("ResignAllSessions() error = %v", err)
+	}
+	if migrated != 2 || skipped != 2 {
+		t.Errorf("ResignAllSessions() = %d migrated, %d skipped, want 2, 2", migrated, skipped)
+	}
+
+	keys, oldData, newData := updated[0].([]string), updated[1].([]string), updated[2].([]string)
+	if fmt.Sprint(keys) != "[a d]" || oldData[0] != oldSession {
+		t.Fatalf("Unexpected update arguments %v, %v", keys, oldData)
+	}
+
+	// Re-signed data verifies under the new key alone and keeps the original timestamp
+	newOnly, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: newKey})
+	if userID, err := newOnly.DecodeSessionUserID(newData[0]); err != nil || userID != "7" {
+		t.Errorf("DecodeSessionUserID(re-signed) = %v, %v, want 7", userID, err)
+	}
+	if newData[0][:strings.LastIndex(newData[0], ":")] != oldSession[:strings.LastIndex(oldSession, ":")] {
+		t.Error("Expected payload and timestamp to be preserved")
+	}
+}
+
+// TestResignAllSessionsPaging tests keyset paging across batches
+func TestResignAllSessionsPaging(t *testing.T) {
+	oldKey, newKey := "old-secret", "new-secret"
+	oldSession, _ := EncodeSessionData("7", oldKey, nil)
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, []interface{}{"", resignBatchSize}).
+		Return(&MockRows{total: resignBatchSize, row: func(i int) []interface{} {
+			return []interface{}{fmt.Sprintf("key%04d", i), oldSession}
+		}}, nil).Once()
+	db.On("Query", mock.Anything, mock.Anything, []interface{}{fmt.Sprintf("key%04d", resignBatchSize-1), resignBatchSize}).
+		Return(&MockRows{total: 0}, nil).Once()
+	db.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+		Return(pgconn.NewCommandTag(fmt.Sprintf("UPDATE %d", resignBatchSize)), nil).Once()
+
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: newKey})
+	migrated, skipped, err := client.ResignAllSessions(context.Background(), oldKey, newKey)
+	if err != nil {
+		t.Fatalf("ResignAllSessions() error = %v", err)
+	}
+	if migrated != resignBatchSize || skipped != 0 {
+		t.Errorf("ResignAllSessions() = %d migrated, %d skipped, want %d, 0", migrated, skipped, resignBatchSize)
+	}
+	db.AssertExpectations(t)
+
+	if _, _, err := client.ResignAllSessions(context.Background(), oldKey, oldKey); err == nil {
+		t.Error("ResignAllSessions() expected error for identical keys")
+	}
+}
+
+func TestSessionsExpiringBefore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	cutoff := now.Add(time.Hour)
+
+	// Rows as the database returns them: filtered to the window and ordered by expire_date
+	expiries := []time.Duration{5 * time.Minute, 20 * time.Minute, 59 * time.Minute}
+	newRows := func() *MockRows {
+		return &MockRows{
+			total: len(expiries),
+			row: func(i int) []interface{} {
+				return []interface{}{fmt.Sprintf("key%d", i), fmt.Sprintf("data%d", i), now.Add(expiries[i])}
+			},
+		}
+	}
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.MatchedBy(func(sql string) bool {
+		return strings.Contains(sql, "expire_date > now() AND expire_date < $1") &&
+			strings.Contains(sql, "ORDER BY expire_date, session_key") && strings.Contains(sql, "LIMIT $2")
+	}), []interface{}{cutoff, 10}).Return(newRows(), nil)
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	sessions, err := client.SessionsExpiringBefore(ctx, cutoff, 10)
+	if err != nil {
+		t.Fatalf("SessionsExpiringBefore() error = %v", err)
+	}
+	if len(sessions) != len(expiries) {
+		t.Fatalf("SessionsExpiringBefore() returned %d sessions, want %d", len(sessions), len(expiries))
+	}
+	for i, session := range sessions {
+		if want := fmt.Sprintf("key%d", i); session.SessionKey != want {
+			t.Errorf("sessions[%d].SessionKey = %s, want %s", i, session.SessionKey, want)
+		}
+		if !session.ExpireDate.Equal(now.Add(expiries[i])) || !session.ExpireDate.Before(cutoff) {
+			t.Errorf("sessions[%d].ExpireDate = %v, want %v", i, session.ExpireDate, now.Add(expiries[i]))
+		}
+		if i > 0 && session.ExpireDate.Before(sessions[i-1].ExpireDate) {
+			t.Errorf("sessions not ordered by expire_date at index %d", i)
+		}
+	}
+	db.AssertExpectations(t)
+
+	t.Run("invalid limit", func(t *testing.T) {
+		if _, err := client.SessionsExpiringBefore(ctx, cutoff, 0); err == nil {
+			t.Error("SessionsExpiringBefore() expected error for zero limit")
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return((*MockRows)(nil), errors.New("connection reset"))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		if _, err := client.SessionsExpiringBefore(ctx, cutoff, 10); err == nil {
+			t.Error("SessionsExpiringBefore() expected error but got none")
+		}
+	})
+}
@@ -2,9 +2,14 @@ package django_session
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -19,6 +24,35 @@ var (
 	ErrInvalidSignature = errors.New("invalid session signature")
 	// ErrUserNotFound is returned when user is not found in database
 	ErrUserNotFound = errors.New("user not found")
+	// ErrNoSessionCookie is returned by the middleware and SessionFromRequest when the request has no session cookie
+	ErrNoSessionCookie = errors.New("no session cookie")
+	// ErrPayloadTooLarge is returned when session data exceeds the configured size limit
+	ErrPayloadTooLarge = errors.New("session payload too large")
+	// ErrCorruptSession is returned when a compressed payload is truncated or unreadable
+	ErrCorruptSession = errors.New("corrupt session data")
+	// ErrSessionKeyRequired is returned by ginsession.RequireSessionKeyMiddleware when the payload lacks the required key
+	ErrSessionKeyRequired = errors.New("required session key missing")
+	// ErrNoAuthBackend is returned when a session payload has no _auth_user_backend
+	ErrNoAuthBackend = errors.New("_auth_user_backend not found in session")
+	// ErrNoAuthUserID is returned when a session payload has no _auth_user_id, i.e. the session is anonymous
+	ErrNoAuthUserID = errors.New("_auth_user_id not found in session")
+	// ErrNoUserID is returned when _auth_user_id is empty or zero and RejectEmptyUserID is set
+	ErrNoUserID = errors.New("empty or zero user ID in session")
+	// ErrInvalidEncoding is returned when ValidateUTF8 is set and a payload is not valid UTF-8
+	ErrInvalidEncoding = errors.New("invalid session encoding")
+	// ErrClaimNotFound is returned by DecodeSessionClaim when the payload lacks the requested key
+	ErrClaimNotFound = errors.New("claim not found in session")
+	// ErrSignatureMismatch is returned instead of ErrInvalidSignature, which it wraps, when
+	// DiagnoseSignatures is set and the signature is well-formed but does not match,
+	// typically because the secret key (e.g. base64 vs raw) or salt differs from Django's
+	ErrSignatureMismatch = fmt.Errorf("%w: well-formed signature does not match (wrong secret key or salt?)", ErrInvalidSignature)
+	// ErrMalformedSignature is returned instead of ErrInvalidSignature, which it wraps, when
+	// DiagnoseSignatures is set and the signature is not base64 of a digest's length
+	ErrMalformedSignature = fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	// ErrDecryptionFailed is returned by DecryptField for malformed or tampered values
+	ErrDecryptionFailed = errors.New("field decryption failed")
+	// ErrSessionRevoked is returned by GetRawSession and CheckRevoked when RevocationChecker reports the session key as revoked
+	ErrSessionRevoked = errors.New("session revoked")
 )
 
 // DBTX is an interface compatible with *pgx.Conn, *pgxpool.Pool and the sqlc generated interfaces.
@@ -29,28 +63,181 @@ type DBTX interface {
 	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 }
 
-// RawSession represents a Django session without decoded payload (fast)
+// RawSession represents a Django session without decoded payload (fast).
+// ExpireDate is always in UTC, whether the column is timestamp or timestamptz.
 type RawSession struct {
 	SessionKey  string
 	SessionData string
 	ExpireDate  time.Time
+	EncodedSize int // Byte length of SessionData as stored (set by GetRawSession)
+}
+
+// DecodedSize returns the payload size in bytes after base64 decoding and, for compressed
+// sessions, decompression. It is computed on each call and does not verify the signature.
+func (s *RawSession) DecodedSize() (int, error) {
+	_, _, size, err := InspectSessionData(s.SessionData)
+	return size, err
+}
+
+// IsExpired reports whether the session's expire_date has passed
+func (s *RawSession) IsExpired() bool {
+	return time.Now().After(s.ExpireDate)
 }
 
 // ClientConfig holds configuration for the Django session client
 type ClientConfig struct {
 	DB                DBTX
+	ReadDB            DBTX // Optional: read replica for session lookups and batch reads (default: DB)
 	SecretKey         string
 	SessionCookieName string
 	MaxAge            time.Duration // Optional: max age for session validation
+	Separator         string        // Optional: signing separator (default: ":")
+
+	// StrictSessionKeyFormat rejects session keys that Django could not have generated
+	// (32 characters of [a-z0-9]) before querying the database
+	StrictSessionKeyFormat bool
+
+	// RevocationChecker is consulted by GetRawSession for sessions that exist and have not
+	// expired, e.g. against a Redis denylist for immediate revocation after an incident.
+	// Revoked keys fail with ErrSessionRevoked; checker errors fail the lookup.
+	RevocationChecker func(ctx context.Context, sessionKey string) (revoked bool, err error)
+
+	// VerifySignature makes GetRawSession, ValidateRawSession and CheckSession also check
+	// the session_data HMAC, failing with ErrInvalidSignature for tampered rows (default: false)
+	VerifySignature bool
+
+	MaxRetries   int                  // Optional: retries for transient DB errors in GetRawSession (default: 0)
+	RetryBackoff time.Duration        // Optional: initial backoff between retries, doubled on each attempt
+	IsTransient  func(err error) bool // Optional: overrides IsTransientDBError for retry classification
+
+	// SecretProvider resolves the SECRET_KEY at verification time, e.g. from Vault, so a
+	// rotated key is picked up without a restart. Keys are cached for SecretProviderTTL
+	// (default: DefaultSecretProviderTTL). SecretKey is used when no provider is set.
+	SecretProvider    func() (string, error)
+	SecretProviderTTL time.Duration
+
+	// SecretKeyFallbacks are previous SECRET_KEYs still accepted when verifying, like
+	// Django's SECRET_KEY_FALLBACKS during key rotation
+	SecretKeyFallbacks []string
+
+	// UserIDType is the user model's primary key type, used to bind _auth_user_id in
+	// GetUser (default: UserIDInt)
+	UserIDType UserIDType
+
+	// RejectEmptyUserID treats an _auth_user_id of "" or 0, as written by buggy custom auth
+	// code, as ErrNoUserID instead of a valid user (default: false)
+	RejectEmptyUserID bool
+
+	// CookieWriter sets the cookie for sessions created by EnsureSession
+	// (default: an HttpOnly cookie named SessionCookieName)
+	CookieWriter *CookieWriter
+
+	// DjangoVersion applies the session signing defaults of a Django release, such as the
+	// sha1 fallback accepted by 3.2 (default: current behaviour, matching 4.2 and later)
+	DjangoVersion DjangoVersion
+
+	// Serializer is Django's SESSION_SERIALIZER setting as a dotted path, e.g.
+	// JSONSerializerPath, so the value can be passed through from Django settings.
+	// PickleSerializerPath and unknown paths are rejected (default: JSON)
+	Serializer string
+
+	// AllowStandardBase64 also accepts payloads encoded with the standard base64 alphabet
+	// ('+' and '/'); URL-safe decoding is always tried first (default: false)
+	AllowStandardBase64 bool
+
+	// JSONCodec replaces encoding/json for session payloads, e.g. with jsoniter
+	// (default: StdJSONCodec)
+	JSONCodec JSONCodec
+
+	// ValidateUTF8 rejects decoded payloads containing invalid UTF-8 with ErrInvalidEncoding.
+	// Data written by Django is always valid UTF-8 (default: false)
+	ValidateUTF8 bool
+
+	// CompressionLevel is the zlib level for session data the client signs, from -2
+	// (Huffman only) to 9 (best compression); zero means zlib.DefaultCompression.
+	// Decoding accepts any level.
+	CompressionLevel int
+
+	// CompressionPrefix is the character marking compressed session data, for stores
+	// written by third-party signers that do not use Django's "." (default: ".")
+	CompressionPrefix string
+
+	// Now overrides the clock used for expiry checks, new session expiry dates and
+	// signature timestamps, for deterministic tests (default: time.Now)
+	Now func() time.Time
+
+	// MaxDecompressedSize limits the size of session_data and of its decompressed payload
+	// (default: DefaultMaxDecompressedSize)
+	MaxDecompressedSize int64
+
+	// DiagnoseSignatures reports failed verifications as ErrSignatureMismatch or
+	// ErrMalformedSignature to tell a wrong key from a format problem (default: false)
+	DiagnoseSignatures bool
 }
 
 // Client provides methods to interact with Django sessions
 type Client struct {
 	db                DBTX
+	readDB            DBTX
 	secretKey         string
 	sessionCookieName string
 	maxAge            time.Duration
 	signer            *DjangoSigner
+	strictKeyFormat   bool
+	verifySignature   bool
+	maxRetries        int
+	retryBackoff      time.Duration
+	isTransient       func(err error) bool
+	sleep             func(ctx context.Context, d time.Duration) error
+	cookieWriter      *CookieWriter
+	userIDType        UserIDType
+	rejectEmptyUserID bool
+	secrets           *secretCache // nil unless SecretProvider is set
+	nowFunc           func() time.Time
+	isRevoked         func(ctx context.Context, sessionKey string) (bool, error)
+}
+
+// defaultSessionAge mirrors Django's SESSION_COOKIE_AGE default (two weeks)
+const defaultSessionAge = 14 * 24 * time.Hour
+
+// sessionKeyChars is Django's VALID_KEY_CHARS: ascii_lowercase + digits
+const sessionKeyChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// GenerateSessionKey returns a random session key in Django's format (32 chars of [a-z0-9]),
+// as SessionBase._get_new_session_key does. The client uses it for every key it creates.
+func GenerateSessionKey() (string, error) {
+	key := make([]byte, 0, djangoSessionKeyLength)
+	buf := make([]byte, djangoSessionKeyLength)
+	for len(key) < djangoSessionKeyLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate session key: %w", err)
+		}
+		for _, b := range buf {
+			// Reject bytes above the largest multiple of 36 to avoid modulo bias
+			if int(b) >= 256-256%len(sessionKeyChars) || len(key) == djangoSessionKeyLength {
+				continue
+			}
+			key = append(key, sessionKeyChars[int(b)%len(sessionKeyChars)])
+		}
+	}
+	return string(key), nil
+}
+
+// djangoSessionKeyLength is the length of keys generated by Django's SessionBase._get_new_session_key
+const djangoSessionKeyLength = 32
+
+// IsValidSessionKey reports whether key matches Django's generated format: 32 chars of [a-z0-9]
+func IsValidSessionKey(key string) bool {
+	if len(key) != djangoSessionKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') {
+			return false
+		}
+	}
+	return true
 }
 
 // NewClient creates a new Django session client
@@ -58,61 +245,576 @@ func NewClient(config ClientConfig) (*Client, error) {
 	if config.DB == nil {
 		return nil, errors.New("database connection is required")
 	}
-	if config.SecretKey == "" {
+	if config.SecretKey == "" && config.SecretProvider == nil {
 		return nil, errors.New("secret key is required")
 	}
+	if config.SecretProviderTTL < 0 {
+		return nil, errors.New("secret provider TTL must not be negative")
+	}
+	if config.SecretProviderTTL == 0 {
+		config.SecretProviderTTL = DefaultSecretProviderTTL
+	}
 	if config.SessionCookieName == "" {
 		config.SessionCookieName = "sessionid" // Django default
 	}
+	if config.Separator == "" {
+		config.Separator = ":" // Django default
+	}
+	if err := validateSeparator(config.Separator); err != nil {
+		return nil, err
+	}
+	if config.AllowStandardBase64 && strings.ContainsAny(config.Separator, "+/") {
+		return nil, fmt.Errorf("separator %q is ambiguous with standard base64", config.Separator)
+	}
+	if config.MaxRetries < 0 {
+		return nil, errors.New("max retries must not be negative")
+	}
+	if config.IsTransient == nil {
+		config.IsTransient = IsTransientDBError
+	}
+	if config.MaxDecompressedSize < 0 {
+		return nil, errors.New("max decompressed size must not be negative")
+	}
+	if config.ReadDB == nil {
+		config.ReadDB = config.DB
+	}
+	if _, err := serializerFormat(config.Serializer); err != nil {
+		return nil, err
+	}
+	if err := validateCompressionLevel(config.CompressionLevel); err != nil {
+		return nil, err
+	}
+	if err := validateCompressionPrefix(config.CompressionPrefix, config.Separator); err != nil {
+		return nil, err
+	}
+	switch config.UserIDType {
+	case "":
+		config.UserIDType = UserIDInt
+	case UserIDInt, UserIDUUID, UserIDString:
+	default:
+		return nil, fmt.Errorf("unsupported user ID type: %q", string(config.UserIDType))
+	}
+	if config.CookieWriter == nil {
+		writer, err := NewCookieWriter(CookieConfig{Name: config.SessionCookieName})
+		if err != nil {
+			return nil, err
+		}
+		config.CookieWriter = writer
+	}
+
+	if config.Now == nil {
+		config.Now = time.Now
+	}
 
 	signer := &DjangoSigner{
 		SecretKey: config.SecretKey,
 		Salt:      "django.contrib.sessions.SessionStore",
-		Sep:       ":",
+		Sep:       config.Separator,
 		Algorithm: "sha256",
+
+		AllowStandardBase64: config.AllowStandardBase64,
+		Codec:               config.JSONCodec,
+		MaxDecompressedSize: config.MaxDecompressedSize,
+		FallbackKeys:        config.SecretKeyFallbacks,
+		ValidateUTF8:        config.ValidateUTF8,
+		CompressionLevel:    config.CompressionLevel,
+		CompressionPrefix:   config.CompressionPrefix,
+		DiagnoseSignatures:  config.DiagnoseSignatures,
+		Now:                 config.Now,
+	}
+	if config.DjangoVersion != "" {
+		defaults, err := config.DjangoVersion.Defaults()
+		if err != nil {
+			return nil, err
+		}
+		signer.Salt = defaults.Salt
+		signer.Algorithm = defaults.Algorithm
+		signer.LegacyAlgorithm = defaults.LegacyAlgorithm
+	}
+
+	var secrets *secretCache
+	if config.SecretProvider != nil {
+		secrets = &secretCache{provider: config.SecretProvider, ttl: config.SecretProviderTTL, now: config.Now}
 	}
 
 	return &Client{
 		db:                config.DB,
+		readDB:            config.ReadDB,
 		secretKey:         config.SecretKey,
 		sessionCookieName: config.SessionCookieName,
 		maxAge:            config.MaxAge,
 		signer:            signer,
+		strictKeyFormat:   config.StrictSessionKeyFormat,
+		verifySignature:   config.VerifySignature,
+		maxRetries:        config.MaxRetries,
+		retryBackoff:      config.RetryBackoff,
+		isTransient:       config.IsTransient,
+		sleep:             sleepContext,
+		cookieWriter:      config.CookieWriter,
+		userIDType:        config.UserIDType,
+		rejectEmptyUserID: config.RejectEmptyUserID,
+		secrets:           secrets,
+		nowFunc:           config.Now,
+		isRevoked:         config.RevocationChecker,
 	}, nil
 }
 
 // GetRawSession retrieves and validates a Django session by session key
 // WITHOUT decoding the payload. This is fast and used by middleware.
 func (c *Client) GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error) {
-	if sessionKey == "" || len(sessionKey) > 255 {
+	session, _, err := c.fetchRawSession(ctx, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkRawSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	// Return session WITHOUT decoding payload
+	return session, nil
+}
+
+// GetRawSessionExtra is GetRawSession that also selects extraColumns, such as created_at
+// or ip_address added to django_session by custom middleware, and returns their values
+// by column name as pgx scans them. Column names must be plain identifiers (letters,
+// digits and underscores); they are quoted in the query, so they are case-sensitive.
+func (c *Client) GetRawSessionExtra(ctx context.Context, sessionKey string, extraColumns ...string) (*RawSession, map[string]interface{}, error) {
+	for _, column := range extraColumns {
+		if !isValidColumnName(column) {
+			return nil, nil, fmt.Errorf("invalid column name %q", column)
+		}
+	}
+
+	session, extra, err := c.fetchRawSession(ctx, sessionKey, extraColumns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkRawSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+	return session, extra, nil
+}
+
+// checkRawSession applies GetRawSession's expiry, signature and revocation checks to a fetched row
+func (c *Client) checkRawSession(ctx context.Context, session *RawSession) error {
+	// Check if session is expired
+	if c.isExpired(session.ExpireDate) {
+		return ErrSessionExpired
+	}
+
+	return c.CheckSession(ctx, session)
+}
+
+// CheckSession applies GetRawSession's checks other than expiry to a session obtained
+// elsewhere, e.g. from a cache or GetRawSessionAllowExpired: the session_data HMAC with
+// ClientConfig.VerifySignature (ErrInvalidSignature), then CheckRevoked.
+func (c *Client) CheckSession(ctx context.Context, session *RawSession) error {
+	if c.verifySignature {
+		if err := c.VerifySessionSignature(session.SessionData); err != nil {
+			return err
+		}
+	}
+	return c.CheckRevoked(ctx, session.SessionKey)
+}
+
+// CheckRevoked runs RevocationChecker for sessionKey. Returns ErrSessionRevoked for revoked
+// keys, a wrapped error when the checker fails, and nil without a checker. GetRawSession
+// calls it; use it for sessions obtained elsewhere, e.g. from a cache or GetRawSessionAllowExpired.
+func (c *Client) CheckRevoked(ctx context.Context, sessionKey string) error {
+	if c.isRevoked == nil {
+		return nil
+	}
+	revoked, err := c.isRevoked(ctx, sessionKey)
+	if err != nil {
+		return fmt.Errorf("revocation check failed: %w", err)
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// isValidColumnName reports whether name is a plain SQL identifier that is safe to select
+func isValidColumnName(name string) bool {
+	if name == "" || len(name) > 63 || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') && ch != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// LookupSession is GetRawSession for hot paths that branch on a boolean: found is false
+// with a nil error when the session does not exist or has expired, and err is non-nil
+// only for genuine failures such as database errors.
+func (c *Client) LookupSession(ctx context.Context, sessionKey string) (*RawSession, bool, error) {
+	session, err := c.GetRawSession(ctx, sessionKey)
+	switch {
+	case err == nil:
+		return session, true, nil
+	case errors.Is(err, ErrSessionNotFound), errors.Is(err, ErrSessionExpired):
+		return nil, false, nil
+	default:
+		return nil, false, err
+	}
+}
+
+// SessionExists reports whether sessionKey names an unexpired session, for presence and
+// rate-limiting checks. Unlike GetRawSession it never transfers session_data.
+func (c *Client) SessionExists(ctx context.Context, sessionKey string) (bool, error) {
+	if !c.acceptsSessionKey(sessionKey) {
+		return false, nil
+	}
+
+	var one int
+	err := c.withRetry(ctx, func() error {
+		return c.readDB.QueryRow(ctx,
+			`SELECT 1 FROM django_session WHERE session_key = $1 AND expire_date > now()`,
+			sessionKey).Scan(&one)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("database query failed: %w", err)
+	}
+	return true, nil
+}
+
+// ValidateRawSession applies GetRawSession's checks to a row the caller already has, e.g.
+// from a cache or a test fixture, without querying the database: the key must be
+// acceptable (ErrSessionNotFound otherwise) and the session unexpired (ErrSessionExpired).
+// With ClientConfig.VerifySignature the session_data HMAC is verified too. RevocationChecker
+// is not consulted, as it may need ctx; call CheckRevoked for that.
+func (c *Client) ValidateRawSession(session RawSession) (*RawSession, error) {
+	if !c.acceptsSessionKey(session.SessionKey) {
 		return nil, ErrSessionNotFound
 	}
+	if c.isExpired(session.ExpireDate) {
+		return nil, ErrSessionExpired
+	}
+	if c.verifySignature {
+		if err := c.VerifySessionSignature(session.SessionData); err != nil {
+			return nil, err
+		}
+	}
+
+	session.ExpireDate = session.ExpireDate.UTC()
+	session.EncodedSize = len(session.SessionData)
+	return &session, nil
+}
+
+// acceptsSessionKey reports whether sessionKey could name a stored session, so obviously
+// invalid keys (and, with StrictSessionKeyFormat, non-Django keys) skip the database
+func (c *Client) acceptsSessionKey(sessionKey string) bool {
+	if sessionKey == "" || len(sessionKey) > 255 {
+		return false
+	}
+	return !c.strictKeyFormat || IsValidSessionKey(sessionKey)
+}
+
+// GetRawSessionAllowExpired retrieves a Django session by session key without rejecting
+// expired sessions. Intended for auditing; callers must check IsExpired() themselves.
+func (c *Client) GetRawSessionAllowExpired(ctx context.Context, sessionKey string) (*RawSession, error) {
+	session, _, err := c.fetchRawSession(ctx, sessionKey)
+	return session, err
+}
+
+// fetchRawSession loads a session row, plus any extraColumns by name, without any expiry
+// check. Column names must already be validated.
+func (c *Client) fetchRawSession(ctx context.Context, sessionKey string, extraColumns ...string) (*RawSession, map[string]interface{}, error) {
+	if !c.acceptsSessionKey(sessionKey) {
+		return nil, nil, ErrSessionNotFound
+	}
 
 	var session RawSession
-	query := `SELECT session_key, session_data, expire_date 
+	var columns strings.Builder
+	for _, column := range extraColumns {
+		columns.WriteString(", ")
+		columns.WriteString(pgx.Identifier{column}.Sanitize())
+	}
+	query := `SELECT session_key, session_data, expire_date` + columns.String() + `
 	          FROM django_session 
 	          WHERE session_key = $1`
 
-	err := c.db.QueryRow(ctx, query, sessionKey).Scan(
-		&session.SessionKey,
-		&session.SessionData,
-		&session.ExpireDate,
-	)
+	values := make([]interface{}, len(extraColumns))
+	dest := []interface{}{&session.SessionKey, sessionDataText{&session.SessionData}, utcTimestamp{&session.ExpireDate}}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	err := c.withRetry(ctx, func() error {
+		return c.readDB.QueryRow(ctx, query, sessionKey).Scan(dest...)
+	})
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrSessionNotFound
+			return nil, nil, ErrSessionNotFound
 		}
-		return nil, fmt.Errorf("database query failed: %w", err)
+		return nil, nil, fmt.Errorf("database query failed: %w", err)
 	}
 
-	// Check if session is expired
-	if time.Now().After(session.ExpireDate) {
-		return nil, ErrSessionExpired
+	var extra map[string]interface{}
+	if len(extraColumns) > 0 {
+		extra = make(map[string]interface{}, len(extraColumns))
+		for i, column := range extraColumns {
+			extra[column] = values[i]
+		}
 	}
 
-	// Return session WITHOUT decoding payload
-	return &session, nil
+	session.EncodedSize = len(session.SessionData)
+	return &session, extra, nil
+}
+
+// BuildSession creates everything needed to persist a new authenticated session: a fresh
+// session key (also the cookie value), the encoded session_data and the expire_date.
+// Expiry is MaxAge from now, or Django's two-week default when MaxAge is unset, in UTC.
+func (c *Client) BuildSession(userID string, extra map[string]interface{}) (sessionKey, sessionData string, expireDate time.Time, err error) {
+	sessionKey, err = GenerateSessionKey()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	sessionData, err = c.encodeUserSession(userID, extra)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	return sessionKey, sessionData, c.nowFunc().Add(c.sessionAge()).UTC(), nil
+}
+
+// encodeUserSession signs extra together with _auth_user_id as new session_data
+func (c *Client) encodeUserSession(userID string, extra map[string]interface{}) (string, error) {
+	data := make(map[string]interface{}, len(extra)+1)
+	for key, value := range extra {
+		data[key] = value
+	}
+	data["_auth_user_id"] = userID
+
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	return signer.SignObject(data, true)
+}
+
+// isExpired reports whether expireDate has passed according to the client's clock
+func (c *Client) isExpired(expireDate time.Time) bool {
+	return c.nowFunc().After(expireDate)
+}
+
+// Now returns the current time according to ClientConfig.Now, the clock the client judges
+// expiry by, so code outside the package (such as ginsession's ExpiryGrace) agrees with it
+func (c *Client) Now() time.Time {
+	return c.nowFunc()
+}
+
+// sessionAge returns how long new sessions stay valid
+func (c *Client) sessionAge() time.Duration {
+	if c.maxAge > 0 {
+		return c.maxAge
+	}
+	return defaultSessionAge
+}
+
+// CreateAnonymousSession stores a new session without an authenticated user, as Django does
+// for guests (e.g. pre-login carts). A zero expireDate uses MaxAge or Django's default age.
+// Returns the new session key to be used as the cookie value.
+func (c *Client) CreateAnonymousSession(ctx context.Context, data map[string]interface{}, expireDate time.Time) (string, error) {
+	session, err := c.createAnonymousSession(ctx, data, expireDate)
+	if err != nil {
+		return "", err
+	}
+	return session.SessionKey, nil
+}
+
+// createAnonymousSession implements CreateAnonymousSession, returning the stored row
+func (c *Client) createAnonymousSession(ctx context.Context, data map[string]interface{}, expireDate time.Time) (*RawSession, error) {
+	if _, ok := data["_auth_user_id"]; ok {
+		return nil, errors.New("anonymous session data must not contain _auth_user_id")
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	signer, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	sessionData, err := signer.SignObject(data, true)
+	if err != nil {
+		return nil, err
+	}
+	if expireDate.IsZero() {
+		expireDate = c.nowFunc().Add(c.sessionAge())
+	}
+	expireDate = expireDate.UTC()
+
+	sessionKey, err := c.insertSession(ctx, sessionData, expireDate)
+	if err != nil {
+		return nil, err
+	}
+	return &RawSession{SessionKey: sessionKey, SessionData: sessionData, ExpireDate: expireDate}, nil
+}
+
+// maxCreateAttempts limits session key regeneration on unique key collisions
+const maxCreateAttempts = 5
+
+// insertSession inserts a session under a freshly generated key, regenerating the key on
+// collision like Django's SessionStore.create. Collisions are detected with ON CONFLICT
+// DO NOTHING rather than a unique violation, which would abort an enclosing transaction.
+// expireDate is written in UTC, since a timestamp without time zone column keeps only the
+// wall clock and reads treat it as UTC.
+func (c *Client) insertSession(ctx context.Context, sessionData string, expireDate time.Time) (string, error) {
+	expireDate = expireDate.UTC()
+	query := `INSERT INTO django_session (session_key, session_data, expire_date)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (session_key) DO NOTHING`
+
+	for attempt := 0; attempt < maxCreateAttempts; attempt++ {
+		sessionKey, err := GenerateSessionKey()
+		if err != nil {
+			return "", err
+		}
+
+		tag, err := c.db.Exec(ctx, query, sessionKey, sessionData, expireDate)
+		if err != nil {
+			return "", fmt.Errorf("database insert failed: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return sessionKey, nil
+		}
+	}
+
+	return "", errors.New("failed to generate a unique session key")
+}
+
+// IsAuthenticated reports whether session data decodes to a session with a user ID.
+// Anonymous sessions and undecodable data are not authenticated.
+func (c *Client) IsAuthenticated(sessionData string) bool {
+	userID, err := c.decodeSessionData(sessionData)
+	return err == nil && userID != ""
+}
+
+// SessionBelongsToUser reports whether the session named by sessionKey is logged in as
+// userID, e.g. for support tools authorizing an action. A different user and an anonymous
+// session are (false, nil); missing or expired sessions, verification failures and
+// database errors are returned as errors.
+func (c *Client) SessionBelongsToUser(ctx context.Context, sessionKey, userID string) (bool, error) {
+	session, err := c.GetRawSession(ctx, sessionKey)
+	if err != nil {
+		return false, err
+	}
+	sessionUserID, err := c.decodeSessionData(session.SessionData)
+	if errors.Is(err, ErrNoAuthUserID) || errors.Is(err, ErrNoUserID) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sessionUserID == userID, nil
+}
+
+// DeleteSession deletes a session by key and returns the number of rows removed.
+// Deleting a nonexistent session is not an error: it returns 0, nil.
+func (c *Client) DeleteSession(ctx context.Context, sessionKey string) (int64, error) {
+	tag, err := c.db.Exec(ctx, `DELETE FROM django_session WHERE session_key = $1`, sessionKey)
+	if err != nil {
+		return 0, fmt.Errorf("database delete failed: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// UpdateSession replaces the payload and expiry of an existing session and returns the
+// number of rows updated (0 if the session does not exist). expireDate is written in UTC.
+func (c *Client) UpdateSession(ctx context.Context, sessionKey, sessionData string, expireDate time.Time) (int64, error) {
+	query := `UPDATE django_session
+	          SET session_data = $2, expire_date = $3
+	          WHERE session_key = $1`
+
+	tag, err := c.db.Exec(ctx, query, sessionKey, sessionData, expireDate.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("database update failed: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// TouchSession extends a session for keep-alive pings: session_data is re-signed with a
+// fresh timestamp (so MaxAge counts from now) and expire_date moves to MaxAge, or Django's
+// two-week default, from now. The data must still verify. Returns the updated session, or
+// ErrSessionNotFound if the row was deleted meanwhile.
+func (c *Client) TouchSession(ctx context.Context, session *RawSession) (*RawSession, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	compressed := strings.HasPrefix(session.SessionData, signer.compressionPrefix())
+	sessionData, err := signer.ReSignObject(session.SessionData, maxAgeLimit(c.maxAge), compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	expireDate := c.nowFunc().Add(c.sessionAge()).UTC()
+	updated, err := c.UpdateSession(ctx, session.SessionKey, sessionData, expireDate)
+	if err != nil {
+		return nil, err
+	}
+	if updated == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	return &RawSession{
+		SessionKey:  session.SessionKey,
+		SessionData: sessionData,
+		ExpireDate:  expireDate,
+		EncodedSize: len(sessionData),
+	}, nil
+}
+
+// ClearExpiredSessions deletes all expired sessions (like Django's clearsessions command)
+// and returns the number of rows removed
+func (c *Client) ClearExpiredSessions(ctx context.Context) (int64, error) {
+	tag, err := c.db.Exec(ctx, `DELETE FROM django_session WHERE expire_date < now()`)
+	if err != nil {
+		return 0, fmt.Errorf("database delete failed: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountSessions returns the number of stored sessions and of those not yet expired, for
+// dashboards. Both counts come from one scan of django_session on the read database.
+func (c *Client) CountSessions(ctx context.Context) (total, active int64, err error) {
+	err = c.withRetry(ctx, func() error {
+		return c.readDB.QueryRow(ctx,
+			`SELECT count(*), count(*) FILTER (WHERE expire_date > now()) FROM django_session`,
+		).Scan(&total, &active)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// An aggregate always returns a row; treat its absence as an empty table
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("database query failed: %w", err)
+	}
+	return total, active, nil
+}
+
+// VerifySessionSignature checks the HMAC signature of session data without decoding the
+// payload. Returns ErrInvalidSignature if the data was tampered with or signed with another key.
+func (c *Client) VerifySessionSignature(sessionData string) error {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return err
+	}
+	_, err = signer.Unsign(sessionData)
+	if err != nil && !errors.Is(err, ErrInvalidSignature) {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return err
 }
 
 // DecodeSessionUserID decodes the session payload and extracts user ID
@@ -121,25 +823,211 @@ func (c *Client) DecodeSessionUserID(sessionData string) (string, error) {
 	return c.decodeSessionData(sessionData)
 }
 
+// DecodeSessionUserIDTimed is DecodeSessionUserID that also reports how long the
+// unsign, decompress and unmarshal steps took, for profiling expensive payloads.
+// The duration is returned on failure too.
+func (c *Client) DecodeSessionUserIDTimed(sessionData string) (userID string, decodeDuration time.Duration, err error) {
+	start := time.Now()
+	userID, err = c.decodeSessionData(sessionData)
+	return userID, time.Since(start), err
+}
+
+// DecodeSessionUserIDMaxAge is DecodeSessionUserID with maxAge in place of the configured
+// MaxAge. A zero or negative maxAge disables the age check.
+func (c *Client) DecodeSessionUserIDMaxAge(sessionData string, maxAge time.Duration) (string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	sessionMap, err := signer.UnsignObject(sessionData, maxAgeLimit(maxAge))
+	if err != nil {
+		return "", err
+	}
+	return c.userIDFromMap(sessionMap)
+}
+
+// DecodeUntimestamped verifies a value signed with Django's plain Signer (no timestamp
+// component) using the client's key and salt, and returns the original value. MaxAge does
+// not apply since there is no signing time to check.
+func (c *Client) DecodeUntimestamped(signedValue string) (string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	return signer.Unsign(signedValue)
+}
+
+// DecodeSessionBackend verifies session data and returns the dotted path of the Django auth
+// backend that logged the user in (_auth_user_backend), e.g. to tell SSO from local logins.
+// Returns ErrNoAuthBackend when the payload has no backend.
+func (c *Client) DecodeSessionBackend(sessionData string) (string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	sessionMap, err := signer.UnsignObject(sessionData, maxAgeLimit(c.maxAge))
+	if err != nil {
+		return "", err
+	}
+
+	backend, ok := sessionMap["_auth_user_backend"].(string)
+	if !ok || backend == "" {
+		return "", ErrNoAuthBackend
+	}
+	return backend, nil
+}
+
+// DecodeSessionClaim verifies session data (applying MaxAge) and unmarshals the raw JSON
+// stored under key into dest, e.g. a nested "profile" object into a typed struct.
+// Returns ErrClaimNotFound when the payload has no such key.
+func (c *Client) DecodeSessionClaim(sessionData, key string, dest interface{}) error {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return err
+	}
+	data, err := signer.unsignJSON(sessionData, maxAgeLimit(c.maxAge))
+	if err != nil {
+		return err
+	}
+	if signer.ValidateUTF8 && !utf8.Valid(data) {
+		return fmt.Errorf("%w: payload is not valid UTF-8", ErrInvalidEncoding)
+	}
+
+	var claims map[string]json.RawMessage
+	if err := signer.codec().Unmarshal(data, &claims); err != nil {
+		return fmt.Errorf("json decode error: %w", err)
+	}
+	raw, ok := claims[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClaimNotFound, key)
+	}
+	if err := signer.codec().Unmarshal(raw, dest); err != nil {
+		return fmt.Errorf("json decode error for %q: %w", key, err)
+	}
+	return nil
+}
+
+// DecodeSessionIgnoreAge is DecodeSessionUserID without the MaxAge check on the signing
+// timestamp, for admin tools inspecting old sessions. The signature is still verified.
+func (c *Client) DecodeSessionIgnoreAge(sessionData string) (string, error) {
+	sessionMap, err := c.DecodeSessionMapIgnoreAge(sessionData)
+	if err != nil {
+		return "", err
+	}
+	return c.userIDFromMap(sessionMap)
+}
+
+// DecodeSessionMap verifies the signature and MaxAge and returns the full payload.
+// Unlike DecodeSessionUserID it does not require _auth_user_id.
+func (c *Client) DecodeSessionMap(sessionData string) (map[string]interface{}, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	return signer.UnsignObject(sessionData, maxAgeLimit(c.maxAge))
+}
+
+// DecodeSessionMapIgnoreAge verifies the signature and returns the full payload without
+// applying MaxAge. Unlike DecodeSessionUserID it does not require _auth_user_id.
+func (c *Client) DecodeSessionMapIgnoreAge(sessionData string) (map[string]interface{}, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	return signer.UnsignObject(sessionData, nil)
+}
+
+// SessionKeys verifies session data (applying MaxAge) and returns its top-level keys in
+// sorted order, for debugging views that show a session's structure without its values.
+func (c *Client) SessionKeys(sessionData string) ([]string, error) {
+	sessionMap, err := c.DecodeSessionMap(sessionData)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(sessionMap))
+	for key := range sessionMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// SignatureAge verifies the session_data signature and returns how long ago it was signed,
+// i.e. since login or the last save, for analytics. The payload is not decoded and MaxAge
+// is not applied.
+func (c *Client) SignatureAge(sessionData string) (time.Duration, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return 0, err
+	}
+	_, signedAt, err := signer.unsignTimestamp(sessionData)
+	if err != nil {
+		return 0, err
+	}
+	return c.nowFunc().Sub(signedAt), nil
+}
+
+// SessionExpiresIn returns how long the session remains valid: the time until ExpireDate or,
+// when MaxAge is configured, until the signed timestamp exceeds MaxAge, whichever is sooner.
+// The result is never negative.
+func (c *Client) SessionExpiresIn(session *RawSession) time.Duration {
+	now := c.nowFunc()
+	remaining := session.ExpireDate.Sub(now)
+	if signer, err := c.currentSigner(); err == nil && c.maxAge > 0 {
+		if _, signedAt, err := signer.unsignTimestamp(session.SessionData); err == nil {
+			if ageRemaining := signedAt.Add(c.maxAge).Sub(now); ageRemaining < remaining {
+				remaining = ageRemaining
+			}
+		}
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 // decodeSessionData decodes Django session data and extracts user ID
 func (c *Client) decodeSessionData(sessionData string) (string, error) {
-	var sessionMap map[string]interface{}
-	var err error
+	_, userID, err := c.decodeSessionMap(sessionData)
+	return userID, err
+}
 
-	if c.maxAge > 0 {
-		sessionMap, err = c.signer.UnsignObject(sessionData, &c.maxAge)
-	} else {
-		sessionMap, err = c.signer.UnsignObject(sessionData, nil)
+// decodeSessionMap decodes Django session data and returns the payload and user ID
+func (c *Client) decodeSessionMap(sessionData string) (map[string]interface{}, string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return nil, "", err
 	}
+	sessionMap, err := signer.UnsignObject(sessionData, maxAgeLimit(c.maxAge))
+	if err != nil {
+		return nil, "", err
+	}
+
+	userID, err := c.userIDFromMap(sessionMap)
+	if err != nil {
+		return nil, "", err
+	}
+	return sessionMap, userID, nil
+}
 
+// userIDFromMap extracts the user ID, applying RejectEmptyUserID
+func (c *Client) userIDFromMap(sessionMap map[string]interface{}) (string, error) {
+	userID, err := extractUserID(sessionMap)
 	if err != nil {
 		return "", err
 	}
+	if c.rejectEmptyUserID && (userID == "" || userID == "0") {
+		return "", ErrNoUserID
+	}
+	return userID, nil
+}
 
+// extractUserID reads _auth_user_id from a decoded session payload as a string
+func extractUserID(sessionMap map[string]interface{}) (string, error) {
 	// Extract _auth_user_id
 	userID, ok := sessionMap["_auth_user_id"]
 	if !ok {
-		return "", errors.New("_auth_user_id not found in session")
+		return "", ErrNoAuthUserID
 	}
 
 	// Convert to string
@@ -148,6 +1036,8 @@ func (c *Client) decodeSessionData(sessionData string) (string, error) {
 		return v, nil
 	case float64:
 		return fmt.Sprintf("%.0f", v), nil
+	case json.Number:
+		return v.String(), nil
 	case int:
 		return fmt.Sprintf("%d", v), nil
 	default:
@@ -155,6 +1045,63 @@ func (c *Client) decodeSessionData(sessionData string) (string, error) {
 	}
 }
 
+// DecodeWithSalt verifies and decodes signed data produced under a non-session salt,
+// reusing the client's secret key and max age
+func (c *Client) DecodeWithSalt(sessionData, salt string) (map[string]interface{}, error) {
+	signer, err := c.signerWithSalt(salt)
+	if err != nil {
+		return nil, err
+	}
+	return signer.UnsignObject(sessionData, maxAgeLimit(c.maxAge))
+}
+
+// VerifyToken verifies a token created with django.core.signing.dumps(obj, salt=salt),
+// e.g. by a custom password reset flow, using the client's secret key and fallbacks, and
+// returns its claims. maxAge replaces MaxAge like PASSWORD_RESET_TIMEOUT; a zero or
+// negative maxAge disables the age check.
+func (c *Client) VerifyToken(token, salt string, maxAge time.Duration) (map[string]interface{}, error) {
+	signer, err := c.signerWithSalt(salt)
+	if err != nil {
+		return nil, err
+	}
+	return signer.UnsignObject(token, maxAgeLimit(maxAge))
+}
+
+// EncodeWithSalt signs data under a non-session salt using the client's secret key
+func (c *Client) EncodeWithSalt(data map[string]interface{}, salt string, compress bool) (string, error) {
+	signer, err := c.signerWithSalt(salt)
+	if err != nil {
+		return "", err
+	}
+	return signer.SignObject(data, compress)
+}
+
+// signerWithSalt returns a copy of the client's signer using the given salt. Its callers
+// all sign timestamped tokens, so an empty salt means Django's TimestampSigner default.
+func (c *Client) signerWithSalt(salt string) (*DjangoSigner, error) {
+	current, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	if salt == "" {
+		salt = defaultTimestampSignerSalt
+	}
+	signer := *current
+	signer.Salt = salt
+	return &signer, nil
+}
+
+// Salt returns the salt sessions are signed with, for diagnosing salt mismatches
+// between environments. The secret key itself is never exposed.
+func (c *Client) Salt() string {
+	return c.signer.Salt
+}
+
+// HasFallbackKeys reports whether SecretKeyFallbacks are configured
+func (c *Client) HasFallbackKeys() bool {
+	return len(c.signer.FallbackKeys) > 0
+}
+
 // SessionCookieName returns the configured session cookie name
 func (c *Client) SessionCookieName() string {
 	return c.sessionCookieName
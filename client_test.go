@@ -2,13 +2,16 @@ package django_session
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -37,6 +40,14 @@ func (m *MockDBTX) CopyFrom(ctx context.Context, tableName pgx.Identifier, colum
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockDBTX) Begin(ctx context.Context) (pgx.Tx, error) {
+	args := m.Called(ctx)
+	if tx, ok := args.Get(0).(pgx.Tx); ok {
+		return tx, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 // MockRow is a mock implementation of pgx.Row
 type MockRow struct {
 	mock.Mock
@@ -47,6 +58,57 @@ func (m *MockRow) Scan(dest ...interface{}) error {
 	return args.Error(0)
 }
 
+// newMockRow returns a MockRow whose Scan copies values into the destinations in order
+func newMockRow(values ...interface{}) *MockRow {
+	row := &MockRow{}
+	matchers := make([]interface{}, len(values))
+	for i := range matchers {
+		matchers[i] = mock.Anything
+	}
+	row.On("Scan", matchers...).Run(func(args mock.Arguments) {
+		for i, v := range values {
+			scanValue(args.Get(i), v)
+		}
+	}).Return(nil)
+	return row
+}
+
+// scanValue copies a mock column value into dest, emulating pgx's scanner interfaces:
+// time.Time is delivered as timestamptz, pgtype.Timestamp as a naive timestamp, and
+// session data through sql.Scanner as pgx decodes text ([]byte for bytea columns)
+func scanValue(dest, value interface{}) {
+	if scanner, ok := dest.(utcTimestamp); ok {
+		switch v := value.(type) {
+		case time.Time:
+			scanner.ScanTimestamptz(pgtype.Timestamptz{Time: v, Valid: true})
+		case pgtype.Timestamp:
+			scanner.ScanTimestamp(v)
+		}
+		return
+	}
+	if scanner, ok := dest.(sessionDataText); ok {
+		scanner.Scan(value)
+		return
+	}
+	reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(value))
+}
+
+// newErrorRow returns a MockRow whose Scan fails with err
+func newErrorRow(err error, columns int) *MockRow {
+	row := &MockRow{}
+	matchers := make([]interface{}, columns)
+	for i := range matchers {
+		matchers[i] = mock.Anything
+	}
+	row.On("Scan", matchers...).Return(err)
+	return row
+}
+
+// sqlContains matches SQL arguments containing the given fragment
+func sqlContains(fragment string) interface{} {
+	return mock.MatchedBy(func(sql string) bool { return strings.Contains(sql, fragment) })
+}
+
 // TestNewClient tests the Client constructor
 func TestNewClient(t *testing.T) {
 	tests := []struct {
@@ -394,3 +456,1414 @@ func TestDecodeSessionUserIDWithInvalidData(t *testing.T) {
 		})
 	}
 }
+
+// TestClientSaltRoundTrip tests encoding and decoding under a custom salt
+func TestClientSaltRoundTrip(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	salt := "myapp.auxiliary"
+	data := map[string]interface{}{"token": "abc123"}
+
+	for _, compress := range []bool{false, true} {
+		signed, err := client.EncodeWithSalt(data, salt, compress)
+		if err != nil {
+			t.Fatalf("EncodeWithSalt() error = %v", err)
+		}
+
+		decoded, err := client.DecodeWithSalt(signed, salt)
+		if err != nil {
+			t.Fatalf("DecodeWithSalt() error = %v", err)
+		}
+		if decoded["token"] != "abc123" {
+			t.Errorf("DecodeWithSalt() token = %v, want abc123", decoded["token"])
+		}
+
+		// Data signed under a custom salt must not verify as a session
+		if _, err := client.DecodeWithSalt(signed, "django.contrib.sessions.SessionStore"); err == nil {
+			t.Error("DecodeWithSalt() expected error for mismatched salt")
+		}
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	secretKey := "test-secret"
+	salt := "myapp.password_reset"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxAge: time.Minute})
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: salt, Sep: ":", Algorithm: "sha256"}
+
+	// signedAgo builds a token as signing.dumps would have produced it age ago
+	signedAgo := func(age time.Duration) string {
+		value := b64Encode([]byte(`{"user":42,"purpose":"reset"}`)) + ":" + b62Encode(time.Now().Add(-age).Unix())
+		return value + ":" + signer.signature(value)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		salt    string
+		maxAge  time.Duration
+		wantErr bool
+	}{
+		{"fresh", signedAgo(0), salt, time.Hour, false},
+		{"within max age, beyond client MaxAge", signedAgo(50 * time.Minute), salt, time.Hour, false},
+		{"just past max age", signedAgo(time.Hour + 2*time.Second), salt, time.Hour, true},
+		{"no max age", signedAgo(30 * 24 * time.Hour), salt, 0, false},
+		{"session salt", signedAgo(0), "django.contrib.sessions.SessionStore", time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := client.VerifyToken(tt.token, tt.salt, tt.maxAge)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (claims["user"] != float64(42) || claims["purpose"] != "reset") {
+				t.Errorf("VerifyToken() = %v", claims)
+			}
+		})
+	}
+
+	compressed, _ := signer.SignObject(map[string]interface{}{"user": 42}, true)
+	if claims, err := client.VerifyToken(compressed, salt, time.Hour); err != nil || claims["user"] != float64(42) {
+		t.Errorf("VerifyToken(compressed) = %v, %v", claims, err)
+	}
+}
+
+// TestGetRawSessionAllowExpired tests that expired rows are only returned by the audit method
+func TestGetRawSessionAllowExpired(t *testing.T) {
+	ctx := context.Background()
+	expiredAt := time.Now().Add(-time.Hour)
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"expired-key"}).
+		Return(newMockRow("expired-key", "data", expiredAt))
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if _, err := client.GetRawSession(ctx, "expired-key"); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("GetRawSession() error = %v, want ErrSessionExpired", err)
+	}
+
+	session, err := client.GetRawSessionAllowExpired(ctx, "expired-key")
+	if err != nil {
+		t.Fatalf("GetRawSessionAllowExpired() error = %v", err)
+	}
+	if session.SessionKey != "expired-key" {
+		t.Errorf("SessionKey = %v, want expired-key", session.SessionKey)
+	}
+	if !session.IsExpired() {
+		t.Error("Expected IsExpired() to be true")
+	}
+}
+
+// TestGetRawSessionValid tests that an unexpired row is returned
+func TestGetRawSessionValid(t *testing.T) {
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"valid-key"}).
+		Return(newMockRow("valid-key", "data", time.Now().Add(time.Hour)))
+
+	client, err := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	session, err := client.GetRawSession(context.Background(), "valid-key")
+	if err != nil {
+		t.Fatalf("GetRawSession() error = %v", err)
+	}
+	if session.IsExpired() {
+		t.Error("Expected IsExpired() to be false")
+	}
+}
+
+// TestClientSeparator tests the Separator option
+func TestClientSeparator(t *testing.T) {
+	secretKey := "test-secret-key"
+
+	pipeClient, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, Separator: "|"})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defaultClient, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	pipeSession, err := pipeClient.signer.SignObject(map[string]interface{}{"_auth_user_id": "5"}, false)
+	if err != nil {
+		t.Fatalf("SignObject() error = %v", err)
+	}
+	if userID, err := pipeClient.DecodeSessionUserID(pipeSession); err != nil || userID != "5" {
+		t.Errorf("DecodeSessionUserID() = %v, %v; want 5", userID, err)
+	}
+	if _, err := defaultClient.DecodeSessionUserID(pipeSession); err == nil {
+		t.Error("Default client should not decode a pipe-separated session")
+	}
+
+	// Default ":" still works
+	colonSession, _ := EncodeSessionData("6", secretKey, nil)
+	if userID, err := defaultClient.DecodeSessionUserID(colonSession); err != nil || userID != "6" {
+		t.Errorf("DecodeSessionUserID() = %v, %v; want 6", userID, err)
+	}
+
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, Separator: "x"}); err == nil {
+		t.Error("NewClient() expected error for unsafe separator")
+	}
+}
+
+// TestWriteOperationCounts tests the affected row counts returned by write methods
+func TestWriteOperationCounts(t *testing.T) {
+	ctx := context.Background()
+	expireDate := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name string
+		tag  string
+		want int64
+		call func(c *Client) (int64, error)
+	}{
+		{"delete existing", "DELETE 1", 1, func(c *Client) (int64, error) { return c.DeleteSession(ctx, "abc") }},
+		{"delete missing", "DELETE 0", 0, func(c *Client) (int64, error) { return c.DeleteSession(ctx, "missing") }},
+		{"update existing", "UPDATE 1", 1, func(c *Client) (int64, error) { return c.UpdateSession(ctx, "abc", "data", expireDate) }},
+		{"update missing", "UPDATE 0", 0, func(c *Client) (int64, error) { return c.UpdateSession(ctx, "missing", "data", expireDate) }},
+		{"clear expired", "DELETE 42", 42, func(c *Client) (int64, error) { return c.ClearExpiredSessions(ctx) }},
+		{"clear nothing", "DELETE 0", 0, func(c *Client) (int64, error) { return c.ClearExpiredSessions(ctx) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.NewCommandTag(tt.tag), nil)
+			client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			got, err := tt.call(client)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("affected = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("database error", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, mock.Anything, mock.Anything).Return(pgconn.CommandTag{}, errors.New("connection reset"))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		if _, err := client.DeleteSession(ctx, "abc"); err == nil {
+			t.Error("DeleteSession() expected error but got none")
+		}
+	})
+}
+
+// TestStrictSessionKeyFormat tests that malformed keys are rejected before querying
+func TestStrictSessionKeyFormat(t *testing.T) {
+	ctx := context.Background()
+	validKey := "abcdefghijklmnopqrstuvwxyz012345"
+
+	tests := []struct {
+		name      string
+		key       string
+		wantQuery bool
+	}{
+		{"valid key", validKey, true},
+		{"too short", "abc123", false},
+		{"too long", validKey + "6", false},
+		{"uppercase", "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345", false},
+		{"symbols", "abcdefghijklmnopqrstuvwxyz01234!", false},
+		{"script injection", "<script>alert(1)</script>aaaaaaa", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+				Return(newMockRow(tt.key, "data", time.Now().Add(time.Hour)))
+
+			client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", StrictSessionKeyFormat: true})
+
+			_, err := client.GetRawSession(ctx, tt.key)
+			if tt.wantQuery {
+				if err != nil {
+					t.Errorf("GetRawSession() error = %v", err)
+				}
+				db.AssertNumberOfCalls(t, "QueryRow", 1)
+				return
+			}
+			if !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("GetRawSession() error = %v, want ErrSessionNotFound", err)
+			}
+			db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("non-strict allows other formats", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+			Return(newMockRow("custom-KEY", "data", time.Now().Add(time.Hour)))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		if _, err := client.GetRawSession(ctx, "custom-KEY"); err != nil {
+			t.Errorf("GetRawSession() error = %v", err)
+		}
+	})
+}
+
+// TestBuildSession tests that built sessions decode back to the original user
+func TestBuildSession(t *testing.T) {
+	secretKey := "test-secret-key"
+
+	t.Run("default age", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		sessionKey, sessionData, expireDate, err := client.BuildSession("42", map[string]interface{}{"theme": "dark"})
+		if err != nil {
+			t.Fatalf("BuildSession() error = %v", err)
+		}
+		if !IsValidSessionKey(sessionKey) {
+			t.Errorf("BuildSession() key %q is not in Django format", sessionKey)
+		}
+
+		userID, err := client.DecodeSessionUserID(sessionData)
+		if err != nil || userID != "42" {
+			t.Errorf("DecodeSessionUserID() = %v, %v; want 42", userID, err)
+		}
+		data, _ := client.signer.UnsignObject(sessionData, nil)
+		if data["theme"] != "dark" {
+			t.Errorf("theme = %v, want dark", data["theme"])
+		}
+
+		wantExpiry := time.Now().Add(14 * 24 * time.Hour)
+		if diff := wantExpiry.Sub(expireDate); diff < 0 || diff > time.Minute {
+			t.Errorf("expireDate = %v, want about %v", expireDate, wantExpiry)
+		}
+	})
+
+	t.Run("max age expiry and unique keys", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxAge: time.Hour})
+
+		key1, _, expireDate, err := client.BuildSession("42", nil)
+		if err != nil {
+			t.Fatalf("BuildSession() error = %v", err)
+		}
+		key2, _, _, _ := client.BuildSession("42", nil)
+		if key1 == key2 {
+			t.Error("BuildSession() returned the same key twice")
+		}
+		if diff := time.Until(expireDate); diff <= 59*time.Minute || diff > time.Hour {
+			t.Errorf("expireDate in %v, want about 1h", diff)
+		}
+	})
+
+	t.Run("expiry in UTC with a non-UTC clock", func(t *testing.T) {
+		now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+5", 5*3600))
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxAge: time.Hour,
+			Now: func() time.Time { return now }})
+
+		_, _, expireDate, err := client.BuildSession("42", nil)
+		if err != nil {
+			t.Fatalf("BuildSession() error = %v", err)
+		}
+		if expireDate.Location() != time.UTC || !expireDate.Equal(now.Add(time.Hour)) {
+			t.Errorf("expireDate = %v, want %v in UTC", expireDate, now.Add(time.Hour).UTC())
+		}
+	})
+
+	t.Run("extra cannot override user id", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		_, sessionData, _, err := client.BuildSession("42", map[string]interface{}{"_auth_user_id": "1"})
+		if err != nil {
+			t.Fatalf("BuildSession() error = %v", err)
+		}
+		if userID, _ := client.DecodeSessionUserID(sessionData); userID != "42" {
+			t.Errorf("DecodeSessionUserID() = %v, want 42", userID)
+		}
+	})
+}
+
+// TestCreateAnonymousSession tests creating guest sessions without a user ID
+func TestCreateAnonymousSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stored without user id", func(t *testing.T) {
+		var storedKey, storedData string
+		var storedExpiry time.Time
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).Run(func(args mock.Arguments) {
+			params := args.Get(2).([]interface{})
+			storedKey = params[0].(string)
+			storedData = params[1].(string)
+			storedExpiry = params[2].(time.Time)
+		}).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		expireDate := time.Now().Add(time.Hour)
+		sessionKey, err := client.CreateAnonymousSession(ctx, map[string]interface{}{"cart": []interface{}{"sku-1"}}, expireDate)
+		if err != nil {
+			t.Fatalf("CreateAnonymousSession() error = %v", err)
+		}
+		if sessionKey != storedKey || !IsValidSessionKey(sessionKey) {
+			t.Errorf("CreateAnonymousSession() key = %q, stored %q", sessionKey, storedKey)
+		}
+		if !storedExpiry.Equal(expireDate) {
+			t.Errorf("stored expiry = %v, want %v", storedExpiry, expireDate)
+		}
+
+		// Still a validly signed session, just without a user
+		data, err := client.signer.UnsignObject(storedData, nil)
+		if err != nil {
+			t.Fatalf("stored session does not validate: %v", err)
+		}
+		if _, ok := data["_auth_user_id"]; ok {
+			t.Error("anonymous session must not contain _auth_user_id")
+		}
+		if data["cart"] == nil {
+			t.Error("expected cart data in session")
+		}
+		if client.IsAuthenticated(storedData) {
+			t.Error("IsAuthenticated() = true for anonymous session")
+		}
+	})
+
+	t.Run("key collision retries", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 0"), nil).Once()
+		db.On("Exec", mock.Anything, mock.Anything, mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		if _, err := client.CreateAnonymousSession(ctx, nil, time.Time{}); err != nil {
+			t.Fatalf("CreateAnonymousSession() error = %v", err)
+		}
+		db.AssertNumberOfCalls(t, "Exec", 2)
+	})
+
+	t.Run("stores expiry in UTC with a non-UTC clock", func(t *testing.T) {
+		var storedExpiry time.Time
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).Run(func(args mock.Arguments) {
+			storedExpiry = args.Get(2).([]interface{})[2].(time.Time)
+		}).Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+
+		now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC-7", -7*3600))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", MaxAge: time.Hour,
+			Now: func() time.Time { return now }})
+
+		if _, err := client.CreateAnonymousSession(ctx, nil, time.Time{}); err != nil {
+			t.Fatalf("CreateAnonymousSession() error = %v", err)
+		}
+		// A timestamp without time zone column keeps the wall clock, which must be UTC
+		if storedExpiry.Location() != time.UTC || storedExpiry.Hour() != 20 {
+			t.Errorf("stored expiry = %v, want 2024-03-01 20:00 UTC", storedExpiry)
+		}
+	})
+
+	t.Run("rejects user id", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+		if _, err := client.CreateAnonymousSession(ctx, map[string]interface{}{"_auth_user_id": "1"}, time.Time{}); err == nil {
+			t.Error("CreateAnonymousSession() expected error for data with _auth_user_id")
+		}
+	})
+
+	t.Run("authenticated session", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+		sessionData, _ := EncodeSessionData("42", "test-secret", nil)
+
+		if !client.IsAuthenticated(sessionData) {
+			t.Error("IsAuthenticated() = false for authenticated session")
+		}
+	})
+}
+
+// TestVerifySessionSignature tests signature-only verification
+func TestVerifySessionSignature(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+	valid, _ := EncodeSessionData("42", "test-secret", nil)
+	forged, _ := EncodeSessionData("42", "other-secret", nil)
+
+	if err := client.VerifySessionSignature(valid); err != nil {
+		t.Errorf("VerifySessionSignature() error = %v", err)
+	}
+	for name, data := range map[string]string{"forged": forged, "garbage": "no-separator"} {
+		if err := client.VerifySessionSignature(data); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: VerifySessionSignature() error = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+// TestReadDB tests that reads go to the replica and writes to the primary
+func TestReadDB(t *testing.T) {
+	ctx := context.Background()
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("7", secretKey, nil)
+
+	primary := &MockDBTX{}
+	replica := &MockDBTX{}
+	replica.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+		Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+	replica.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&MockRows{
+		total: 1,
+		row: func(i int) []interface{} {
+			return []interface{}{"abc", sessionData, time.Now().Add(time.Hour)}
+		},
+	}, nil)
+	primary.On("Exec", mock.Anything, sqlContains("DELETE"), mock.Anything).Return(pgconn.NewCommandTag("DELETE 1"), nil)
+
+	client, err := NewClient(ClientConfig{DB: primary, ReadDB: replica, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.GetRawSession(ctx, "abc"); err != nil {
+		t.Fatalf("GetRawSession() error = %v", err)
+	}
+	if _, err := client.ListActiveSessions(ctx, 10, 0); err != nil {
+		t.Fatalf("ListActiveSessions() error = %v", err)
+	}
+	if _, err := client.DeleteSession(ctx, "abc"); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+
+	replica.AssertNumberOfCalls(t, "QueryRow", 1)
+	replica.AssertNumberOfCalls(t, "Query", 1)
+	replica.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	primary.AssertNumberOfCalls(t, "Exec", 1)
+	primary.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+
+	t.Run("falls back to DB", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+			Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+}
+
+// TestSessionExpiresIn tests remaining lifetime from expire_date and MaxAge
+func TestSessionExpiresIn(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	if got := client.SessionExpiresIn(&RawSession{SessionData: sessionData, ExpireDate: time.Now().Add(-time.Minute)}); got != 0 {
+		t.Errorf("expired session: SessionExpiresIn() = %v, want 0", got)
+	}
+	if got := client.SessionExpiresIn(&RawSession{SessionData: sessionData, ExpireDate: time.Now().Add(time.Hour)}); got < 59*time.Minute {
+		t.Errorf("SessionExpiresIn() = %v, want about 1h", got)
+	}
+
+	client, _ = NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxAge: time.Minute})
+	if got := client.SessionExpiresIn(&RawSession{SessionData: sessionData, ExpireDate: time.Now().Add(time.Hour)}); got > time.Minute || got < 58*time.Second {
+		t.Errorf("with MaxAge: SessionExpiresIn() = %v, want about 1m", got)
+	}
+}
+
+// TestClientMaxDecompressedSize tests that the payload limit is applied to decoding
+func TestClientMaxDecompressedSize(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"cart": strings.Repeat("item,", 100)})
+
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxDecompressedSize: -1}); err == nil {
+		t.Error("NewClient() expected error for negative MaxDecompressedSize")
+	}
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxDecompressedSize: 64})
+	if _, err := client.DecodeSessionUserID(sessionData); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("DecodeSessionUserID() error = %v, want ErrPayloadTooLarge", err)
+	}
+
+	client, _ = NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	if userID, err := client.DecodeSessionUserID(sessionData); err != nil || userID != "42" {
+		t.Errorf("DecodeSessionUserID() = %v, %v, want 42", userID, err)
+	}
+}
+
+// TestLookupSession tests that missing sessions are reported via found, not err
+func TestValidateRawSession(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+	tampered := strings.Replace(sessionData, ":", "x:", 1)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		config  ClientConfig
+		session RawSession
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			session: RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: future},
+		},
+		{
+			name:    "expired",
+			session: RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: time.Now().Add(-time.Second)},
+			wantErr: ErrSessionExpired,
+		},
+		{
+			name:    "tampered passes without signature verification",
+			session: RawSession{SessionKey: "abc", SessionData: tampered, ExpireDate: future},
+		},
+		{
+			name:    "tampered with signature verification",
+			config:  ClientConfig{VerifySignature: true},
+			session: RawSession{SessionKey: "abc", SessionData: tampered, ExpireDate: future},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "valid with signature verification",
+			config:  ClientConfig{VerifySignature: true},
+			session: RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: future},
+		},
+		{
+			name:    "empty key",
+			session: RawSession{SessionData: sessionData, ExpireDate: future},
+			wantErr: ErrSessionNotFound,
+		},
+		{
+			name:    "malformed key with strict format",
+			config:  ClientConfig{StrictSessionKeyFormat: true},
+			session: RawSession{SessionKey: "<script>", SessionData: sessionData, ExpireDate: future},
+			wantErr: ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			tt.config.DB = db
+			tt.config.SecretKey = secretKey
+			client, _ := NewClient(tt.config)
+
+			session, err := client.ValidateRawSession(tt.session)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateRawSession() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if session.SessionKey != tt.session.SessionKey || session.EncodedSize != len(tt.session.SessionData) {
+					t.Errorf("ValidateRawSession() = %+v", session)
+				}
+				if session.ExpireDate.Location() != time.UTC {
+					t.Errorf("ExpireDate location = %v, want UTC", session.ExpireDate.Location())
+				}
+			}
+			db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestGetRawSessionVerifySignature tests that ClientConfig.VerifySignature applies to database reads
+func TestGetRawSessionVerifySignature(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+	tampered := strings.Replace(sessionData, ":", "x:", 1)
+	future := time.Now().Add(time.Hour)
+
+	for _, verify := range []bool{false, true} {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"valid"}).Return(newMockRow("valid", sessionData, future))
+		db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"tampered"}).Return(newMockRow("tampered", tampered, future))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey, VerifySignature: verify})
+
+		if _, err := client.GetRawSession(context.Background(), "valid"); err != nil {
+			t.Errorf("VerifySignature=%v: GetRawSession(valid) error = %v", verify, err)
+		}
+		_, err := client.GetRawSession(context.Background(), "tampered")
+		if verify && !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifySignature=true: GetRawSession(tampered) error = %v, want ErrInvalidSignature", err)
+		}
+		if !verify && err != nil {
+			t.Errorf("VerifySignature=false: GetRawSession(tampered) error = %v, want nil", err)
+		}
+	}
+}
+
+func TestLookupSession(t *testing.T) {
+	ctx := context.Background()
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"valid"}).
+		Return(newMockRow("valid", "data", time.Now().Add(time.Hour)))
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"expired"}).
+		Return(newMockRow("expired", "data", time.Now().Add(-time.Hour)))
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"missing"}).
+		Return(newErrorRow(pgx.ErrNoRows, 3))
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"broken"}).
+		Return(newErrorRow(errors.New("connection refused"), 3))
+
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	tests := []struct {
+		key       string
+		wantFound bool
+		wantErr   bool
+	}{
+		{"valid", true, false},
+		{"expired", false, false},
+		{"missing", false, false},
+		{"", false, false},
+		{"broken", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			session, found, err := client.LookupSession(ctx, tt.key)
+			if found != tt.wantFound {
+				t.Errorf("found = %v, want %v", found, tt.wantFound)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if found && session.SessionKey != tt.key {
+				t.Errorf("SessionKey = %q, want %q", session.SessionKey, tt.key)
+			}
+			if !found && session != nil {
+				t.Errorf("Expected nil session when not found, got %+v", session)
+			}
+		})
+	}
+}
+
+// TestClientAllowStandardBase64 tests the client option and separator ambiguity check
+func TestClientAllowStandardBase64(t *testing.T) {
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "s", Separator: "/", AllowStandardBase64: true}); err == nil {
+		t.Error("NewClient() expected error for '/' separator with standard base64")
+	}
+
+	client, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "s", AllowStandardBase64: true})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if !client.signer.AllowStandardBase64 {
+		t.Error("Expected signer to allow standard base64")
+	}
+}
+
+// TestDecodeSessionIgnoreAge tests decoding old sessions despite MaxAge
+func TestDecodeSessionIgnoreAge(t *testing.T) {
+	secretKey := "test-secret"
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+
+	// Signed three days ago
+	value := b64Encode([]byte(`{"_auth_user_id":"42","theme":"dark"}`)) + ":" + b62Encode(time.Now().Add(-72*time.Hour).Unix())
+	oldSession := value + ":" + signer.signature(value)
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, MaxAge: 24 * time.Hour})
+
+	_, err := client.DecodeSessionUserID(oldSession)
+	var expired *ErrExpired
+	if !errors.As(err, &expired) || !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("DecodeSessionUserID() error = %v, want *ErrExpired", err)
+	}
+	if expired.MaxAge() != 24*time.Hour || expired.Age() < 72*time.Hour {
+		t.Errorf("ErrExpired Age() = %v, MaxAge() = %v", expired.Age(), expired.MaxAge())
+	}
+
+	userID, err := client.DecodeSessionIgnoreAge(oldSession)
+	if err != nil || userID != "42" {
+		t.Errorf("DecodeSessionIgnoreAge() = %v, %v, want 42", userID, err)
+	}
+
+	data, err := client.DecodeSessionMapIgnoreAge(oldSession)
+	if err != nil || data["theme"] != "dark" {
+		t.Errorf("DecodeSessionMapIgnoreAge() = %v, %v", data, err)
+	}
+	if _, err := client.DecodeSessionMap(oldSession); err == nil {
+		t.Error("DecodeSessionMap() expected max age error")
+	}
+
+	// The signature is still enforced
+	forged := value + ":" + (&DjangoSigner{SecretKey: "other", Salt: signer.Salt, Sep: ":"}).signature(value)
+	if _, err := client.DecodeSessionIgnoreAge(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeSessionIgnoreAge(forged) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// TestClientIntrospection tests the read-only salt and fallback key accessors
+func TestClientIntrospection(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "new-secret"})
+	if client.Salt() != "django.contrib.sessions.SessionStore" {
+		t.Errorf("Salt() = %q", client.Salt())
+	}
+	if client.HasFallbackKeys() {
+		t.Error("HasFallbackKeys() = true, want false")
+	}
+
+	client, _ = NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "new-secret", SecretKeyFallbacks: []string{"old-secret"}})
+	if !client.HasFallbackKeys() {
+		t.Error("HasFallbackKeys() = false, want true")
+	}
+
+	// Sessions signed with a fallback key still decode
+	oldSession, _ := EncodeSessionData("42", "old-secret", nil)
+	if userID, err := client.DecodeSessionUserID(oldSession); err != nil || userID != "42" {
+		t.Errorf("DecodeSessionUserID(fallback) = %v, %v, want 42", userID, err)
+	}
+
+	tenant, _ := client.WithSecretKey("tenant-secret")
+	if tenant.HasFallbackKeys() {
+		t.Error("Expected WithSecretKey to drop fallback keys")
+	}
+}
+
+// TestDecodeUntimestamped tests verifying plain Signer values with MaxAge configured
+func TestDecodeUntimestamped(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", MaxAge: time.Hour})
+
+	// Django: Signer(salt="django.contrib.sessions.SessionStore").sign("hello")
+	signed := "hello:" + client.signer.signature("hello")
+
+	if _, err := client.DecodeSessionUserID(signed); err == nil {
+		t.Error("DecodeSessionUserID() expected error for untimestamped value")
+	}
+
+	value, err := client.DecodeUntimestamped(signed)
+	if err != nil || value != "hello" {
+		t.Errorf("DecodeUntimestamped() = %v, %v, want hello", value, err)
+	}
+
+	if _, err := client.DecodeUntimestamped("hello:forged"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeUntimestamped(forged) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDecodeSessionBackend(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	t.Run("backend set", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{
+			"_auth_user_backend": "social_core.backends.google.GoogleOAuth2",
+		})
+		backend, err := client.DecodeSessionBackend(sessionData)
+		if err != nil {
+			t.Fatalf("DecodeSessionBackend() error = %v", err)
+		}
+		if backend != "social_core.backends.google.GoogleOAuth2" {
+			t.Errorf("DecodeSessionBackend() = %v", backend)
+		}
+	})
+
+	t.Run("backend omitted", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, nil)
+		backend, err := client.DecodeSessionBackend(sessionData)
+		if !errors.Is(err, ErrNoAuthBackend) || backend != "" {
+			t.Errorf("DecodeSessionBackend() = %q, %v, want ErrNoAuthBackend", backend, err)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", "other-secret", map[string]interface{}{
+			"_auth_user_backend": "django.contrib.auth.backends.ModelBackend",
+		})
+		if _, err := client.DecodeSessionBackend(sessionData); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("DecodeSessionBackend() error = %v, want ErrInvalidSignature", err)
+		}
+	})
+}
+
+func TestRejectEmptyUserID(t *testing.T) {
+	secretKey := "test-secret"
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+
+	tests := []struct {
+		name   string
+		userID interface{}
+		want   string
+	}{
+		{"empty string", "", ""},
+		{"zero string", "0", ""},
+		{"zero number", 0, ""},
+		{"positive ID", "42", "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionData, err := signer.SignObject(map[string]interface{}{"_auth_user_id": tt.userID}, false)
+			if err != nil {
+				t.Fatalf("SignObject() error = %v", err)
+			}
+
+			lenient, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+			if _, err := lenient.DecodeSessionUserID(sessionData); err != nil {
+				t.Errorf("DecodeSessionUserID() without RejectEmptyUserID error = %v", err)
+			}
+
+			strict, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, RejectEmptyUserID: true})
+			userID, err := strict.DecodeSessionUserID(sessionData)
+			if tt.want == "" {
+				if !errors.Is(err, ErrNoUserID) {
+					t.Errorf("DecodeSessionUserID() error = %v, want ErrNoUserID", err)
+				}
+				return
+			}
+			if err != nil || userID != tt.want {
+				t.Errorf("DecodeSessionUserID() = %v, %v, want %v", userID, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestRawSessionSizes tests EncodedSize from GetRawSession and the lazy DecodedSize
+func TestRawSessionSizes(t *testing.T) {
+	secretKey := "test-secret"
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+	payload := map[string]interface{}{"_auth_user_id": "42", "blob": strings.Repeat("x", 4096)}
+
+	for _, compress := range []bool{false, true} {
+		sessionData, err := signer.SignObject(payload, compress)
+		if err != nil {
+			t.Fatalf("SignObject() error = %v", err)
+		}
+
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		session, err := client.GetRawSession(context.Background(), "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.EncodedSize != len(sessionData) {
+			t.Errorf("EncodedSize = %d, want %d (compress=%v)", session.EncodedSize, len(sessionData), compress)
+		}
+
+		jsonData, _ := json.Marshal(payload)
+		decoded, err := session.DecodedSize()
+		if err != nil || decoded != len(jsonData) {
+			t.Errorf("DecodedSize() = %d, %v, want %d (compress=%v)", decoded, err, len(jsonData), compress)
+		}
+	}
+}
+
+// TestSessionExists tests the lightweight existence check
+func TestSessionExists(t *testing.T) {
+	ctx := context.Background()
+	existsQuery := sqlContains("SELECT 1 FROM django_session WHERE session_key = $1 AND expire_date > now()")
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, existsQuery, []interface{}{"live"}).Return(newMockRow(1))
+	// The query filters expired rows, so expired and missing keys both return no rows
+	db.On("QueryRow", mock.Anything, existsQuery, []interface{}{"expired"}).Return(newErrorRow(pgx.ErrNoRows, 1))
+	db.On("QueryRow", mock.Anything, existsQuery, []interface{}{"missing"}).Return(newErrorRow(pgx.ErrNoRows, 1))
+	db.On("QueryRow", mock.Anything, existsQuery, []interface{}{"broken"}).Return(newErrorRow(errors.New("connection refused"), 1))
+
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	for key, want := range map[string]bool{"live": true, "expired": false, "missing": false, "": false} {
+		exists, err := client.SessionExists(ctx, key)
+		if err != nil || exists != want {
+			t.Errorf("SessionExists(%q) = %v, %v, want %v", key, exists, err, want)
+		}
+	}
+
+	if _, err := client.SessionExists(ctx, "broken"); err == nil {
+		t.Error("SessionExists() expected database error")
+	}
+	db.AssertNotCalled(t, "QueryRow", mock.Anything, sqlContains("session_data"), mock.Anything)
+}
+
+func TestCountSessions(t *testing.T) {
+	ctx := context.Background()
+	countQuery := sqlContains("SELECT count(*), count(*) FILTER (WHERE expire_date > now()) FROM django_session")
+
+	tests := []struct {
+		name       string
+		row        *MockRow
+		wantTotal  int64
+		wantActive int64
+		wantErr    bool
+	}{
+		{"counts", newMockRow(int64(120), int64(87)), 120, 87, false},
+		{"empty table", newMockRow(int64(0), int64(0)), 0, 0, false},
+		{"no rows", newErrorRow(pgx.ErrNoRows, 2), 0, 0, false},
+		{"database error", newErrorRow(errors.New("connection refused"), 2), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, countQuery, []interface{}(nil)).Return(tt.row)
+			client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			total, active, err := client.CountSessions(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CountSessions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if total != tt.wantTotal || active != tt.wantActive {
+				t.Errorf("CountSessions() = %d, %d, want %d, %d", total, active, tt.wantTotal, tt.wantActive)
+			}
+			db.AssertExpectations(t)
+		})
+	}
+}
+
+// TestClientValidateUTF8 tests that ValidateUTF8 reaches the client's signer
+func TestClientValidateUTF8(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", ValidateUTF8: true})
+	sessionData, err := client.signer.signJSON([]byte("{\"_auth_user_id\":\"42\",\"name\":\"\xff\"}"), false, false)
+	if err != nil {
+		t.Fatalf("signJSON() error = %v", err)
+	}
+	if _, err := client.DecodeSessionUserID(sessionData); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("DecodeSessionUserID() error = %v, want ErrInvalidEncoding", err)
+	}
+}
+
+func TestDecodeSessionClaim(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{
+		"profile": map[string]interface{}{"role": "admin", "org": 42, "tags": []string{"beta"}},
+	})
+
+	type profile struct {
+		Role string   `json:"role"`
+		Org  int      `json:"org"`
+		Tags []string `json:"tags"`
+	}
+
+	var got profile
+	if err := client.DecodeSessionClaim(sessionData, "profile", &got); err != nil {
+		t.Fatalf("DecodeSessionClaim() error = %v", err)
+	}
+	if got.Role != "admin" || got.Org != 42 || !reflect.DeepEqual(got.Tags, []string{"beta"}) {
+		t.Errorf("DecodeSessionClaim() = %+v", got)
+	}
+
+	var userID string
+	if err := client.DecodeSessionClaim(sessionData, "_auth_user_id", &userID); err != nil || userID != "42" {
+		t.Errorf("DecodeSessionClaim(_auth_user_id) = %q, %v, want 42", userID, err)
+	}
+
+	if err := client.DecodeSessionClaim(sessionData, "missing", &got); !errors.Is(err, ErrClaimNotFound) {
+		t.Errorf("DecodeSessionClaim(missing) error = %v, want ErrClaimNotFound", err)
+	}
+
+	var wrongType int
+	if err := client.DecodeSessionClaim(sessionData, "profile", &wrongType); err == nil {
+		t.Error("DecodeSessionClaim() expected type mismatch error")
+	}
+
+	forged, _ := EncodeSessionData("42", "other-secret", map[string]interface{}{"profile": map[string]interface{}{}})
+	if err := client.DecodeSessionClaim(forged, "profile", &got); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeSessionClaim(forged) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestSessionKeys(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{
+		"cart":               []string{"sku-1"},
+		"_auth_user_backend": "django.contrib.auth.backends.ModelBackend",
+		"_auth_user_hash":    "abc123",
+		"profile":            map[string]interface{}{"role": "admin"},
+	})
+
+	keys, err := client.SessionKeys(sessionData)
+	if err != nil {
+		t.Fatalf("SessionKeys() error = %v", err)
+	}
+	want := []string{"_auth_user_backend", "_auth_user_hash", "_auth_user_id", "cart", "profile"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("SessionKeys() = %v, want %v", keys, want)
+	}
+
+	empty, _ := client.signer.SignObject(map[string]interface{}{}, true)
+	if keys, err := client.SessionKeys(empty); err != nil || len(keys) != 0 {
+		t.Errorf("SessionKeys(empty) = %v, %v, want no keys", keys, err)
+	}
+
+	forged, _ := EncodeSessionData("42", "other-secret", nil)
+	if _, err := client.SessionKeys(forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("SessionKeys(forged) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDecodeSessionUserIDTimed(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"blob": strings.Repeat("x", 4096)})
+	userID, duration, err := client.DecodeSessionUserIDTimed(sessionData)
+	if err != nil || userID != "42" {
+		t.Fatalf("DecodeSessionUserIDTimed() = %v, %v, want 42", userID, err)
+	}
+	if duration <= 0 {
+		t.Errorf("DecodeSessionUserIDTimed() duration = %v, want > 0", duration)
+	}
+
+	_, duration, err = client.DecodeSessionUserIDTimed("garbage")
+	if err == nil || duration <= 0 {
+		t.Errorf("DecodeSessionUserIDTimed(garbage) = %v, %v, want error with duration", duration, err)
+	}
+}
+
+func TestSessionBelongsToUser(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+		Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"corrupt"}).
+		Return(newMockRow("corrupt", "garbage", time.Now().Add(time.Hour)))
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"missing"}).
+		Return(newErrorRow(pgx.ErrNoRows, 3))
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+	ctx := context.Background()
+
+	anonymous, _ := client.signer.SignObject(map[string]interface{}{"cart": []int{1}}, true)
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"anonymous"}).
+		Return(newMockRow("anonymous", anonymous, time.Now().Add(time.Hour)))
+
+	if ok, err := client.SessionBelongsToUser(ctx, "abc", "42"); err != nil || !ok {
+		t.Errorf("SessionBelongsToUser(match) = %v, %v, want true", ok, err)
+	}
+	if ok, err := client.SessionBelongsToUser(ctx, "abc", "7"); err != nil || ok {
+		t.Errorf("SessionBelongsToUser(mismatch) = %v, %v, want false, nil", ok, err)
+	}
+	if ok, err := client.SessionBelongsToUser(ctx, "missing", "42"); !errors.Is(err, ErrSessionNotFound) || ok {
+		t.Errorf("SessionBelongsToUser(missing) = %v, %v, want ErrSessionNotFound", ok, err)
+	}
+	if ok, err := client.SessionBelongsToUser(ctx, "anonymous", "42"); err != nil || ok {
+		t.Errorf("SessionBelongsToUser(anonymous) = %v, %v, want false, nil", ok, err)
+	}
+	if ok, err := client.SessionBelongsToUser(ctx, "corrupt", "42"); err == nil || ok {
+		t.Errorf("SessionBelongsToUser(corrupt) = %v, %v, want error", ok, err)
+	}
+}
+
+// fakeClock is a manually advanced clock for ClientConfig.Now and DjangoSigner.Now
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestClientNow(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	expireDate := clock.now.Add(time.Hour)
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+		Return(newMockRow("abc", "data", expireDate))
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", MaxAge: 30 * time.Minute, Now: clock.Now})
+
+	t.Run("expire_date", func(t *testing.T) {
+		clock.now = expireDate.Add(-time.Second)
+		if _, err := client.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() one second before expiry error = %v", err)
+		}
+		clock.Advance(2 * time.Second)
+		if _, err := client.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("GetRawSession() one second after expiry error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("signature max age", func(t *testing.T) {
+		clock.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		_, sessionData, buildExpiry, err := client.BuildSession("42", nil)
+		if err != nil {
+			t.Fatalf("BuildSession() error = %v", err)
+		}
+		if !buildExpiry.Equal(clock.now.Add(30 * time.Minute)) {
+			t.Errorf("BuildSession() expireDate = %v, want MaxAge after the fake now", buildExpiry)
+		}
+
+		clock.Advance(30 * time.Minute)
+		if userID, err := client.DecodeSessionUserID(sessionData); err != nil || userID != "42" {
+			t.Fatalf("DecodeSessionUserID() at max age = %q, %v", userID, err)
+		}
+		if got := client.SessionExpiresIn(&RawSession{SessionData: sessionData, ExpireDate: clock.now.Add(time.Hour)}); got != 0 {
+			t.Errorf("SessionExpiresIn() at max age = %v, want 0", got)
+		}
+
+		clock.Advance(time.Second)
+		var expired *ErrExpired
+		if _, err := client.DecodeSessionUserID(sessionData); !errors.As(err, &expired) || expired.Age() != 30*time.Minute+time.Second {
+			t.Errorf("DecodeSessionUserID() past max age error = %v, want ErrExpired with age 30m1s", err)
+		}
+	})
+}
+
+func TestGetRawSessionRevocationChecker(t *testing.T) {
+	ctx := context.Background()
+	db := &MockDBTX{}
+	for _, key := range []string{"revoked", "active", "expired"} {
+		expireDate := time.Now().Add(time.Hour)
+		if key == "expired" {
+			expireDate = time.Now().Add(-time.Hour)
+		}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{key}).
+			Return(newMockRow(key, "data", expireDate))
+	}
+
+	var checked []string
+	denylist := map[string]bool{"revoked": true, "expired": true}
+	client, _ := NewClient(ClientConfig{
+		DB:        db,
+		SecretKey: "test-secret",
+		RevocationChecker: func(ctx context.Context, sessionKey string) (bool, error) {
+			checked = append(checked, sessionKey)
+			return denylist[sessionKey], nil
+		},
+	})
+
+	if _, err := client.GetRawSession(ctx, "revoked"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("GetRawSession(revoked) error = %v, want ErrSessionRevoked", err)
+	}
+	if session, err := client.GetRawSession(ctx, "active"); err != nil || session.SessionKey != "active" {
+		t.Errorf("GetRawSession(active) = %v, %v", session, err)
+	}
+	if _, err := client.GetRawSession(ctx, "expired"); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("GetRawSession(expired) error = %v, want ErrSessionExpired", err)
+	}
+	if !reflect.DeepEqual(checked, []string{"revoked", "active"}) {
+		t.Errorf("RevocationChecker called for %v, want only unexpired sessions", checked)
+	}
+
+	// A failing denylist fails closed
+	errDenylist := errors.New("redis unavailable")
+	failing, _ := NewClient(ClientConfig{
+		DB:                db,
+		SecretKey:         "test-secret",
+		RevocationChecker: func(context.Context, string) (bool, error) { return false, errDenylist },
+	})
+	if _, err := failing.GetRawSession(ctx, "active"); !errors.Is(err, errDenylist) {
+		t.Errorf("GetRawSession() error = %v, want the checker error", err)
+	}
+}
+
+func TestTouchSession(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	setup := func(tag string) (*Client, *MockDBTX, *RawSession) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag(tag), nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", MaxAge: time.Hour, Now: clock.Now})
+		sessionData, _ := client.signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, true)
+		return client, db, &RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: clock.now.Add(time.Hour)}
+	}
+
+	t.Run("extends expiry and signature", func(t *testing.T) {
+		client, db, session := setup("UPDATE 1")
+		clock.Advance(50 * time.Minute)
+
+		touched, err := client.TouchSession(ctx, session)
+		if err != nil {
+			t.Fatalf("TouchSession() error = %v", err)
+		}
+		if want := clock.now.Add(time.Hour); !touched.ExpireDate.Equal(want) {
+			t.Errorf("ExpireDate = %v, want %v", touched.ExpireDate, want)
+		}
+		if touched.SessionData == session.SessionData {
+			t.Errorf("SessionData = %q, want a fresh signature", touched.SessionData)
+		}
+		db.AssertCalled(t, "Exec", mock.Anything, sqlContains("UPDATE django_session"),
+			[]interface{}{"abc", touched.SessionData, touched.ExpireDate})
+
+		// The fresh timestamp keeps the session valid past the original MaxAge
+		clock.Advance(30 * time.Minute)
+		if userID, err := client.DecodeSessionUserID(touched.SessionData); err != nil || userID != "42" {
+			t.Errorf("DecodeSessionUserID() = %q, %v", userID, err)
+		}
+	})
+
+	t.Run("deleted session", func(t *testing.T) {
+		client, _, session := setup("UPDATE 0")
+		if _, err := client.TouchSession(ctx, session); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("TouchSession() error = %v, want ErrSessionNotFound", err)
+		}
+	})
+
+	t.Run("writes expiry in UTC with a non-UTC clock", func(t *testing.T) {
+		now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+9", 9*3600))
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", MaxAge: time.Hour,
+			Now: func() time.Time { return now }})
+		sessionData, _ := client.signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, true)
+
+		touched, err := client.TouchSession(ctx, &RawSession{SessionKey: "abc", SessionData: sessionData})
+		if err != nil {
+			t.Fatalf("TouchSession() error = %v", err)
+		}
+		want := time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC)
+		if touched.ExpireDate.Location() != time.UTC || !touched.ExpireDate.Equal(want) {
+			t.Errorf("ExpireDate = %v, want %v", touched.ExpireDate, want)
+		}
+		// The wall clock reaching a timestamp without time zone column must be UTC's
+		db.AssertCalled(t, "Exec", mock.Anything, sqlContains("UPDATE django_session"),
+			[]interface{}{"abc", touched.SessionData, want})
+
+		if _, err := client.UpdateSession(ctx, "abc", sessionData, now); err != nil {
+			t.Fatalf("UpdateSession() error = %v", err)
+		}
+		db.AssertCalled(t, "Exec", mock.Anything, sqlContains("UPDATE django_session"),
+			[]interface{}{"abc", sessionData, now.UTC()})
+	})
+
+	t.Run("signature too old", func(t *testing.T) {
+		client, db, session := setup("UPDATE 1")
+		clock.Advance(2 * time.Hour)
+		if _, err := client.TouchSession(ctx, session); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("TouchSession() error = %v, want ErrSessionExpired", err)
+		}
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetRawSessionByteaData(t *testing.T) {
+	ctx := context.Background()
+	sessionData, _ := EncodeSessionData("42", "test-secret", map[string]interface{}{"page_views": 1})
+
+	t.Run("GetRawSession", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", []byte(sessionData), time.Now().Add(time.Hour)))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		session, err := client.GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionData != sessionData || session.EncodedSize != len(sessionData) {
+			t.Errorf("SessionData = %q (size %d), want %q", session.SessionData, session.EncodedSize, sessionData)
+		}
+		if userID, err := client.DecodeSessionUserID(session.SessionData); err != nil || userID != "42" {
+			t.Errorf("DecodeSessionUserID() = %q, %v, want 42", userID, err)
+		}
+	})
+
+	t.Run("IncrementSessionValue", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("FOR UPDATE"), []interface{}{"abc"}).
+			Return(newMockRow([]byte(sessionData), time.Now().Add(time.Hour)))
+		tx.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		if value, err := client.IncrementSessionValue(ctx, "abc", "page_views", 1); err != nil || value != 2 {
+			t.Errorf("IncrementSessionValue() = %v, %v, want 2", value, err)
+		}
+	})
+
+	t.Run("CycleKey", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{"abc"}).
+			Return(newMockRow([]byte(sessionData), time.Now().Add(time.Hour)))
+		tx.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		session, err := client.CycleKey(ctx, "abc")
+		if err != nil {
+			t.Fatalf("CycleKey() error = %v", err)
+		}
+		if session.SessionData != sessionData {
+			t.Errorf("CycleKey() SessionData = %q, want %q", session.SessionData, sessionData)
+		}
+	})
+}
+
+func TestSignatureAge(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", MaxAge: time.Hour, Now: clock.Now})
+	sessionData, _ := client.signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, true)
+
+	// MaxAge does not apply: the age is reported even past it
+	clock.Advance(90 * time.Minute)
+	if age, err := client.SignatureAge(sessionData); err != nil || age != 90*time.Minute {
+		t.Errorf("SignatureAge() = %v, %v, want 1h30m0s", age, err)
+	}
+
+	other, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "other-secret", Now: clock.Now})
+	if _, err := other.SignatureAge(sessionData); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("SignatureAge() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestGetRawSessionExtra(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("two extra columns", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains(`expire_date, "created_at", "ip_address"`), []interface{}{"abc"}).
+			Return(newMockRow("abc", "data", time.Now().Add(time.Hour), createdAt, "203.0.113.7"))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		session, extra, err := client.GetRawSessionExtra(ctx, "abc", "created_at", "ip_address")
+		if err != nil {
+			t.Fatalf("GetRawSessionExtra() error = %v", err)
+		}
+		if session.SessionKey != "abc" || session.SessionData != "data" {
+			t.Errorf("session = %+v", session)
+		}
+		want := map[string]interface{}{"created_at": createdAt, "ip_address": "203.0.113.7"}
+		if !reflect.DeepEqual(extra, want) {
+			t.Errorf("extra = %v, want %v", extra, want)
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+			Return(newMockRow("abc", "data", time.Now().Add(-time.Hour), createdAt))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		if _, _, err := client.GetRawSessionExtra(ctx, "abc", "created_at"); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("GetRawSessionExtra() error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("invalid column names", func(t *testing.T) {
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		for _, column := range []string{"", "1st", "ip address", `ip"; DROP TABLE django_session; --`, "a.b", strings.Repeat("c", 64)} {
+			if _, _, err := client.GetRawSessionExtra(ctx, "abc", "created_at", column); err == nil {
+				t.Errorf("GetRawSessionExtra(%q) expected error", column)
+			}
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,34 @@
+package django_session
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONCodec marshals and unmarshals session payloads. Implementations such as jsoniter's
+// ConfigCompatibleWithStandardLibrary satisfy it directly.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdJSONCodec is the default JSONCodec backed by encoding/json
+type StdJSONCodec struct {
+	// UseNumber decodes numbers as json.Number instead of float64, preserving large integers
+	UseNumber bool
+}
+
+// Marshal encodes v with json.Marshal
+func (c StdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes data into v, honouring UseNumber
+func (c StdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	if !c.UseNumber {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,100 @@
+package django_session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingCodec wraps StdJSONCodec and counts calls
+type recordingCodec struct {
+	StdJSONCodec
+	marshals   int
+	unmarshals int
+}
+
+func (r *recordingCodec) Marshal(v interface{}) ([]byte, error) {
+	r.marshals++
+	return r.StdJSONCodec.Marshal(v)
+}
+
+func (r *recordingCodec) Unmarshal(data []byte, v interface{}) error {
+	r.unmarshals++
+	return r.StdJSONCodec.Unmarshal(data, v)
+}
+
+func TestSignerCustomCodec(t *testing.T) {
+	codec := &recordingCodec{}
+	signer := &DjangoSigner{SecretKey: "test-secret", Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256", Codec: codec}
+
+	signed, err := signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, false)
+	if err != nil {
+		t.Fatalf("SignObject() error = %v", err)
+	}
+	result, err := signer.UnsignObject(signed, nil)
+	if err != nil {
+		t.Fatalf("UnsignObject() error = %v", err)
+	}
+	if result["_auth_user_id"] != "42" {
+		t.Errorf("_auth_user_id = %v, want 42", result["_auth_user_id"])
+	}
+	if codec.marshals != 1 || codec.unmarshals != 1 {
+		t.Errorf("codec calls: marshal %d, unmarshal %d, want 1 each", codec.marshals, codec.unmarshals)
+	}
+}
+
+func TestStdJSONCodecUseNumber(t *testing.T) {
+	data := []byte(`{"_auth_user_id": 9007199254740993}`)
+
+	var lossy map[string]interface{}
+	if err := (StdJSONCodec{}).Unmarshal(data, &lossy); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if _, ok := lossy["_auth_user_id"].(float64); !ok {
+		t.Errorf("Expected float64 without UseNumber, got %T", lossy["_auth_user_id"])
+	}
+
+	var exact map[string]interface{}
+	if err := (StdJSONCodec{UseNumber: true}).Unmarshal(data, &exact); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if n, ok := exact["_auth_user_id"].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Errorf("Expected json.Number 9007199254740993, got %T %v", exact["_auth_user_id"], exact["_auth_user_id"])
+	}
+
+	userID, err := extractUserID(exact)
+	if err != nil || userID != "9007199254740993" {
+		t.Errorf("extractUserID() = %v, %v, want 9007199254740993", userID, err)
+	}
+}
+
+func TestClientJSONCodecInBatchListing(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("1", secretKey, nil)
+
+	db := &MockDBTX{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&MockRows{
+		total: 3,
+		row: func(i int) []interface{} {
+			return []interface{}{fmt.Sprintf("key%d", i), sessionData, time.Now().Add(time.Hour)}
+		},
+	}, nil)
+
+	codec := &recordingCodec{StdJSONCodec: StdJSONCodec{UseNumber: true}}
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey, JSONCodec: codec})
+
+	sessions, err := client.ListActiveSessions(context.Background(), 10, 0)
+	if err != nil {
+		t.Fatalf("ListActiveSessions() error = %v", err)
+	}
+	if len(sessions) != 3 {
+		t.Errorf("ListActiveSessions() returned %d sessions, want 3", len(sessions))
+	}
+	if codec.unmarshals != 3 {
+		t.Errorf("codec unmarshal calls = %d, want 3", codec.unmarshals)
+	}
+}
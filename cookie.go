@@ -0,0 +1,153 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// CookieConfig configures how session cookies are written to responses
+type CookieConfig struct {
+	Name            string        // Cookie name (default: "sessionid")
+	Domain          string        // Optional: cookie domain
+	Path            string        // Cookie path (default: "/")
+	Secure          bool          // Send cookie over HTTPS only
+	DisableHttpOnly bool          // Let JavaScript read the cookie, like SESSION_COOKIE_HTTPONLY = False (default: HttpOnly)
+	SameSite        http.SameSite // SameSite attribute (default: http.SameSiteLaxMode, like Django)
+	Production      bool          // Optional: enables production checks (warns when Secure is false)
+	Logger          *slog.Logger  // Optional: logger for configuration warnings (default: slog.Default())
+}
+
+// CookieWriter writes and clears Django session cookies
+type CookieWriter struct {
+	config CookieConfig
+}
+
+// NewCookieWriter validates the cookie configuration and creates a CookieWriter.
+// SameSite=None without Secure is rejected because browsers ignore such cookies.
+func NewCookieWriter(config CookieConfig) (*CookieWriter, error) {
+	if config.Name == "" {
+		config.Name = "sessionid" // Django default
+	}
+	if config.Path == "" {
+		config.Path = "/"
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	if config.SameSite == http.SameSiteNoneMode && !config.Secure {
+		return nil, errors.New("SameSite=None requires Secure=true")
+	}
+	if config.Production && !config.Secure {
+		config.Logger.Warn("session cookie is not marked Secure in production", "cookie", config.Name)
+	}
+
+	return &CookieWriter{config: config}, nil
+}
+
+// SetSessionCookie writes the session cookie expiring at expireDate
+func (w *CookieWriter) SetSessionCookie(rw http.ResponseWriter, sessionKey string, expireDate time.Time) {
+	http.SetCookie(rw, w.cookie(sessionKey, expireDate, int(time.Until(expireDate).Seconds())))
+}
+
+// ClearSessionCookie removes the session cookie from the browser
+func (w *CookieWriter) ClearSessionCookie(rw http.ResponseWriter) {
+	http.SetCookie(rw, w.cookie("", time.Unix(0, 0), -1))
+}
+
+// cookie builds the http.Cookie for the configured attributes
+func (w *CookieWriter) cookie(value string, expires time.Time, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     w.config.Name,
+		Value:    value,
+		Domain:   w.config.Domain,
+		Path:     w.config.Path,
+		Expires:  expires,
+		MaxAge:   maxAge,
+		Secure:   w.config.Secure,
+		HttpOnly: !w.config.DisableHttpOnly,
+		SameSite: w.config.SameSite,
+	}
+}
+
+// CookieWriter returns the writer the client uses for session cookies (ClientConfig.CookieWriter)
+func (c *Client) CookieWriter() *CookieWriter {
+	return c.cookieWriter
+}
+
+// EnsureSession returns the request's session if its cookie is valid, or creates an empty
+// anonymous session and sets its cookie via the client's CookieWriter. created reports
+// whether a new session was made. Database errors other than a missing or expired
+// session are returned rather than replaced by a new session.
+func (c *Client) EnsureSession(w http.ResponseWriter, r *http.Request) (*RawSession, bool, error) {
+	if cookie, err := r.Cookie(c.sessionCookieName); err == nil && cookie.Value != "" {
+		session, err := c.GetRawSession(r.Context(), cookie.Value)
+		if err == nil {
+			return session, false, nil
+		}
+		if !errors.Is(err, ErrSessionNotFound) && !errors.Is(err, ErrSessionExpired) {
+			return nil, false, err
+		}
+	}
+
+	session, err := c.createAnonymousSession(r.Context(), nil, time.Time{})
+	if err != nil {
+		return nil, false, err
+	}
+	c.cookieWriter.SetSessionCookie(w, session.SessionKey, session.ExpireDate)
+	return session, true, nil
+}
+
+// cookieSignerKeyPrefix is prepended to the secret key for signed cookies, as in Django's
+// django.core.signing.get_cookie_signer
+const cookieSignerKeyPrefix = "django.http.cookies"
+
+// UnsignCookieValue recovers the session key from a session cookie whose value was signed
+// like Django's response.set_signed_cookie(name, key, salt=salt), i.e. what
+// request.get_signed_cookie(name, salt=salt) returns. The session cookie name is part of
+// the salt, as in Django. Returns ErrInvalidSignature for tampered or malformed values.
+func (c *Client) UnsignCookieValue(value, salt string) (string, error) {
+	signer, err := c.cookieSigner(salt)
+	if err != nil {
+		return "", err
+	}
+	sessionKey, err := signer.UnsignTimestamp(value, nil)
+	if err != nil && !errors.Is(err, ErrInvalidSignature) {
+		return "", fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return sessionKey, err
+}
+
+// SignCookieValue signs a session key for use as a signed session cookie value, like
+// Django's response.set_signed_cookie(name, key, salt=salt). It is the inverse of
+// UnsignCookieValue.
+func (c *Client) SignCookieValue(sessionKey, salt string) (string, error) {
+	signer, err := c.cookieSigner(salt)
+	if err != nil {
+		return "", err
+	}
+	return signer.SignTimestamp(sessionKey)
+}
+
+// cookieSigner returns the current signer adapted to Django's get_cookie_signer: the
+// session cookie name is prepended to salt and the cookie prefix to every secret key
+func (c *Client) cookieSigner(salt string) (*DjangoSigner, error) {
+	current, err := c.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	signer := *current
+	signer.Salt = c.sessionCookieName + salt
+	signer.SecretKey = cookieSignerKeyPrefix + current.SecretKey
+	signer.FallbackKeys = make([]string, len(current.FallbackKeys))
+	for i, key := range current.FallbackKeys {
+		signer.FallbackKeys[i] = cookieSignerKeyPrefix + key
+	}
+	return &signer, nil
+}
@@ -0,0 +1,226 @@
+package django_session
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewCookieWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CookieConfig
+		wantErr bool
+	}{
+		{"defaults", CookieConfig{}, false},
+		{"secure lax", CookieConfig{Secure: true, SameSite: http.SameSiteLaxMode}, false},
+		{"samesite none with secure", CookieConfig{Secure: true, SameSite: http.SameSiteNoneMode}, false},
+		{"samesite none without secure", CookieConfig{Secure: false, SameSite: http.SameSiteNoneMode}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCookieWriter(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCookieWriter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewCookieWriterProductionWarning(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	if _, err := NewCookieWriter(CookieConfig{Production: true, Logger: logger}); err != nil {
+		t.Fatalf("NewCookieWriter() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "not marked Secure") {
+		t.Errorf("Expected Secure warning, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := NewCookieWriter(CookieConfig{Production: true, Secure: true, Logger: logger}); err != nil {
+		t.Fatalf("NewCookieWriter() error = %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warning for Secure cookie, got %q", buf.String())
+	}
+}
+
+func TestCookieWriterSetAndClear(t *testing.T) {
+	writer, err := NewCookieWriter(CookieConfig{Secure: true})
+	if err != nil {
+		t.Fatalf("NewCookieWriter() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	writer.SetSessionCookie(w, "abc123", time.Now().Add(time.Hour))
+
+	header := w.Header().Get("Set-Cookie")
+	for _, want := range []string{"sessionid=abc123", "Path=/", "Secure", "HttpOnly", "SameSite=Lax", "Max-Age="} {
+		if !strings.Contains(header, want) {
+			t.Errorf("Set-Cookie %q missing %q", header, want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	writer.ClearSessionCookie(w)
+
+	header = w.Header().Get("Set-Cookie")
+	if !strings.Contains(header, "sessionid=;") || !strings.Contains(header, "Max-Age=0") {
+		t.Errorf("Unexpected clearing Set-Cookie %q", header)
+	}
+}
+
+func TestCookieWriterDisableHttpOnly(t *testing.T) {
+	writer, err := NewCookieWriter(CookieConfig{DisableHttpOnly: true})
+	if err != nil {
+		t.Fatalf("NewCookieWriter() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	writer.SetSessionCookie(w, "abc123", time.Now().Add(time.Hour))
+	if header := w.Header().Get("Set-Cookie"); strings.Contains(header, "HttpOnly") {
+		t.Errorf("Set-Cookie %q is HttpOnly, want it readable by JavaScript", header)
+	}
+}
+
+func TestEnsureSession(t *testing.T) {
+	newRequest := func(cookie string) (*http.Request, *httptest.ResponseRecorder) {
+		r := httptest.NewRequest("GET", "/cart", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "sessionid", Value: cookie})
+		}
+		return r, httptest.NewRecorder()
+	}
+
+	t.Run("reuses valid session", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"guest"}).
+			Return(newMockRow("guest", "data", time.Now().Add(time.Hour)))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		r, w := newRequest("guest")
+		session, created, err := client.EnsureSession(w, r)
+		if err != nil {
+			t.Fatalf("EnsureSession() error = %v", err)
+		}
+		if created || session.SessionKey != "guest" {
+			t.Errorf("EnsureSession() = %+v, created %v, want existing session", session, created)
+		}
+		if header := w.Header().Get("Set-Cookie"); header != "" {
+			t.Errorf("Expected no cookie to be set, got %q", header)
+		}
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	for name, cookie := range map[string]string{"no cookie": "", "expired cookie": "stale"} {
+		t.Run("creates session with "+name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"stale"}).
+				Return(newMockRow("stale", "data", time.Now().Add(-time.Hour)))
+			db.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).
+				Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+			client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			r, w := newRequest(cookie)
+			session, created, err := client.EnsureSession(w, r)
+			if err != nil {
+				t.Fatalf("EnsureSession() error = %v", err)
+			}
+			if !created || !IsValidSessionKey(session.SessionKey) {
+				t.Errorf("EnsureSession() = %+v, created %v, want new session", session, created)
+			}
+			if client.IsAuthenticated(session.SessionData) {
+				t.Error("Expected an anonymous session")
+			}
+			header := w.Header().Get("Set-Cookie")
+			if !strings.Contains(header, "sessionid="+session.SessionKey) || !strings.Contains(header, "HttpOnly") {
+				t.Errorf("Unexpected Set-Cookie %q", header)
+			}
+		})
+	}
+
+	t.Run("database error is returned", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+			Return(newErrorRow(errors.New("connection refused"), 3))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		r, w := newRequest("guest")
+		if _, _, err := client.EnsureSession(w, r); err == nil {
+			t.Error("EnsureSession() expected error")
+		}
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUnsignCookieValue(t *testing.T) {
+	// response.set_signed_cookie("sessionid", "abcdefghijklmnopqrstuvwxyz012345",
+	// salt="session-wrap") with SECRET_KEY = "test-secret-key"
+	const djangoSigned = "abcdefghijklmnopqrstuvwxyz012345:1r31eq:4Rfo87p9rNYsdI5Cz1nZ5t-vCh87sT_zHxWnkvf9n6g"
+	const sessionKey = "abcdefghijklmnopqrstuvwxyz012345"
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret-key"})
+
+	if got, err := client.UnsignCookieValue(djangoSigned, "session-wrap"); err != nil || got != sessionKey {
+		t.Errorf("UnsignCookieValue() = %q, %v, want %q", got, err, sessionKey)
+	}
+
+	rotated, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "new-secret", SecretKeyFallbacks: []string{"test-secret-key"}})
+	if got, err := rotated.UnsignCookieValue(djangoSigned, "session-wrap"); err != nil || got != sessionKey {
+		t.Errorf("UnsignCookieValue() with fallback key = %q, %v, want %q", got, err, sessionKey)
+	}
+
+	otherName, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret-key", SessionCookieName: "othersession"})
+
+	tests := []struct {
+		name   string
+		client *Client
+		value  string
+		salt   string
+	}{
+		{"wrong salt", client, djangoSigned, "other"},
+		{"cookie name is part of the salt", otherName, djangoSigned, "session-wrap"},
+		{"tampered key", client, "abcdefghijklmnopqrstuvwxyz012346" + djangoSigned[32:], "session-wrap"},
+		{"unsigned key", client, sessionKey, "session-wrap"},
+		{"empty", client, "", "session-wrap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.client.UnsignCookieValue(tt.value, tt.salt); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("UnsignCookieValue() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestSignCookieValue(t *testing.T) {
+	// Same cookie as in TestUnsignCookieValue, signed at its timestamp "1r31eq"
+	const djangoSigned = "abcdefghijklmnopqrstuvwxyz012345:1r31eq:4Rfo87p9rNYsdI5Cz1nZ5t-vCh87sT_zHxWnkvf9n6g"
+	const sessionKey = "abcdefghijklmnopqrstuvwxyz012345"
+	timestamp, _ := Base62Decode("1r31eq")
+
+	client, _ := NewClient(ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+		Now:       func() time.Time { return time.Unix(timestamp, 0) },
+	})
+
+	signed, err := client.SignCookieValue(sessionKey, "session-wrap")
+	if err != nil || signed != djangoSigned {
+		t.Errorf("SignCookieValue() = %q, %v, want %q", signed, err, djangoSigned)
+	}
+	if got, err := client.UnsignCookieValue(signed, "session-wrap"); err != nil || got != sessionKey {
+		t.Errorf("UnsignCookieValue(SignCookieValue()) = %q, %v, want %q", got, err, sessionKey)
+	}
+}
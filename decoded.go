@@ -0,0 +1,76 @@
+package django_session
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// DecodeSession verifies the session's data (applying MaxAge) and returns it with the
+// decoded payload, whose values can be read with the typed accessors. Unlike
+// DecodeSessionUserID, anonymous sessions decode too; UserID is then empty.
+func (c *Client) DecodeSession(session *RawSession) (*DecodedSession, error) {
+	data, err := c.DecodeSessionMap(session.SessionData)
+	if err != nil {
+		return nil, err
+	}
+	decoded := &DecodedSession{RawSession: *session, Data: data}
+	if _, ok := data["_auth_user_id"]; ok {
+		userID, err := c.userIDFromMap(data)
+		if err != nil {
+			return nil, err
+		}
+		decoded.UserID = userID
+	}
+	return decoded, nil
+}
+
+// GetString returns the string stored under key. ok is false when the key is missing or
+// holds another type.
+func (s *DecodedSession) GetString(key string) (string, bool) {
+	value, ok := s.Data[key].(string)
+	return value, ok
+}
+
+// GetInt returns the integer stored under key, accepting JSON numbers decoded as float64
+// or json.Number. ok is false when the key is missing, holds another type, or holds a
+// number that is fractional or out of int64 range.
+func (s *DecodedSession) GetInt(key string) (int64, bool) {
+	switch v := s.Data[key].(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// GetBool returns the boolean stored under key. ok is false when the key is missing or
+// holds another type.
+func (s *DecodedSession) GetBool(key string) (bool, bool) {
+	value, ok := s.Data[key].(bool)
+	return value, ok
+}
+
+// GetBoolCoerce interprets the value under key as a flag for serializers that store
+// booleans as numbers or strings: true, 1, "true" and "1" are true; anything else,
+// including a missing key, is false.
+func (s *DecodedSession) GetBoolCoerce(key string) bool {
+	switch v := s.Data[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "1"
+	default:
+		n, ok := s.GetInt(key)
+		return ok && n == 1
+	}
+}
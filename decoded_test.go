@@ -0,0 +1,160 @@
+package django_session
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeSession(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"_auth_user_hash": "abc123"})
+	raw := &RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: time.Now().Add(time.Hour)}
+
+	decoded, err := client.DecodeSession(raw)
+	if err != nil {
+		t.Fatalf("DecodeSession() error = %v", err)
+	}
+	if decoded.SessionKey != "abc" || decoded.UserID != "42" {
+		t.Errorf("DecodeSession() = %+v", decoded)
+	}
+	if hash, ok := decoded.GetString("_auth_user_hash"); !ok || hash != "abc123" {
+		t.Errorf("GetString(_auth_user_hash) = %q, %v", hash, ok)
+	}
+
+	anonymous, _ := client.signer.SignObject(map[string]interface{}{"cart": "1"}, true)
+	decoded, err = client.DecodeSession(&RawSession{SessionKey: "anon", SessionData: anonymous})
+	if err != nil || decoded.UserID != "" {
+		t.Errorf("DecodeSession(anonymous) = %+v, %v, want no user ID", decoded, err)
+	}
+
+	forged, _ := EncodeSessionData("42", "other-secret", nil)
+	if _, err := client.DecodeSession(&RawSession{SessionData: forged}); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeSession(forged) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDecodedSessionAccessors(t *testing.T) {
+	session := &DecodedSession{Data: map[string]interface{}{
+		"name":       "alice",
+		"count":      float64(7),
+		"big":        json.Number("9007199254740993"),
+		"ratio":      1.5,
+		"huge":       1e20,
+		"bad_number": json.Number("1.5"),
+		"native":     12,
+		"verified":   true,
+		"nothing":    nil,
+	}}
+
+	stringTests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"name", "alice", true},
+		{"count", "", false},
+		{"verified", "", false},
+		{"missing", "", false},
+	}
+	for _, tt := range stringTests {
+		if got, ok := session.GetString(tt.key); got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetString(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	intTests := []struct {
+		key    string
+		want   int64
+		wantOK bool
+	}{
+		{"count", 7, true},
+		{"big", 9007199254740993, true},
+		{"native", 12, true},
+		{"ratio", 0, false},
+		{"huge", 0, false},
+		{"bad_number", 0, false},
+		{"name", 0, false},
+		{"nothing", 0, false},
+		{"missing", 0, false},
+	}
+	for _, tt := range intTests {
+		if got, ok := session.GetInt(tt.key); got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetInt(%q) = %d, %v, want %d, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	boolTests := []struct {
+		key    string
+		want   bool
+		wantOK bool
+	}{
+		{"verified", true, true},
+		{"count", false, false},
+		{"name", false, false},
+		{"missing", false, false},
+	}
+	for _, tt := range boolTests {
+		if got, ok := session.GetBool(tt.key); got != tt.want || ok != tt.wantOK {
+			t.Errorf("GetBool(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDecodedSessionGetBoolCoerce(t *testing.T) {
+	session := &DecodedSession{Data: map[string]interface{}{
+		"bool_true":    true,
+		"bool_false":   false,
+		"float_one":    float64(1),
+		"float_zero":   float64(0),
+		"float_two":    float64(2),
+		"float_frac":   1.5,
+		"number_one":   json.Number("1"),
+		"number_zero":  json.Number("0"),
+		"int_one":      1,
+		"int64_one":    int64(1),
+		"string_true":  "true",
+		"string_one":   "1",
+		"string_false": "false",
+		"string_zero":  "0",
+		"string_yes":   "yes",
+		"string_title": "True",
+		"string_empty": "",
+		"nothing":      nil,
+		"list":         []interface{}{true},
+	}}
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"bool_true", true},
+		{"bool_false", false},
+		{"float_one", true},
+		{"float_zero", false},
+		{"float_two", false},
+		{"float_frac", false},
+		{"number_one", true},
+		{"number_zero", false},
+		{"int_one", true},
+		{"int64_one", true},
+		{"string_true", true},
+		{"string_one", true},
+		{"string_false", false},
+		{"string_zero", false},
+		{"string_yes", false},
+		{"string_title", false},
+		{"string_empty", false},
+		{"nothing", false},
+		{"list", false},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if got := session.GetBoolCoerce(tt.key); got != tt.want {
+			t.Errorf("GetBoolCoerce(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SessionDiagnostics describes the result of inspecting a signed session payload
+type SessionDiagnostics struct {
+	SignatureValid bool                   // Signature matches the secret key and session salt
+	Compressed     bool                   // Payload carries the "." compression prefix
+	SignedAt       time.Time              // Timestamp embedded by Django's TimestampSigner
+	Age            time.Duration          // Time elapsed since SignedAt
+	Expired        bool                   // Age exceeds Django's default SESSION_COOKIE_AGE (two weeks)
+	Data           map[string]interface{} // Decoded payload (nil if decoding failed)
+	Err            error                  // First error encountered, if any
+}
+
+// VerifySession performs a dry-run verification of Django session data and reports
+// every stage of the decode (signature, compression, timestamp, payload).
+// The returned diagnostics are never nil; the error mirrors SessionDiagnostics.Err.
+// An expired session is still decoded and reported with Expired set, not as an error.
+func VerifySession(sessionData, secretKey string) (*SessionDiagnostics, error) {
+	signer := &DjangoSigner{
+		SecretKey: secretKey,
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+
+		DiagnoseSignatures: true,
+	}
+
+	diag := &SessionDiagnostics{}
+	fail := func(err error) (*SessionDiagnostics, error) {
+		diag.Err = err
+		return diag, err
+	}
+
+	// Verify signature
+	value, err := signer.Unsign(sessionData)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidSignature) {
+			err = fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		return fail(err)
+	}
+	diag.SignatureValid = true
+
+	// Extract timestamp
+	lastSepIndex := strings.LastIndex(value, signer.Sep)
+	if lastSepIndex == -1 {
+		return fail(errors.New("no timestamp separator found"))
+	}
+	base64Data := value[:lastSepIndex]
+	timestamp, err := b62Decode(value[lastSepIndex+len(signer.Sep):])
+	if err != nil {
+		return fail(fmt.Errorf("invalid timestamp: %w", err))
+	}
+	diag.SignedAt = time.Unix(timestamp, 0)
+	diag.Age = time.Since(diag.SignedAt)
+	diag.Expired = diag.Age > defaultSessionAge
+
+	// Decode payload
+	diag.Compressed = strings.HasPrefix(base64Data, ".")
+	data, err := signer.UnsignObject(sessionData, nil)
+	if err != nil {
+		return fail(err)
+	}
+	diag.Data = data
+
+	return diag, nil
+}
+
+// InspectSessionData reports the storage format of session data without verifying its
+// signature, for telemetry over trusted rows: whether the payload is compressed, its
+// base64-decoded length (rawLen) and its length after decompression (decompressedLen,
+// equal to rawLen when uncompressed). Decompression is capped at DefaultMaxDecompressedSize.
+// It expects Django's ":" separator; use DjangoSigner.InspectSessionData for others.
+func InspectSessionData(sessionData string) (compressed bool, rawLen int, decompressedLen int, err error) {
+	return NewDjangoSigner("").InspectSessionData(sessionData)
+}
+
+// InspectSessionData is the package-level InspectSessionData for data written with this
+// signer's Sep and CompressionPrefix, capping decompression at MaxDecompressedSize
+func (ds *DjangoSigner) InspectSessionData(sessionData string) (compressed bool, rawLen int, decompressedLen int, err error) {
+	if err := validateSeparator(ds.Sep); err != nil {
+		return false, 0, 0, err
+	}
+	limit := ds.maxPayloadSize()
+	if int64(len(sessionData)) > limit {
+		return false, 0, 0, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrPayloadTooLarge, len(sessionData), limit)
+	}
+
+	// Strip signature and timestamp: payload<sep>timestamp<sep>signature
+	parts := strings.Split(sessionData, ds.Sep)
+	if len(parts) < 3 {
+		return false, 0, 0, errors.New("invalid session data format")
+	}
+	payload := strings.Join(parts[:len(parts)-2], ds.Sep)
+
+	prefix := ds.compressionPrefix()
+	compressed = strings.HasPrefix(payload, prefix)
+	data, err := b64Decode(strings.TrimPrefix(payload, prefix))
+	if err != nil {
+		return compressed, 0, 0, fmt.Errorf("base64 decode error: %w", err)
+	}
+	if !compressed {
+		return false, len(data), len(data), nil
+	}
+
+	decompressed, err := decompressData(data, limit)
+	if err != nil {
+		return true, len(data), 0, err
+	}
+	return true, len(data), len(decompressed), nil
+}
+
+// InspectSessionData is the package-level InspectSessionData using the client's
+// Separator, CompressionPrefix and MaxDecompressedSize
+func (c *Client) InspectSessionData(sessionData string) (compressed bool, rawLen int, decompressedLen int, err error) {
+	return c.signer.InspectSessionData(sessionData)
+}
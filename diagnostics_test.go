@@ -0,0 +1,191 @@
+package django_session
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySession(t *testing.T) {
+	secretKey := "test-secret-key-9k2j3n4l5k6j7h8g9f0d1s2a3f4g5h6j"
+	signer := &DjangoSigner{
+		SecretKey: secretKey,
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+
+	valid, err := EncodeSessionData("42", secretKey, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test session: %v", err)
+	}
+
+	// Sign a payload with a timestamp from 15 days ago, past the two-week session age
+	oldTimestamp := time.Now().Add(-15 * 24 * time.Hour).Unix()
+	oldValue := b64Encode([]byte(`{"_auth_user_id":"42"}`)) + ":" + b62Encode(oldTimestamp)
+	expired := oldValue + ":" + signer.signature(oldValue)
+
+	// Correctly signed, but the payload is not valid base64 JSON
+	corruptValue := "!!!not-base64!!!:" + b62Encode(time.Now().Unix())
+	corrupt := corruptValue + ":" + signer.signature(corruptValue)
+
+	t.Run("valid", func(t *testing.T) {
+		diag, err := VerifySession(valid, secretKey)
+		if err != nil {
+			t.Fatalf("VerifySession() error = %v", err)
+		}
+		if !diag.SignatureValid {
+			t.Error("Expected SignatureValid to be true")
+		}
+		if !diag.Compressed {
+			t.Error("Expected Compressed to be true")
+		}
+		if diag.Age < 0 || diag.Age > time.Minute {
+			t.Errorf("Unexpected age %v", diag.Age)
+		}
+		if diag.Expired {
+			t.Error("Expected Expired to be false")
+		}
+		if diag.Data["_auth_user_id"] != "42" {
+			t.Errorf("_auth_user_id = %v, want 42", diag.Data["_auth_user_id"])
+		}
+		if diag.Err != nil {
+			t.Errorf("Expected no diagnostic error, got %v", diag.Err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		diag, err := VerifySession(expired, secretKey)
+		if err != nil {
+			t.Fatalf("VerifySession() error = %v", err)
+		}
+		if diag.Compressed {
+			t.Error("Expected Compressed to be false")
+		}
+		if diag.SignedAt.Unix() != oldTimestamp {
+			t.Errorf("SignedAt = %v, want %v", diag.SignedAt.Unix(), oldTimestamp)
+		}
+		if diag.Age < 15*24*time.Hour-time.Minute {
+			t.Errorf("Expected age of about 15 days, got %v", diag.Age)
+		}
+		if !diag.Expired {
+			t.Error("Expected Expired to be true")
+		}
+		if diag.Data["_auth_user_id"] != "42" || diag.Err != nil {
+			t.Errorf("Expected the expired payload to still decode, got %v, %v", diag.Data, diag.Err)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		diag, err := VerifySession(valid+"x", secretKey)
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifySession() error = %v, want ErrInvalidSignature", err)
+		}
+		if diag == nil || diag.SignatureValid {
+			t.Error("Expected diagnostics with SignatureValid false")
+		}
+		if diag.Err != err {
+			t.Errorf("diag.Err = %v, want %v", diag.Err, err)
+		}
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		diag, err := VerifySession(corrupt, secretKey)
+		if err == nil {
+			t.Fatal("VerifySession() expected error but got none")
+		}
+		if !diag.SignatureValid {
+			t.Error("Expected SignatureValid to be true for corrupt payload")
+		}
+		if diag.Data != nil {
+			t.Errorf("Expected nil data, got %v", diag.Data)
+		}
+	})
+}
+
+func TestInspectSessionData(t *testing.T) {
+	signer := &DjangoSigner{SecretKey: "test-secret", Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+	payload := map[string]interface{}{"_auth_user_id": "42", "cart": strings.Repeat("item,", 200)}
+	jsonLen := len(mustMarshal(t, payload))
+
+	compressedData, _ := signer.SignObject(payload, true)
+	plainData, _ := signer.SignObject(payload, false)
+
+	t.Run("compressed", func(t *testing.T) {
+		compressed, rawLen, decompressedLen, err := InspectSessionData(compressedData)
+		if err != nil {
+			t.Fatalf("InspectSessionData() error = %v", err)
+		}
+		if !compressed {
+			t.Error("Expected compressed payload")
+		}
+		if rawLen >= decompressedLen {
+			t.Errorf("rawLen %d should be smaller than decompressedLen %d", rawLen, decompressedLen)
+		}
+		if decompressedLen != jsonLen {
+			t.Errorf("decompressedLen = %d, want %d", decompressedLen, jsonLen)
+		}
+	})
+
+	t.Run("uncompressed", func(t *testing.T) {
+		compressed, rawLen, decompressedLen, err := InspectSessionData(plainData)
+		if err != nil {
+			t.Fatalf("InspectSessionData() error = %v", err)
+		}
+		if compressed || rawLen != jsonLen || decompressedLen != jsonLen {
+			t.Errorf("InspectSessionData() = %v, %d, %d, want false, %d, %d", compressed, rawLen, decompressedLen, jsonLen, jsonLen)
+		}
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		custom := *signer
+		custom.Sep = "/"
+		customData, _ := custom.SignObject(payload, true)
+
+		compressed, _, decompressedLen, err := custom.InspectSessionData(customData)
+		if err != nil || !compressed || decompressedLen != jsonLen {
+			t.Errorf("InspectSessionData() = %v, %d, %v, want true, %d", compressed, decompressedLen, err, jsonLen)
+		}
+
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", Separator: "/"})
+		if _, _, decompressedLen, err := client.InspectSessionData(customData); err != nil || decompressedLen != jsonLen {
+			t.Errorf("Client.InspectSessionData() = %d, %v, want %d", decompressedLen, err, jsonLen)
+		}
+		if _, _, _, err := InspectSessionData(customData); err == nil {
+			t.Error("InspectSessionData() with the default separator expected error")
+		}
+	})
+
+	t.Run("signature is not checked", func(t *testing.T) {
+		forged := compressedData[:len(compressedData)-4] + "AAAA"
+		if _, _, _, err := InspectSessionData(forged); err != nil {
+			t.Errorf("InspectSessionData() error = %v, want nil for trusted rows", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, data := range []string{"", "no-separators", "!!!:abc:sig"} {
+			if _, _, _, err := InspectSessionData(data); err == nil {
+				t.Errorf("InspectSessionData(%q) expected error", data)
+			}
+		}
+	})
+
+	t.Run("oversized", func(t *testing.T) {
+		huge := strings.Repeat("A", int(DefaultMaxDecompressedSize)+1) + ":ts:sig"
+		if _, _, _, err := InspectSessionData(huge); !errors.Is(err, ErrPayloadTooLarge) {
+			t.Errorf("InspectSessionData() error = %v, want ErrPayloadTooLarge", err)
+		}
+	})
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return data
+}
@@ -0,0 +1,61 @@
+package django_session
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultDumpsSalt is the salt django.core.signing.dumps and loads use when none is given.
+// It differs from the Signer class default ("django.core.signing.Signer").
+const DefaultDumpsSalt = "django.core.signing"
+
+// SigningOptions mirrors the keyword arguments of django.core.signing.dumps and loads
+type SigningOptions struct {
+	Salt       string        // salt= (default: DefaultDumpsSalt)
+	Serializer string        // serializer=, as a dotted path such as "django.core.signing.JSONSerializer" (default: JSON); pickle is rejected
+	Compress   bool          // compress=, Dumps only: zlib-compress the payload when that makes it shorter
+	MaxAge     time.Duration // max_age=, Loads only; zero or negative disables the age check
+}
+
+// Dumps signs obj like django.core.signing.dumps(obj, salt=..., serializer=..., compress=...)
+// with the client's secret key. The salt is passed to the signer as is: like Django, the
+// HMAC key is derived from salt + "signer", so tokens verify with signing.loads. As in
+// Django, compression is only applied when it makes the payload smaller, and the JSON is
+// encoded like json.dumps, with keys in sorted order.
+func (c *Client) Dumps(obj map[string]interface{}, opts SigningOptions) (string, error) {
+	signer, err := c.dumpsSigner(opts)
+	if err != nil {
+		return "", err
+	}
+
+	fields := make([]KeyValue, 0, len(obj))
+	for key, value := range obj {
+		fields = append(fields, KeyValue{Key: key, Value: value})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return signer.SignOrderedObject(fields, opts.Compress)
+}
+
+// Loads verifies a token made by django.core.signing.dumps, or by Dumps, with the client's
+// secret key and fallbacks and returns the object. Compressed and uncompressed tokens are
+// both accepted, as in Django; opts.Compress is ignored.
+func (c *Client) Loads(token string, opts SigningOptions) (map[string]interface{}, error) {
+	signer, err := c.dumpsSigner(opts)
+	if err != nil {
+		return nil, err
+	}
+	return signer.UnsignObject(token, maxAgeLimit(opts.MaxAge))
+}
+
+// dumpsSigner returns the client's signer configured with opts' salt after checking the
+// serializer
+func (c *Client) dumpsSigner(opts SigningOptions) (*DjangoSigner, error) {
+	if _, err := serializerFormat(opts.Serializer); err != nil {
+		return nil, err
+	}
+	salt := opts.Salt
+	if salt == "" {
+		salt = DefaultDumpsSalt
+	}
+	return c.signerWithSalt(salt)
+}
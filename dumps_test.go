@@ -0,0 +1,138 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tokens from django.core.signing.dumps with SECRET_KEY = dumpsTestSecret at Unix time
+// 1700000000, e.g. dumps({"a": 1, "b": "two"}, salt="x", serializer=JSONSerializer,
+// compress=True)
+const (
+	dumpsTestSecret = "django-insecure-dumps-test-key"
+
+	djangoDumpsSmall            = "eyJhIjoxLCJiIjoidHdvIn0:1r31eq:g5plJrqDRpvpAzqf1Dp-NwTOG9v20SjNKxwH9rB9lKI"
+	djangoDumpsSmallSaltX       = "eyJhIjoxLCJiIjoidHdvIn0:1r31eq:i1WS4Djzoud1w8hORCxttac1-QLr8X7u0J4z0Zr-muI"
+	djangoDumpsLargeCompressed  = ".eJyrVsosSc0tVrKKBjN0DZR0IAxDGMMIxjCGMUbVUKAmVkeptDi1SMlKycRIqRYAwohq1A:1r31eq:a1JnjzEIDr-PxDIYtf-uRQ9Slj5HCLqFfzKaWq9sMJ4"
+	djangoDumpsLargeCompressedX = ".eJyrVsosSc0tVrKKBjN0DZR0IAxDGMMIxjCGMUbVUKAmVkeptDi1SMlKycRIqRYAwohq1A:1r31eq:qPOpkRZptpqdyHX5FwwtDAM8ju8tbwuLNscmC6Elpz0"
+	djangoDumpsEscaping         = "eyJuYW1lIjoiWm9cdTAwZWIiLCJuZXh0IjoiL2E_Yj0xJmM9PDI-In0:1r31eq:xtakD8NsOsjPJjnSQ5Vokwpi9rvPKDnrKD4qLmoNS68"
+	djangoDumpsLargeSaltX       = "eyJpdGVtcyI6WyJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiLCJpdGVtLTAiLCJpdGVtLTEiLCJpdGVtLTIiLCJpdGVtLTMiXSwidXNlciI6IjQyIn0:1r31eq:rc9Zs93oJyy0jJDSoPtafLm9TjXVA9-q-zODZ6B-cfU"
+)
+
+// dumpsLargeObject is {"items": ["item-0", ..., "item-3"] * 10, "user": "42"}, large
+// enough that Django compresses it
+func dumpsLargeObject() map[string]interface{} {
+	items := make([]interface{}, 40)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i%4)
+	}
+	return map[string]interface{}{"items": items, "user": "42"}
+}
+
+func TestLoadsDjangoDumps(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: dumpsTestSecret})
+	small := map[string]interface{}{"a": float64(1), "b": "two"}
+
+	tests := []struct {
+		name  string
+		token string
+		opts  SigningOptions
+		want  map[string]interface{}
+	}{
+		{"default salt", djangoDumpsSmall, SigningOptions{}, small},
+		{"explicit default salt", djangoDumpsSmall, SigningOptions{Salt: DefaultDumpsSalt}, small},
+		{"salt", djangoDumpsSmallSaltX, SigningOptions{Salt: "x"}, small},
+		{"salt and JSONSerializer", djangoDumpsSmallSaltX, SigningOptions{Salt: "x", Serializer: "django.core.signing.JSONSerializer"}, small},
+		{"compressed", djangoDumpsLargeCompressed, SigningOptions{}, dumpsLargeObject()},
+		{"compressed with salt", djangoDumpsLargeCompressedX, SigningOptions{Salt: "x", Serializer: "json"}, dumpsLargeObject()},
+		{"compress requested but not applied", djangoDumpsLargeSaltX, SigningOptions{Salt: "x", Compress: true}, dumpsLargeObject()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Loads(tt.token, tt.opts)
+			if err != nil {
+				t.Fatalf("Loads() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Loads() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("salt mismatch", func(t *testing.T) {
+		for _, salt := range []string{"x", "xsigner", "django.core.signing.Signer"} {
+			if _, err := client.Loads(djangoDumpsSmall, SigningOptions{Salt: salt}); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Loads(salt %q) error = %v, want ErrInvalidSignature", salt, err)
+			}
+		}
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		if _, err := client.Loads(djangoDumpsSmall, SigningOptions{MaxAge: time.Hour}); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("Loads() error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("pickle serializer", func(t *testing.T) {
+		if _, err := client.Loads(djangoDumpsSmall, SigningOptions{Serializer: PickleSerializerPath}); err == nil {
+			t.Error("Loads() expected error for PickleSerializer")
+		}
+	})
+}
+
+func TestDumpsMatchesDjango(t *testing.T) {
+	signedAt := time.Unix(1700000000, 0)
+	client, _ := NewClient(ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: dumpsTestSecret,
+		Now:       func() time.Time { return signedAt },
+	})
+
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		opts SigningOptions
+		want string
+	}{
+		{"default salt", map[string]interface{}{"a": 1, "b": "two"}, SigningOptions{}, djangoDumpsSmall},
+		{"salt and serializer", map[string]interface{}{"a": 1, "b": "two"}, SigningOptions{Salt: "x", Serializer: "django.core.signing.JSONSerializer"}, djangoDumpsSmallSaltX},
+		{"compress too small to shrink", map[string]interface{}{"a": 1, "b": "two"}, SigningOptions{Salt: "x", Compress: true}, djangoDumpsSmallSaltX},
+		{"uncompressed large", dumpsLargeObject(), SigningOptions{Salt: "x"}, djangoDumpsLargeSaltX},
+		{"json.dumps escaping", map[string]interface{}{"next": "/a?b=1&c=<2>", "name": "Zoë"}, SigningOptions{}, djangoDumpsEscaping},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.Dumps(tt.obj, tt.opts)
+			if err != nil {
+				t.Fatalf("Dumps() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Dumps() = %q, want Django's %q", got, tt.want)
+			}
+		})
+	}
+
+	// zlib output may differ byte-wise between implementations, so compressed tokens are
+	// checked by round trip
+	t.Run("compressed", func(t *testing.T) {
+		for _, salt := range []string{"", "x"} {
+			opts := SigningOptions{Salt: salt, Compress: true}
+			token, err := client.Dumps(dumpsLargeObject(), opts)
+			if err != nil {
+				t.Fatalf("Dumps() error = %v", err)
+			}
+			if token[0] != '.' {
+				t.Errorf("Dumps(salt %q) = %q, want a compressed token", salt, token)
+			}
+			got, err := client.Loads(token, opts)
+			if err != nil || !reflect.DeepEqual(got, dumpsLargeObject()) {
+				t.Errorf("Loads(Dumps()) = %v, %v", got, err)
+			}
+		}
+	})
+}
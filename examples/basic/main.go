@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/knrd/go-gin-django-session/ginsession"
 )
 
 func main() {
@@ -68,7 +69,7 @@ func main() {
 	})
 
 	// Mixed authentication endpoint - works for both logged in and anonymous users
-	r.GET("/home", djsession.OptionalAuthMiddleware(djsession.MiddlewareConfig{
+	r.GET("/home", ginsession.OptionalAuthMiddleware(ginsession.MiddlewareConfig{
 		Client:     client,
 		SessionKey: "django_session",
 	}), func(c *gin.Context) {
@@ -103,7 +104,7 @@ func main() {
 
 	// Protected routes - require Django session authentication
 	protected := r.Group("/api")
-	protected.Use(djsession.AuthMiddleware(djsession.MiddlewareConfig{
+	protected.Use(ginsession.AuthMiddleware(ginsession.MiddlewareConfig{
 		Client:     client,
 		SessionKey: "django_session",
 		OnError: func(c *gin.Context, err error) {
@@ -0,0 +1,75 @@
+package django_session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// FieldEncryptionSalt is the salt EncryptField derives its AES key with, so the key differs
+// from every HMAC key Django derives from the same secret
+const FieldEncryptionSalt = "django_session.field_encryption"
+
+// EncryptField encrypts plaintext with AES-256-GCM under a key derived from the secret key
+// and FieldEncryptionSalt, for storing sensitive values (e.g. OAuth tokens) inside a
+// session. Django's signing only protects session_data against tampering; anyone who can
+// read the table can still decode the payload. The result is URL-safe base64 of the
+// random nonce followed by the ciphertext, so it can be stored as a session value.
+func (c *Client) EncryptField(plaintext string) (string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	aead, err := fieldCipher(signer.SecretKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return b64Encode(aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+// DecryptField decrypts a value made by EncryptField. Values encrypted under one of
+// SecretKeyFallbacks are accepted, as signatures are. Returns ErrDecryptionFailed for
+// malformed or tampered values and values encrypted under another secret key.
+func (c *Client) DecryptField(ciphertext string) (string, error) {
+	signer, err := c.currentSigner()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := b64Decode(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid base64", ErrDecryptionFailed)
+	}
+
+	for _, secretKey := range append([]string{signer.SecretKey}, signer.FallbackKeys...) {
+		aead, err := fieldCipher(secretKey)
+		if err != nil {
+			return "", err
+		}
+		if len(sealed) < aead.NonceSize()+aead.Overhead() {
+			return "", fmt.Errorf("%w: value too short", ErrDecryptionFailed)
+		}
+		nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+		if plaintext, err := aead.Open(nil, nonce, data, nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", ErrDecryptionFailed
+}
+
+// fieldCipher returns the AES-256-GCM cipher for secretKey, keyed like the first step of
+// Django's salted_hmac: sha256(FieldEncryptionSalt + secretKey)
+func fieldCipher(secretKey string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(FieldEncryptionSalt + secretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,80 @@
+package django_session
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptField(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+	tests := []string{"", "oauth-token-123", "ünïcödé ✓", strings.Repeat("x", 4096)}
+	for _, plaintext := range tests {
+		ciphertext, err := client.EncryptField(plaintext)
+		if err != nil {
+			t.Fatalf("EncryptField(%q) error = %v", plaintext, err)
+		}
+		if plaintext != "" && strings.Contains(ciphertext, plaintext) {
+			t.Errorf("EncryptField(%q) leaks the plaintext: %q", plaintext, ciphertext)
+		}
+		got, err := client.DecryptField(ciphertext)
+		if err != nil || got != plaintext {
+			t.Errorf("DecryptField() = %q, %v, want %q", got, err, plaintext)
+		}
+	}
+
+	t.Run("random nonce", func(t *testing.T) {
+		a, _ := client.EncryptField("same")
+		b, _ := client.EncryptField("same")
+		if a == b {
+			t.Error("EncryptField() returned identical ciphertexts for the same plaintext")
+		}
+	})
+
+	t.Run("fallback key", func(t *testing.T) {
+		old, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "old-secret"})
+		ciphertext, _ := old.EncryptField("token")
+
+		rotated, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", SecretKeyFallbacks: []string{"old-secret"}})
+		if got, err := rotated.DecryptField(ciphertext); err != nil || got != "token" {
+			t.Errorf("DecryptField() = %q, %v, want token", got, err)
+		}
+		if _, err := client.DecryptField(ciphertext); !errors.Is(err, ErrDecryptionFailed) {
+			t.Errorf("DecryptField() under another key error = %v, want ErrDecryptionFailed", err)
+		}
+	})
+}
+
+func TestDecryptFieldTampered(t *testing.T) {
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+	ciphertext, _ := client.EncryptField("oauth-token-123")
+	sealed, _ := b64Decode(ciphertext)
+
+	flip := func(i int) string {
+		tampered := append([]byte(nil), sealed...)
+		tampered[i] ^= 0x01
+		return b64Encode(tampered)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"flipped nonce bit", flip(0)},
+		{"flipped ciphertext bit", flip(len(sealed) / 2)},
+		{"flipped tag bit", flip(len(sealed) - 1)},
+		{"truncated", b64Encode(sealed[:len(sealed)-1])},
+		{"too short", b64Encode(sealed[:8])},
+		{"not base64", "!!!"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.DecryptField(tt.value); !errors.Is(err, ErrDecryptionFailed) {
+				t.Errorf("DecryptField() error = %v, want ErrDecryptionFailed", err)
+			}
+		})
+	}
+}
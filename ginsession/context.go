@@ -0,0 +1,26 @@
+package ginsession
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// sessionContextKey is the context.Context key for the raw session. Being unexported, it
+// cannot collide with or be overwritten by keys set by other packages.
+type sessionContextKey struct{}
+
+// setRequestSession stores the raw session in the request's context.Context under
+// sessionContextKey, alongside the string key in the gin context
+func setRequestSession(c *gin.Context, session *djsession.RawSession) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), sessionContextKey{}, session))
+}
+
+// SessionFromContext returns the raw session stored by the middleware. Unlike reading
+// MiddlewareConfig.SessionKey with c.Get, it cannot be shadowed by other middleware
+// writing the same string key.
+func SessionFromContext(c *gin.Context) (*djsession.RawSession, bool) {
+	session, ok := c.Request.Context().Value(sessionContextKey{}).(*djsession.RawSession)
+	return session, ok && session != nil
+}
@@ -0,0 +1,63 @@
+package ginsession
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+func TestSessionFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sessionData, _ := djsession.EncodeSessionData("42", "test-secret", nil)
+
+	middlewares := map[string]func(MiddlewareConfig) gin.HandlerFunc{
+		"AuthMiddleware":         AuthMiddleware,
+		"OptionalAuthMiddleware": OptionalAuthMiddleware,
+	}
+
+	for name, middleware := range middlewares {
+		t.Run(name, func(t *testing.T) {
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: "test-secret"})
+
+			var session *djsession.RawSession
+			var found bool
+			router := gin.New()
+			router.Use(middleware(MiddlewareConfig{Client: client}))
+			// Another middleware clobbers the string key
+			router.Use(func(c *gin.Context) {
+				c.Set("django_session", "something else")
+				c.Next()
+			})
+			router.GET("/test", func(c *gin.Context) {
+				session, found = SessionFromContext(c)
+				c.Status(http.StatusOK)
+			})
+
+			if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if !found || session.SessionKey != "abc" || session.SessionData != sessionData {
+				t.Errorf("SessionFromContext() = %+v, %v", session, found)
+			}
+		})
+	}
+
+	t.Run("no session", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+		found := true
+		router := gin.New()
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			_, found = SessionFromContext(c)
+			c.Status(http.StatusOK)
+		})
+
+		serveWithCookie(router, "")
+		if found {
+			t.Error("Expected no session without a cookie")
+		}
+	})
+}
@@ -0,0 +1,13 @@
+package ginsession
+
+import (
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// setRequestIdentity replaces the request's context with one carrying the session's identity,
+// readable by non-gin code with djsession.FromContext
+func setRequestIdentity(c *gin.Context, session *djsession.RawSession, userID string) {
+	identity := djsession.Identity{UserID: userID, SessionKey: session.SessionKey, ExpireDate: session.ExpireDate}
+	c.Request = c.Request.WithContext(djsession.ContextWithIdentity(c.Request.Context(), identity))
+}
@@ -0,0 +1,87 @@
+package ginsession
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMiddlewareIdentityInContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	// downstream reads the identity without touching gin.Context keys
+	downstream := func(ctx context.Context) (djsession.Identity, bool) {
+		return djsession.FromContext(ctx)
+	}
+
+	middlewares := map[string]func(MiddlewareConfig) gin.HandlerFunc{
+		"AuthMiddleware":             AuthMiddleware,
+		"OptionalAuthMiddleware":     OptionalAuthMiddleware,
+		"AuthMiddlewareWithFullUser": AuthMiddlewareWithFullUser,
+	}
+
+	for name, middleware := range middlewares {
+		t.Run(name, func(t *testing.T) {
+			db := newSessionDB("abc", sessionData)
+			db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+				Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+			var identity djsession.Identity
+			var found bool
+			router := gin.New()
+			router.Use(middleware(MiddlewareConfig{Client: client, IdentityInContext: true}))
+			router.GET("/test", func(c *gin.Context) {
+				identity, found = downstream(c.Request.Context())
+				if _, exists := c.Get("django_session"); !exists {
+					t.Error("Expected raw session in gin context as well")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if !found || identity.UserID != "42" || identity.SessionKey != "abc" || identity.ExpireDate.IsZero() {
+				t.Errorf("FromContext() = %+v, %v", identity, found)
+			}
+		})
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: secretKey})
+
+		var found bool
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			_, found = djsession.FromContext(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+
+		serveWithCookie(router, "abc")
+		if found {
+			t.Error("Expected no identity without IdentityInContext")
+		}
+	})
+
+	t.Run("undecodable session fails AuthMiddleware", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", "garbage"), SecretKey: secretKey})
+
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client, IdentityInContext: true}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusFound {
+			t.Errorf("Expected status %d, got %d", http.StatusFound, w.Code)
+		}
+	})
+}
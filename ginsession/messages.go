@@ -0,0 +1,91 @@
+package ginsession
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeMessageError is the default error response when MiddlewareConfig.Messages is set:
+// a 401 JSON body carrying the message for err in the request's preferred language
+func writeMessageError(c *gin.Context, config MiddlewareConfig, err error) {
+	c.JSON(http.StatusUnauthorized, gin.H{"error": localizedMessage(config.Messages, c.GetHeader("Accept-Language"), err)})
+}
+
+// localizedMessage picks the catalog entry for err in the first language of acceptLanguage
+// that has one, trying each tag and then its base language ("pt-BR", then "pt"). Without
+// a match it returns the generic 401 status text, never err's own text, which may carry
+// internal details such as database errors.
+func localizedMessage(messages map[string]map[error]string, acceptLanguage string, err error) string {
+	for _, tag := range acceptedLanguages(acceptLanguage) {
+		candidates := []string{tag}
+		if base, _, found := strings.Cut(tag, "-"); found {
+			candidates = append(candidates, base)
+		}
+		for _, lang := range candidates {
+			for key, catalog := range messages {
+				if !strings.EqualFold(key, lang) {
+					continue
+				}
+				if message, ok := catalogMessage(catalog, err); ok {
+					return message
+				}
+			}
+		}
+	}
+	return http.StatusText(http.StatusUnauthorized)
+}
+
+// catalogMessage looks err up in a language's catalog, matching wrapped errors with errors.Is
+func catalogMessage(catalog map[error]string, err error) (string, bool) {
+	if message, ok := catalog[err]; ok {
+		return message, true
+	}
+	for target, message := range catalog {
+		if errors.Is(err, target) {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// acceptedLanguages parses an Accept-Language header into lowercase language tags ordered
+// by quality, keeping header order for equal weights. Wildcards and q=0 entries are dropped.
+func acceptedLanguages(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		langs = append(langs, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].quality > langs[j].quality })
+	tags := make([]string, len(langs))
+	for i, lang := range langs {
+		tags[i] = lang.tag
+	}
+	return tags
+}
@@ -0,0 +1,98 @@
+package ginsession
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMiddlewareMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	messages := map[string]map[error]string{
+		"en": {djsession.ErrSessionExpired: "Your session has expired"},
+		"de": {djsession.ErrSessionExpired: "Ihre Sitzung ist abgelaufen"},
+		"pl": {djsession.ErrSessionExpired: "Twoja sesja wygasła"},
+	}
+
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+		Return(newMockRow("abc", sessionData, time.Now().Add(-time.Hour)))
+	client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+	router := gin.New()
+	router.Use(AuthMiddleware(MiddlewareConfig{Client: client, Messages: messages}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(cookie, acceptLanguage string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "sessionid", Value: cookie})
+		}
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		cookie         string
+		acceptLanguage string
+		want           string
+	}{
+		{"german", "abc", "de-DE,de;q=0.9,en;q=0.8", "Ihre Sitzung ist abgelaufen"},
+		{"polish preferred by quality", "abc", "en;q=0.5, pl", "Twoja sesja wygasła"},
+		{"falls back to a later language", "abc", "fr-FR, fr;q=0.9, en;q=0.8", "Your session has expired"},
+		{"no matching language", "abc", "fr", "Unauthorized"},
+		{"no message for error", "", "de", "Unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.cookie, tt.acceptLanguage)
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Invalid JSON body %q: %v", w.Body.String(), err)
+			}
+			if body["error"] != tt.want {
+				t.Errorf("error = %q, want %q", body["error"], tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptedLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"de", []string{"de"}},
+		{"pt-BR, en;q=0.5, pt;q=0.8", []string{"pt-br", "pt", "en"}},
+		{"fr;q=0.7, *;q=0.9, es;q=0.7", []string{"fr", "es"}},
+		{"en;q=0, de;q=bad, it", []string{"it"}},
+	}
+
+	for _, tt := range tests {
+		if got := acceptedLanguages(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("acceptedLanguages(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,664 @@
+// Package ginsession provides Gin middleware and handlers that authenticate requests with
+// Django sessions. The signing and database logic lives in the gin-independent
+// django_session package, which net/http services can use directly.
+package ginsession
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// MiddlewareConfig configures the authentication middleware
+type MiddlewareConfig struct {
+	Client             *djsession.Client
+	Store              djsession.SessionStore                              // Optional: session source, e.g. a CachedDBStore (default: the request's djsession.Client)
+	LoginRedirectURL   string                                              // URL to redirect when auth fails (default: "/account/login")
+	RedirectStatusCode int                                                 // Status code for the login redirect, must be 3xx (default: 302)
+	SessionKey         string                                              // Context key for storing session (default: "django_session")
+	UserKey            string                                              // Context key for storing user (default: "django_user")
+	UserIDKey          string                                              // Context key for the eagerly decoded user ID (default: "django_user_id")
+	OnError            func(c *gin.Context, err error)                     // Optional: custom error handler
+	OnSuccess          func(c *gin.Context, session *djsession.RawSession) // Optional: called after a valid session is stored in context
+
+	// OnOptionalError is called by OptionalAuthMiddleware, without aborting, when a session
+	// cookie is present but fails validation, e.g. to log anomalies. It is not called when
+	// the request has no session cookie.
+	OnOptionalError func(c *gin.Context, err error)
+
+	// FailClosedOnStoreError makes OptionalAuthMiddleware route ErrStoreUnavailable
+	// failures (e.g. the database is down) through OnError, Messages or the login redirect,
+	// like AuthMiddleware, instead of continuing without a session. By default it fails
+	// open: the request continues anonymously and OnOptionalError receives the error.
+	FailClosedOnStoreError bool
+
+	// SkipPaths lists request paths that bypass the middleware entirely, e.g. a health check
+	// or public webhook inside a protected group. Entries are exact paths or path.Match
+	// globs ("/api/webhooks/*"); invalid globs panic when the middleware is created.
+	SkipPaths []string
+
+	// CookieValueSigned unwraps session cookies whose value is itself signed, as written by
+	// Django's set_signed_cookie(SESSION_COOKIE_NAME, key, salt=CookieSalt) or a proxy, with
+	// Client.UnsignCookieValue before the session is looked up. Tampered values fail with
+	// ErrInvalidSignature.
+	CookieValueSigned bool
+	CookieSalt        string // Salt the cookie value was signed with (default: "")
+
+	// StrictSessionKeyFormat rejects cookie values Django could not have generated
+	// (djsession.IsValidSessionKey) with ErrSessionNotFound before any store access.
+	// ClientConfig.StrictSessionKeyFormat does the same inside Client.GetRawSession; this
+	// option also covers custom Stores such as caches and HTTP stores.
+	StrictSessionKeyFormat bool
+
+	// VerifySignature checks the stored session_data signature (HMAC only, no payload
+	// decoding) so a tampered row fails with ErrInvalidSignature instead of passing
+	VerifySignature bool
+
+	// DecodeEagerly makes AuthMiddleware decode the payload up front and store the user ID
+	// under UserIDKey; decode errors are treated as authentication failures
+	DecodeEagerly bool
+
+	// IdentityInContext decodes the user ID and stores an Identity in the request's
+	// context.Context (read it with djsession.FromContext), for non-gin downstream code.
+	// AuthMiddleware treats decode errors as auth failures; OptionalAuthMiddleware skips them.
+	IdentityInContext bool
+
+	// SetExpiryHeader writes the seconds remaining on a valid session (see Client.SessionExpiresIn)
+	// to the response header ExpiryHeaderName (default: "X-Session-Expires-In")
+	SetExpiryHeader  bool
+	ExpiryHeaderName string
+
+	// SecretKeyResolver selects the SECRET_KEY per request (e.g. by host) for multi-tenant
+	// gateways. Clients derived from Client with each secret are cached.
+	SecretKeyResolver func(c *gin.Context) (secretKey string, err error)
+
+	// AuditOnly evaluates sessions without enforcing them, for measuring impact before
+	// rollout: AuthMiddleware, AuthMiddlewareWithFullUser and RequireSessionKeyMiddleware
+	// record the would-be decision under AuthWouldFailKey, report it to OnAudit and always
+	// continue the chain. OnError and the login redirect are not used. Without OnAudit,
+	// would-be failures are logged with slog.Default().
+	AuditOnly bool
+	OnAudit   func(c *gin.Context, err error) // Optional: called with the would-be auth error (nil when the session is valid)
+
+	// ExpiryGrace accepts sessions whose expire_date passed less than this long ago, to
+	// smooth over clock drift at the boundary; such sessions are flagged with
+	// SessionInGraceKey. It needs a Store that can return expired rows, such as
+	// *djsession.Client (the default); other Stores ignore it.
+	ExpiryGrace time.Duration
+
+	// MemoizePerRequest reuses the first session lookup of a request, including a failed
+	// one, in every later validation of the same *gin.Context, e.g. when several
+	// middlewares or batched sub-requests check the same cookie. Enable it on each
+	// middleware that should share the result.
+	MemoizePerRequest bool
+
+	// Messages is a catalog of error messages by language and error (lang -> error ->
+	// message), e.g. {"de": {djsession.ErrSessionExpired: "Sitzung abgelaufen"}}. When set
+	// and OnError is nil, auth failures get a 401 JSON response {"error": message} in the
+	// best language from Accept-Language instead of the login redirect. Errors are matched
+	// with errors.Is; failures without a message get the generic "Unauthorized".
+	Messages map[string]map[error]string
+
+	// RotateKeyOnAuth moves each session authenticated by AuthMiddleware or
+	// AuthMiddlewareWithFullUser to a new key (Client.CycleKey) and sets the new session
+	// cookie on the response, protecting login flows against session fixation. Every
+	// rotation writes to the database, so enable it on the routes that complete a login
+	// (e.g. an SSO callback) rather than on every route. Rotation errors are auth failures.
+	RotateKeyOnAuth bool
+
+	// TrackSessionAge verifies the signing timestamp of each valid session and stores its
+	// age (time.Duration) under SessionAgeKey, for cohort analytics. It costs an HMAC check
+	// even when the payload is not decoded. Sessions whose signature doesn't verify get no
+	// age and are not rejected for it; use VerifySignature for that.
+	TrackSessionAge bool
+	OnSessionAge    func(c *gin.Context, age time.Duration) // Optional: called with the tracked age, e.g. to record a metric
+
+	// ClientResolver selects the whole Client per request, for tenants that also differ in
+	// database. It takes precedence over SecretKeyResolver. Resolver errors are auth failures.
+	ClientResolver func(c *gin.Context) (*djsession.Client, error)
+}
+
+// ErrStoreUnavailable wraps session lookup failures other than a missing, expired, revoked
+// or tampered session, such as database or network errors, so handlers can tell an
+// outage from an anonymous user with errors.Is. The store's error is wrapped as well.
+var ErrStoreUnavailable = errors.New("session store unavailable")
+
+// DefaultUserKey is the default context key under which AuthMiddlewareWithFullUser stores the user
+const DefaultUserKey = "django_user"
+
+// DefaultExpiryHeaderName is the default response header written when SetExpiryHeader is enabled
+const DefaultExpiryHeaderName = "X-Session-Expires-In"
+
+// DefaultClientKey is the context key under which the middleware stores the request's Client
+const DefaultClientKey = "django_client"
+
+// DefaultUserIDKey is the default context key under which AuthMiddleware stores an eagerly decoded user ID
+const DefaultUserIDKey = "django_user_id"
+
+// AuthWouldFailKey is the context key under which AuditOnly stores whether the request
+// would have failed authentication (bool)
+const AuthWouldFailKey = "auth_would_fail"
+
+// SessionInGraceKey is the context key set to true when ExpiryGrace admitted an expired session
+const SessionInGraceKey = "session_in_grace"
+
+// SessionAgeKey is the context key under which TrackSessionAge stores the session's age
+const SessionAgeKey = "session_age"
+
+// DefaultSessionDataKey is the context key under which the decoded session payload is cached
+const DefaultSessionDataKey = "django_session_data"
+
+// sessionLookupKey is the context key under which MemoizePerRequest keeps the first
+// validation result of a request
+const sessionLookupKey = "django_session_lookup"
+
+// sessionLookup is a memoized getSessionFromCookie result
+type sessionLookup struct {
+	client  *djsession.Client
+	session *djsession.RawSession
+	err     error
+}
+
+// getSessionFromCookie attempts to retrieve and validate a Django session from cookie
+// Returns the client resolved for the request, the raw session and error (if any).
+// Does not abort the request. With MemoizePerRequest, the first result is reused for the
+// rest of the request.
+func getSessionFromCookie(c *gin.Context, config MiddlewareConfig) (*djsession.Client, *djsession.RawSession, error) {
+	if !config.MemoizePerRequest {
+		return lookupSession(c, config)
+	}
+	if value, ok := c.Get(sessionLookupKey); ok {
+		if memo, ok := value.(*sessionLookup); ok {
+			return memo.client, memo.session, memo.err
+		}
+	}
+	client, session, err := lookupSession(c, config)
+	c.Set(sessionLookupKey, &sessionLookup{client: client, session: session, err: err})
+	return client, session, err
+}
+
+// lookupSession implements getSessionFromCookie without memoization
+func lookupSession(c *gin.Context, config MiddlewareConfig) (*djsession.Client, *djsession.RawSession, error) {
+	client := config.Client
+	if config.ClientResolver != nil {
+		resolved, err := config.ClientResolver(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		client = resolved
+	}
+
+	// Get session cookie
+	sessionID, err := c.Cookie(client.SessionCookieName())
+	if err != nil || sessionID == "" {
+		return nil, nil, djsession.ErrNoSessionCookie
+	}
+
+	if config.CookieValueSigned {
+		if sessionID, err = client.UnsignCookieValue(sessionID, config.CookieSalt); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Reject scanner garbage such as "<script>" before it reaches the store
+	if config.StrictSessionKeyFormat && !djsession.IsValidSessionKey(sessionID) {
+		return nil, nil, djsession.ErrSessionNotFound
+	}
+
+	// Validate session existence and expiration WITHOUT decoding payload
+	store := config.Store
+	if store == nil {
+		store = client
+	}
+	rawSession, err := getRawSessionWithGrace(c, config, client, store, sessionID)
+	if err != nil {
+		if !isSessionRejection(err) {
+			return nil, nil, fmt.Errorf("%w: %w", ErrStoreUnavailable, err)
+		}
+		return nil, nil, err
+	}
+
+	// Optionally reject rows whose stored data fails signature verification
+	if config.VerifySignature {
+		if err := client.VerifySessionSignature(rawSession.SessionData); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return client, rawSession, nil
+}
+
+// isSessionRejection reports whether a store error means the session itself is unusable,
+// as opposed to the store failing to answer
+func isSessionRejection(err error) bool {
+	return errors.Is(err, djsession.ErrSessionNotFound) || errors.Is(err, djsession.ErrSessionExpired) ||
+		errors.Is(err, djsession.ErrSessionRevoked) || errors.Is(err, djsession.ErrInvalidSignature)
+}
+
+// allowExpiredStore is a SessionStore that can also return expired rows, as *djsession.Client does
+type allowExpiredStore interface {
+	GetRawSessionAllowExpired(ctx context.Context, sessionKey string) (*djsession.RawSession, error)
+}
+
+// sessionChecker is a SessionStore that can check sessions it did not load itself, as
+// *djsession.Client does with CheckSession
+type sessionChecker interface {
+	CheckSession(ctx context.Context, session *djsession.RawSession) error
+}
+
+// getRawSessionWithGrace loads the session from store, accepting sessions expired by less
+// than ExpiryGrace by the client's clock and flagging them under SessionInGraceKey. The
+// signature and revocation checks still run, since GetRawSessionAllowExpired skips them.
+func getRawSessionWithGrace(c *gin.Context, config MiddlewareConfig, client *djsession.Client, store djsession.SessionStore, sessionKey string) (*djsession.RawSession, error) {
+	graceStore, ok := store.(allowExpiredStore)
+	if config.ExpiryGrace <= 0 || !ok {
+		return store.GetRawSession(c.Request.Context(), sessionKey)
+	}
+
+	session, err := graceStore.GetRawSessionAllowExpired(c.Request.Context(), sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	if checker, ok := store.(sessionChecker); ok {
+		if err := checker.CheckSession(c.Request.Context(), session); err != nil {
+			return nil, err
+		}
+	}
+	if expiredFor := client.Now().Sub(session.ExpireDate); expiredFor > 0 {
+		if expiredFor > config.ExpiryGrace {
+			return nil, djsession.ErrSessionExpired
+		}
+		c.Set(SessionInGraceKey, true)
+	}
+	return session, nil
+}
+
+// setConfigDefaults sets default values for MiddlewareConfig.
+// Panics on an invalid RedirectStatusCode so misconfiguration fails at startup.
+func setConfigDefaults(config *MiddlewareConfig) {
+	if config.LoginRedirectURL == "" {
+		config.LoginRedirectURL = "/account/login"
+	}
+	if config.SessionKey == "" {
+		config.SessionKey = "django_session"
+	}
+	if config.RedirectStatusCode == 0 {
+		config.RedirectStatusCode = http.StatusFound
+	}
+	if config.RedirectStatusCode < 300 || config.RedirectStatusCode > 399 {
+		panic(fmt.Sprintf("ginsession: RedirectStatusCode must be a 3xx status, got %d", config.RedirectStatusCode))
+	}
+	if config.UserKey == "" {
+		config.UserKey = DefaultUserKey
+	}
+	if config.UserIDKey == "" {
+		config.UserIDKey = DefaultUserIDKey
+	}
+	if config.ExpiryHeaderName == "" {
+		config.ExpiryHeaderName = DefaultExpiryHeaderName
+	}
+	for _, pattern := range config.SkipPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			panic(fmt.Sprintf("ginsession: invalid SkipPaths pattern %q: %v", pattern, err))
+		}
+	}
+	if config.ClientResolver == nil && config.SecretKeyResolver != nil {
+		config.ClientResolver = secretKeyClientResolver(config.Client, config.SecretKeyResolver)
+	}
+}
+
+// skipPath reports whether the request path matches one of SkipPaths
+func skipPath(c *gin.Context, config MiddlewareConfig) bool {
+	requestPath := c.Request.URL.Path
+	for _, pattern := range config.SkipPaths {
+		if pattern == requestPath {
+			return true
+		}
+		if matched, _ := path.Match(pattern, requestPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// setExpiryHeader writes the remaining session lifetime in whole seconds when SetExpiryHeader is enabled
+func setExpiryHeader(c *gin.Context, config MiddlewareConfig, client *djsession.Client, session *djsession.RawSession) {
+	if !config.SetExpiryHeader {
+		return
+	}
+	remaining := client.SessionExpiresIn(session)
+	c.Header(config.ExpiryHeaderName, strconv.FormatInt(int64(remaining/time.Second), 10))
+}
+
+// trackSessionAge stores the age of the session's signature under SessionAgeKey and reports
+// it to OnSessionAge when TrackSessionAge is enabled
+func trackSessionAge(c *gin.Context, config MiddlewareConfig, client *djsession.Client, session *djsession.RawSession) {
+	if !config.TrackSessionAge {
+		return
+	}
+	age, err := client.SignatureAge(session.SessionData)
+	if err != nil {
+		return
+	}
+	c.Set(SessionAgeKey, age)
+	if config.OnSessionAge != nil {
+		config.OnSessionAge(c, age)
+	}
+}
+
+// recordAudit stores the would-be decision under AuthWouldFailKey and reports it to
+// OnAudit, or logs would-be failures when OnAudit is nil. No-op unless AuditOnly is set.
+func recordAudit(c *gin.Context, config MiddlewareConfig, err error) {
+	if !config.AuditOnly {
+		return
+	}
+	c.Set(AuthWouldFailKey, err != nil)
+	if config.OnAudit != nil {
+		config.OnAudit(c, err)
+	} else if err != nil {
+		slog.Info("session authentication would fail", "path", c.Request.URL.Path, "error", err)
+	}
+}
+
+// invalidatingStore is a SessionStore with a cache that can drop keys, as *djsession.CachedDBStore
+type invalidatingStore interface {
+	Invalidate(ctx context.Context, sessionKey string) error
+}
+
+// rotateSessionKey cycles the session key when RotateKeyOnAuth is enabled, drops the old key
+// from a caching Store and sets the new session cookie so the browser drops the old key
+func rotateSessionKey(c *gin.Context, config MiddlewareConfig, client *djsession.Client, session *djsession.RawSession) (*djsession.RawSession, error) {
+	if !config.RotateKeyOnAuth {
+		return session, nil
+	}
+	rotated, err := client.CycleKey(c.Request.Context(), session.SessionKey)
+	if err != nil {
+		return nil, err
+	}
+	if store, ok := config.Store.(invalidatingStore); ok {
+		if err := store.Invalidate(c.Request.Context(), session.SessionKey); err != nil {
+			return nil, fmt.Errorf("invalidating rotated session key: %w", err)
+		}
+	}
+	if err := setSessionCookie(c, client, rotated, config.CookieValueSigned, config.CookieSalt); err != nil {
+		return nil, err
+	}
+	if config.MemoizePerRequest {
+		c.Set(sessionLookupKey, &sessionLookup{client: client, session: rotated})
+	}
+	return rotated, nil
+}
+
+// setSessionCookie writes session's cookie the way lookupSession reads it back: signed
+// with salt when signed is set, as for MiddlewareConfig.CookieValueSigned
+func setSessionCookie(c *gin.Context, client *djsession.Client, session *djsession.RawSession, signed bool, salt string) error {
+	value := session.SessionKey
+	if signed {
+		signedValue, err := client.SignCookieValue(value, salt)
+		if err != nil {
+			return err
+		}
+		value = signedValue
+	}
+	client.CookieWriter().SetSessionCookie(c.Writer, value, session.ExpireDate)
+	return nil
+}
+
+// handleAuthError routes an authentication failure through OnError, the localized JSON
+// response when Messages is set, or the login redirect. In AuditOnly mode the failure is
+// only recorded and the request continues.
+func handleAuthError(c *gin.Context, config MiddlewareConfig, err error) {
+	if config.AuditOnly {
+		recordAudit(c, config, err)
+		c.Next()
+		return
+	}
+	if config.OnError != nil {
+		config.OnError(c, err)
+	} else if config.Messages != nil {
+		writeMessageError(c, config, err)
+	} else {
+		c.Redirect(config.RedirectStatusCode, config.LoginRedirectURL)
+	}
+	c.Abort()
+}
+
+// AuthMiddleware creates a Gin middleware that validates Django sessions
+// It only checks if session exists and is not expired, WITHOUT decoding the payload
+// (unless DecodeEagerly is set).
+// Redirects to login page if session is invalid or missing.
+func AuthMiddleware(config MiddlewareConfig) gin.HandlerFunc {
+	setConfigDefaults(&config)
+
+	return func(c *gin.Context) {
+		if skipPath(c, config) {
+			c.Next()
+			return
+		}
+
+		client, rawSession, err := getSessionFromCookie(c, config)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		// Optionally fail fast on unreadable payloads instead of deferring to the handler
+		var userID string
+		if config.DecodeEagerly || config.IdentityInContext {
+			userID, err = client.DecodeSessionUserID(rawSession.SessionData)
+			if err != nil {
+				handleAuthError(c, config, err)
+				return
+			}
+		}
+
+		rawSession, err = rotateSessionKey(c, config, client, rawSession)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		if config.DecodeEagerly {
+			c.Set(config.UserIDKey, userID)
+		}
+		if config.IdentityInContext {
+			setRequestIdentity(c, rawSession, userID)
+		}
+
+		// Store raw session in context (payload NOT decoded unless DecodeEagerly)
+		c.Set(config.SessionKey, rawSession)
+		setRequestSession(c, rawSession)
+		c.Set(DefaultClientKey, client)
+		setExpiryHeader(c, config, client, rawSession)
+		trackSessionAge(c, config, client, rawSession)
+		recordAudit(c, config, nil)
+		if config.OnSuccess != nil {
+			config.OnSuccess(c, rawSession)
+		}
+		c.Next()
+	}
+}
+
+// AuthMiddlewareWithFullUser creates a Gin middleware that validates the Django session,
+// decodes the user ID and loads the user from auth_user.
+// Stores both the raw session (SessionKey) and the *DjangoUser (UserKey) in context.
+// Session, decode and user lookup failures are routed through OnError or the login redirect.
+func AuthMiddlewareWithFullUser(config MiddlewareConfig) gin.HandlerFunc {
+	setConfigDefaults(&config)
+
+	return func(c *gin.Context) {
+		if skipPath(c, config) {
+			c.Next()
+			return
+		}
+
+		client, rawSession, err := getSessionFromCookie(c, config)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		userID, err := client.DecodeSessionUserID(rawSession.SessionData)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		user, err := client.GetUser(c.Request.Context(), userID)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		rawSession, err = rotateSessionKey(c, config, client, rawSession)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		if config.IdentityInContext {
+			setRequestIdentity(c, rawSession, userID)
+		}
+		c.Set(config.SessionKey, rawSession)
+		setRequestSession(c, rawSession)
+		c.Set(DefaultClientKey, client)
+		c.Set(config.UserKey, user)
+		setExpiryHeader(c, config, client, rawSession)
+		trackSessionAge(c, config, client, rawSession)
+		recordAudit(c, config, nil)
+		if config.OnSuccess != nil {
+			config.OnSuccess(c, rawSession)
+		}
+		c.Next()
+	}
+}
+
+// GetCurrentUser returns the user stored by AuthMiddlewareWithFullUser under DefaultUserKey.
+// Use GetCurrentUserWithKey when the middleware was configured with a custom UserKey.
+func GetCurrentUser(c *gin.Context) (*djsession.DjangoUser, bool) {
+	return GetCurrentUserWithKey(c, DefaultUserKey)
+}
+
+// GetCurrentUserWithKey returns the user stored by AuthMiddlewareWithFullUser under key
+func GetCurrentUserWithKey(c *gin.Context, key string) (*djsession.DjangoUser, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return nil, false
+	}
+	user, ok := value.(*djsession.DjangoUser)
+	return user, ok
+}
+
+// OptionalAuthMiddleware creates a Gin middleware that validates Django sessions
+// but does NOT redirect when session is missing or invalid.
+// If session exists and is valid, it will be stored in context.
+// If session is missing or invalid, the request continues without setting session in context.
+// Store outages (ErrStoreUnavailable) do the same unless FailClosedOnStoreError is set.
+func OptionalAuthMiddleware(config MiddlewareConfig) gin.HandlerFunc {
+	setConfigDefaults(&config)
+
+	return func(c *gin.Context) {
+		if skipPath(c, config) {
+			c.Next()
+			return
+		}
+
+		client, rawSession, err := getSessionFromCookie(c, config)
+		if err == nil {
+			// Store raw session in context only if valid
+			c.Set(config.SessionKey, rawSession)
+			setRequestSession(c, rawSession)
+			c.Set(DefaultClientKey, client)
+			if config.IdentityInContext {
+				if userID, err := client.DecodeSessionUserID(rawSession.SessionData); err == nil {
+					setRequestIdentity(c, rawSession, userID)
+				} else if config.OnOptionalError != nil {
+					config.OnOptionalError(c, err)
+				}
+			}
+			setExpiryHeader(c, config, client, rawSession)
+			trackSessionAge(c, config, client, rawSession)
+			if config.OnSuccess != nil {
+				config.OnSuccess(c, rawSession)
+			}
+		} else if config.FailClosedOnStoreError && errors.Is(err, ErrStoreUnavailable) {
+			handleAuthError(c, config, err)
+			return
+		} else if config.OnOptionalError != nil && !errors.Is(err, djsession.ErrNoSessionCookie) {
+			config.OnOptionalError(c, err)
+		}
+		// Continue processing regardless of session validity
+		c.Next()
+	}
+}
+
+// RequireSessionKeyMiddleware creates a Gin middleware that validates the Django session,
+// decodes its payload and requires predicate(payload[key]) to be true, e.g. an
+// "mfa_verified" flag set by a multi-step Django flow. A nil predicate only requires the
+// key to be present. Failures are routed through OnError or the login redirect.
+// The decoded payload is cached under DefaultSessionDataKey so chained requirements
+// decode it once per request.
+func RequireSessionKeyMiddleware(config MiddlewareConfig, key string, predicate func(interface{}) bool) gin.HandlerFunc {
+	setConfigDefaults(&config)
+
+	return func(c *gin.Context) {
+		if skipPath(c, config) {
+			c.Next()
+			return
+		}
+
+		client, rawSession, err := getSessionFromCookie(c, config)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		payload, err := sessionPayload(c, client, rawSession)
+		if err != nil {
+			handleAuthError(c, config, err)
+			return
+		}
+
+		value, ok := payload[key]
+		if !ok || (predicate != nil && !predicate(value)) {
+			handleAuthError(c, config, fmt.Errorf("%w: %s", djsession.ErrSessionKeyRequired, key))
+			return
+		}
+
+		c.Set(config.SessionKey, rawSession)
+		setRequestSession(c, rawSession)
+		c.Set(DefaultClientKey, client)
+		setExpiryHeader(c, config, client, rawSession)
+		trackSessionAge(c, config, client, rawSession)
+		recordAudit(c, config, nil)
+		if config.OnSuccess != nil {
+			config.OnSuccess(c, rawSession)
+		}
+		c.Next()
+	}
+}
+
+// sessionPayload decodes the session payload, reusing the copy cached on the request
+// under DefaultSessionDataKey by an earlier middleware
+func sessionPayload(c *gin.Context, client *djsession.Client, session *djsession.RawSession) (map[string]interface{}, error) {
+	if cached, ok := c.Get(DefaultSessionDataKey); ok {
+		if data, ok := cached.(map[string]interface{}); ok {
+			return data, nil
+		}
+	}
+
+	data, err := client.DecodeSessionMap(session.SessionData)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(DefaultSessionDataKey, data)
+	return data, nil
+}
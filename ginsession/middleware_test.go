@@ -0,0 +1,2092 @@
+package ginsession
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDBTX is a mock implementation of djsession.DBTX
+type MockDBTX struct {
+	mock.Mock
+}
+
+func (m *MockDBTX) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	args := m.Called(ctx, sql, arguments)
+	return args.Get(0).(pgconn.CommandTag), args.Error(1)
+}
+
+func (m *MockDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	calledArgs := m.Called(ctx, sql, args)
+	return calledArgs.Get(0).(pgx.Rows), calledArgs.Error(1)
+}
+
+func (m *MockDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	calledArgs := m.Called(ctx, sql, args)
+	return calledArgs.Get(0).(pgx.Row)
+}
+
+func (m *MockDBTX) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	args := m.Called(ctx, tableName, columnNames, rowSrc)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDBTX) Begin(ctx context.Context) (pgx.Tx, error) {
+	args := m.Called(ctx)
+	if tx, ok := args.Get(0).(pgx.Tx); ok {
+		return tx, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// MockRow is a mock implementation of pgx.Row
+type MockRow struct {
+	mock.Mock
+}
+
+func (m *MockRow) Scan(dest ...interface{}) error {
+	args := m.Called(dest...)
+	return args.Error(0)
+}
+
+// newMockRow returns a MockRow whose Scan copies values into the destinations in order.
+// time.Time values are delivered through pgx's timestamptz scanner, and other values
+// through sql.Scanner when the destination implements it, like a real row.
+func newMockRow(values ...interface{}) *MockRow {
+	row := &MockRow{}
+	matchers := make([]interface{}, len(values))
+	for i := range matchers {
+		matchers[i] = mock.Anything
+	}
+	row.On("Scan", matchers...).Run(func(args mock.Arguments) {
+		for i, v := range values {
+			dest := args.Get(i)
+			if scanner, ok := dest.(pgtype.TimestamptzScanner); ok {
+				scanner.ScanTimestamptz(pgtype.Timestamptz{Time: v.(time.Time), Valid: true})
+				continue
+			}
+			if scanner, ok := dest.(sql.Scanner); ok {
+				scanner.Scan(v)
+				continue
+			}
+			reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(v))
+		}
+	}).Return(nil)
+	return row
+}
+
+// newErrorRow returns a MockRow whose Scan fails with err
+func newErrorRow(err error, columns int) *MockRow {
+	row := &MockRow{}
+	matchers := make([]interface{}, columns)
+	for i := range matchers {
+		matchers[i] = mock.Anything
+	}
+	row.On("Scan", matchers...).Return(err)
+	return row
+}
+
+// sqlContains matches SQL arguments containing the given fragment
+func sqlContains(fragment string) interface{} {
+	return mock.MatchedBy(func(sql string) bool { return strings.Contains(sql, fragment) })
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Create a test client (won't actually connect to DB in these tests)
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		setupRequest      func(*http.Request)
+		expectedStatus    int
+		expectedRedirect  string
+		shouldCallNext    bool
+		contextShouldHave string
+	}{
+		{
+			name: "no session cookie",
+			setupRequest: func(r *http.Request) {
+				// No cookie set
+			},
+			expectedStatus:   http.StatusFound,
+			expectedRedirect: "/account/login",
+			shouldCallNext:   false,
+		},
+		{
+			name: "empty session cookie",
+			setupRequest: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{
+					Name:  "sessionid",
+					Value: "",
+				})
+			},
+			expectedStatus:   http.StatusFound,
+			expectedRedirect: "/account/login",
+			shouldCallNext:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create test router
+			router := gin.New()
+
+			nextCalled := false
+			router.Use(AuthMiddleware(MiddlewareConfig{
+				Client: client,
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				nextCalled = true
+				c.Status(http.StatusOK)
+			})
+
+			// Create test request
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			tt.setupRequest(req)
+
+			// Execute request
+			router.ServeHTTP(w, req)
+
+			// Check status
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			// Check redirect
+			if tt.expectedRedirect != "" {
+				location := w.Header().Get("Location")
+				if location != tt.expectedRedirect {
+					t.Errorf("Expected redirect to %s, got %s", tt.expectedRedirect, location)
+				}
+			}
+
+			// Check if next was called
+			if nextCalled != tt.shouldCallNext {
+				t.Errorf("Expected nextCalled=%v, got %v", tt.shouldCallNext, nextCalled)
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareWithCustomConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	t.Run("custom login redirect URL", func(t *testing.T) {
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client:           client,
+			LoginRedirectURL: "/custom-login",
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusFound {
+			t.Errorf("Expected status %d, got %d", http.StatusFound, w.Code)
+		}
+
+		location := w.Header().Get("Location")
+		if location != "/custom-login" {
+			t.Errorf("Expected redirect to /custom-login, got %s", location)
+		}
+	})
+
+	t.Run("custom session key", func(t *testing.T) {
+		router := gin.New()
+		customSessionKey := "my_custom_session"
+
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client:     client,
+			SessionKey: customSessionKey,
+			OnError: func(c *gin.Context, err error) {
+				// Custom error handler - just abort without redirect
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("custom error handler", func(t *testing.T) {
+		errorHandlerCalled := false
+		var capturedError error
+
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client: client,
+			OnError: func(c *gin.Context, err error) {
+				errorHandlerCalled = true
+				capturedError = err
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				c.Abort()
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if !errorHandlerCalled {
+			t.Error("Expected custom error handler to be called")
+		}
+
+		if capturedError == nil {
+			t.Error("Expected error to be captured")
+		}
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+}
+
+func TestAuthMiddlewareBackwardCompatibility(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test that minimal config works (backward compatibility)
+	router := gin.New()
+	router.Use(AuthMiddleware(MiddlewareConfig{
+		Client: client,
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	router.ServeHTTP(w, req)
+
+	// Should redirect to default /account/login
+	if w.Code != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, w.Code)
+	}
+
+	location := w.Header().Get("Location")
+	if location != "/account/login" {
+		t.Errorf("Expected redirect to /account/login, got %s", location)
+	}
+}
+
+// TestAuthMiddlewareWithMockSession tests middleware behavior with a mocked session
+func TestAuthMiddlewareWithMockSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// This test demonstrates the expected flow when a valid session exists
+	// In a real scenario, you'd need to mock the database or use a test database
+
+	t.Run("context storage", func(t *testing.T) {
+		// Create a custom middleware that simulates what AuthMiddleware should do
+		router := gin.New()
+
+		// Simulate successful session validation
+		router.Use(func(c *gin.Context) {
+			// Simulate what AuthMiddleware does when session is valid
+			mockSession := &djsession.RawSession{
+				SessionKey:  "test-session-key",
+				SessionData: "mock-data",
+				ExpireDate:  time.Now().Add(1 * time.Hour),
+			}
+			c.Set("django_session", mockSession)
+			c.Next()
+		})
+
+		router.GET("/test", func(c *gin.Context) {
+			// Verify session is in context
+			sessionValue, exists := c.Get("django_session")
+			if !exists {
+				t.Error("Expected session in context")
+			}
+
+			rawSession, ok := sessionValue.(*djsession.RawSession)
+			if !ok {
+				t.Errorf("Expected *RawSession, got %T", sessionValue)
+			}
+
+			if rawSession.SessionKey != "test-session-key" {
+				t.Errorf("Expected session key 'test-session-key', got %s", rawSession.SessionKey)
+			}
+
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+// TestMiddlewareConfigDefaults tests that default values are set correctly
+func TestMiddlewareConfigDefaults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// Test with minimal config
+	config := MiddlewareConfig{
+		Client: client,
+	}
+
+	router := gin.New()
+	middleware := AuthMiddleware(config)
+	router.Use(middleware)
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	router.ServeHTTP(w, req)
+
+	// Should use default redirect URL
+	location := w.Header().Get("Location")
+	if location != "/account/login" {
+		t.Errorf("Expected default redirect to /account/login, got %s", location)
+	}
+}
+
+// TestAuthMiddlewareErrorHandling tests that custom error handlers work correctly
+func TestAuthMiddlewareErrorHandling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("error handler receives error on missing cookie", func(t *testing.T) {
+		var capturedError error
+		errorHandlerCalled := false
+
+		client, _ := djsession.NewClient(djsession.ClientConfig{
+			DB:        &MockDBTX{},
+			SecretKey: "test-secret-key",
+		})
+
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client: client,
+			OnError: func(c *gin.Context, err error) {
+				errorHandlerCalled = true
+				capturedError = err
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		// No cookie set
+
+		router.ServeHTTP(w, req)
+
+		if !errorHandlerCalled {
+			t.Error("Expected error handler to be called")
+		}
+
+		if capturedError == nil {
+			t.Error("Expected error to be captured by OnError handler")
+		}
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("different error messages", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			setupRequest   func(*http.Request)
+			expectedErrMsg string
+		}{
+			{
+				name: "no cookie",
+				setupRequest: func(r *http.Request) {
+					// No cookie
+				},
+				expectedErrMsg: "no session cookie",
+			},
+			{
+				name: "empty cookie",
+				setupRequest: func(r *http.Request) {
+					r.AddCookie(&http.Cookie{
+						Name:  "sessionid",
+						Value: "",
+					})
+				},
+				expectedErrMsg: "no session cookie",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				var capturedError error
+
+				client, _ := djsession.NewClient(djsession.ClientConfig{
+					DB:        &MockDBTX{},
+					SecretKey: "test-secret-key",
+				})
+
+				router := gin.New()
+				router.Use(AuthMiddleware(MiddlewareConfig{
+					Client: client,
+					OnError: func(c *gin.Context, err error) {
+						capturedError = err
+						c.AbortWithStatus(http.StatusUnauthorized)
+					},
+				}))
+				router.GET("/test", func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", "/test", nil)
+				tt.setupRequest(req)
+
+				router.ServeHTTP(w, req)
+
+				if capturedError == nil {
+					t.Fatal("Expected error to be captured")
+				}
+
+				if capturedError.Error() != tt.expectedErrMsg {
+					t.Errorf("Expected error message '%s', got '%s'", tt.expectedErrMsg, capturedError.Error())
+				}
+			})
+		}
+	})
+}
+
+// TestSessionCookieName tests that custom cookie names are respected
+func TestSessionCookieName(t *testing.T) {
+	customCookieName := "custom_session"
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:                &MockDBTX{},
+		SecretKey:         "test-secret-key",
+		SessionCookieName: customCookieName,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if client.SessionCookieName() != customCookieName {
+		t.Errorf("Expected cookie name %s, got %s", customCookieName, client.SessionCookieName())
+	}
+}
+
+// TestAuthMiddlewareContextKeys tests that session is stored with correct key
+func TestAuthMiddlewareContextKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("default session key", func(t *testing.T) {
+		// Simulate middleware behavior with mocked successful session
+		router := gin.New()
+
+		router.Use(func(c *gin.Context) {
+			// Simulate what AuthMiddleware does - store with default key
+			mockSession := &djsession.RawSession{
+				SessionKey:  "test-key",
+				SessionData: "test-data",
+				ExpireDate:  time.Now().Add(1 * time.Hour),
+			}
+			c.Set("django_session", mockSession)
+			c.Next()
+		})
+
+		router.GET("/test", func(c *gin.Context) {
+			sessionValue, exists := c.Get("django_session")
+			if !exists {
+				t.Error("Expected django_session key in context")
+			}
+
+			_, ok := sessionValue.(*djsession.RawSession)
+			if !ok {
+				t.Errorf("Expected *RawSession type, got %T", sessionValue)
+			}
+
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("custom session key", func(t *testing.T) {
+		customKey := "my_session"
+
+		router := gin.New()
+
+		router.Use(func(c *gin.Context) {
+			// Simulate middleware with custom key
+			mockSession := &djsession.RawSession{
+				SessionKey:  "test-key",
+				SessionData: "test-data",
+				ExpireDate:  time.Now().Add(1 * time.Hour),
+			}
+			c.Set(customKey, mockSession)
+			c.Next()
+		})
+
+		router.GET("/test", func(c *gin.Context) {
+			sessionValue, exists := c.Get(customKey)
+			if !exists {
+				t.Errorf("Expected %s key in context", customKey)
+			}
+
+			_, ok := sessionValue.(*djsession.RawSession)
+			if !ok {
+				t.Errorf("Expected *RawSession type, got %T", sessionValue)
+			}
+
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestOptionalAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	client, err := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: "test-secret-key",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	t.Run("no session cookie - continues without redirect", func(t *testing.T) {
+		router := gin.New()
+
+		handlerCalled := false
+		var sessionExists bool
+
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+			Client: client,
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			handlerCalled = true
+			_, sessionExists = c.Get("django_session")
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if !handlerCalled {
+			t.Error("Expected handler to be called")
+		}
+
+		if sessionExists {
+			t.Error("Expected session to NOT exist in context when no cookie")
+		}
+
+		// Should NOT redirect
+		location := w.Header().Get("Location")
+		if location != "" {
+			t.Errorf("Expected no redirect, got redirect to %s", location)
+		}
+	})
+
+	t.Run("empty session cookie - continues without redirect", func(t *testing.T) {
+		router := gin.New()
+
+		handlerCalled := false
+		var sessionExists bool
+
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+			Client: client,
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			handlerCalled = true
+			_, sessionExists = c.Get("django_session")
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.AddCookie(&http.Cookie{
+			Name:  "sessionid",
+			Value: "",
+		})
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if !handlerCalled {
+			t.Error("Expected handler to be called")
+		}
+
+		if sessionExists {
+			t.Error("Expected session to NOT exist in context when empty cookie")
+		}
+
+		// Should NOT redirect
+		location := w.Header().Get("Location")
+		if location != "" {
+			t.Errorf("Expected no redirect, got redirect to %s", location)
+		}
+	})
+
+	t.Run("with custom session key", func(t *testing.T) {
+		customSessionKey := "my_custom_session"
+		router := gin.New()
+
+		var sessionExists bool
+
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+			Client:     client,
+			SessionKey: customSessionKey,
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			_, sessionExists = c.Get(customSessionKey)
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if sessionExists {
+			t.Error("Expected session to NOT exist in context with custom key")
+		}
+	})
+
+	t.Run("valid session - sets context", func(t *testing.T) {
+		// This test simulates what would happen with a valid session
+		router := gin.New()
+
+		handlerCalled := false
+		var sessionExists bool
+
+		router.Use(func(c *gin.Context) {
+			// Simulate OptionalAuthMiddleware with valid session
+			mockSession := &djsession.RawSession{
+				SessionKey:  "valid-session-key",
+				SessionData: "valid-data",
+				ExpireDate:  time.Now().Add(1 * time.Hour),
+			}
+			c.Set("django_session", mockSession)
+			c.Next()
+		})
+
+		router.GET("/test", func(c *gin.Context) {
+			handlerCalled = true
+			sessionValue, exists := c.Get("django_session")
+			sessionExists = exists
+
+			if exists {
+				rawSession, ok := sessionValue.(*djsession.RawSession)
+				if !ok {
+					t.Errorf("Expected *RawSession, got %T", sessionValue)
+				}
+				if rawSession.SessionKey != "valid-session-key" {
+					t.Errorf("Expected session key 'valid-session-key', got %s", rawSession.SessionKey)
+				}
+			}
+
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		if !handlerCalled {
+			t.Error("Expected handler to be called")
+		}
+
+		if !sessionExists {
+			t.Error("Expected session to exist in context with valid session")
+		}
+	})
+
+	t.Run("OnError handler is NOT called", func(t *testing.T) {
+		// OptionalAuthMiddleware should NOT call OnError handler
+		errorHandlerCalled := false
+
+		router := gin.New()
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+			Client: client,
+			OnError: func(c *gin.Context, err error) {
+				errorHandlerCalled = true
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		// No cookie set
+		router.ServeHTTP(w, req)
+
+		if errorHandlerCalled {
+			t.Error("Expected OnError handler to NOT be called in OptionalAuthMiddleware")
+		}
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+// newSessionDB returns a MockDBTX serving a single valid django_session row
+func newSessionDB(sessionKey, sessionData string) *MockDBTX {
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{sessionKey}).
+		Return(newMockRow(sessionKey, sessionData, time.Now().Add(time.Hour)))
+	return db
+}
+
+// serveWithCookie runs a GET /test request with the given session cookie value
+func serveWithCookie(router *gin.Engine, cookieValue string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/test", nil)
+	if cookieValue != "" {
+		req.AddCookie(&http.Cookie{Name: "sessionid", Value: cookieValue})
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddlewareWithFullUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+
+	sessionData, err := djsession.EncodeSessionData("42", secretKey, nil)
+	if err != nil {
+		t.Fatalf("Failed to create test session: %v", err)
+	}
+
+	t.Run("user loaded into context", func(t *testing.T) {
+		db := newSessionDB("abc", sessionData)
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var currentUser *djsession.DjangoUser
+		router := gin.New()
+		router.Use(AuthMiddlewareWithFullUser(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			currentUser, _ = GetCurrentUser(c)
+			if _, exists := c.Get("django_session"); !exists {
+				t.Error("Expected raw session in context")
+			}
+			c.Status(http.StatusOK)
+		})
+
+		w := serveWithCookie(router, "abc")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if currentUser == nil || currentUser.Username != "alice" {
+			t.Errorf("GetCurrentUser() = %+v, want alice", currentUser)
+		}
+	})
+
+	t.Run("custom user key", func(t *testing.T) {
+		db := newSessionDB("abc", sessionData)
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var currentUser *djsession.DjangoUser
+		var foundDefault bool
+		router := gin.New()
+		router.Use(AuthMiddlewareWithFullUser(MiddlewareConfig{Client: client, UserKey: "me"}))
+		router.GET("/test", func(c *gin.Context) {
+			currentUser, _ = GetCurrentUserWithKey(c, "me")
+			_, foundDefault = GetCurrentUser(c)
+			c.Status(http.StatusOK)
+		})
+
+		serveWithCookie(router, "abc")
+		if currentUser == nil || currentUser.Username != "alice" {
+			t.Errorf("GetCurrentUserWithKey() = %+v, want alice", currentUser)
+		}
+		if foundDefault {
+			t.Error("GetCurrentUser() found a user under the default key, want none")
+		}
+	})
+
+	t.Run("user lookup failure routes through OnError", func(t *testing.T) {
+		db := newSessionDB("abc", sessionData)
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 8))
+
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var capturedError error
+		handlerCalled := false
+		router := gin.New()
+		router.Use(AuthMiddlewareWithFullUser(MiddlewareConfig{
+			Client: client,
+			OnError: func(c *gin.Context, err error) {
+				capturedError = err
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			handlerCalled = true
+		})
+
+		w := serveWithCookie(router, "abc")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if !errors.Is(capturedError, djsession.ErrUserNotFound) {
+			t.Errorf("OnError received %v, want ErrUserNotFound", capturedError)
+		}
+		if handlerCalled {
+			t.Error("Expected handler NOT to be called")
+		}
+	})
+
+	t.Run("no cookie redirects", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		router := gin.New()
+		router.Use(AuthMiddlewareWithFullUser(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := serveWithCookie(router, "")
+		if w.Code != http.StatusFound {
+			t.Errorf("Expected status %d, got %d", http.StatusFound, w.Code)
+		}
+	})
+}
+
+func TestMiddlewareOnSuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	middlewares := map[string]func(MiddlewareConfig) gin.HandlerFunc{
+		"AuthMiddleware":         AuthMiddleware,
+		"OptionalAuthMiddleware": OptionalAuthMiddleware,
+	}
+
+	for name, middleware := range middlewares {
+		t.Run(name+" valid session", func(t *testing.T) {
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: secretKey})
+
+			var successSession *djsession.RawSession
+			var storedBeforeCallback bool
+			router := gin.New()
+			router.Use(middleware(MiddlewareConfig{
+				Client: client,
+				OnSuccess: func(c *gin.Context, session *djsession.RawSession) {
+					successSession = session
+					_, storedBeforeCallback = c.Get("django_session")
+				},
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				if successSession == nil {
+					t.Error("Expected OnSuccess to run before the handler")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			serveWithCookie(router, "abc")
+			if successSession == nil || successSession.SessionKey != "abc" {
+				t.Errorf("OnSuccess session = %+v, want key abc", successSession)
+			}
+			if !storedBeforeCallback {
+				t.Error("Expected session to be in context when OnSuccess runs")
+			}
+		})
+
+		t.Run(name+" no session", func(t *testing.T) {
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+			called := false
+			router := gin.New()
+			router.Use(middleware(MiddlewareConfig{
+				Client:    client,
+				OnSuccess: func(c *gin.Context, session *djsession.RawSession) { called = true },
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			serveWithCookie(router, "")
+			if called {
+				t.Error("Expected OnSuccess NOT to be called without a session")
+			}
+		})
+	}
+}
+
+func TestAuthMiddlewareDistinguishesErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+
+	validData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+	forgedData, _ := djsession.EncodeSessionData("1", "attacker-secret", nil)
+
+	db := newSessionDB("valid", validData)
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"forged"}).
+		Return(newMockRow("forged", forgedData, time.Now().Add(time.Hour)))
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"unknown"}).
+		Return(newErrorRow(pgx.ErrNoRows, 3))
+
+	client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+	tests := []struct {
+		name    string
+		cookie  string
+		wantErr error
+	}{
+		{"valid session", "valid", nil},
+		{"missing cookie", "", djsession.ErrNoSessionCookie},
+		{"unknown session", "unknown", djsession.ErrSessionNotFound},
+		{"tampered session data", "forged", djsession.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedError error
+			router := gin.New()
+			router.Use(AuthMiddleware(MiddlewareConfig{
+				Client:          client,
+				VerifySignature: true,
+				OnError: func(c *gin.Context, err error) {
+					capturedError = err
+					c.AbortWithStatus(http.StatusUnauthorized)
+				},
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, tt.cookie)
+			if tt.wantErr == nil {
+				if w.Code != http.StatusOK || capturedError != nil {
+					t.Errorf("Expected success, got status %d and error %v", w.Code, capturedError)
+				}
+				return
+			}
+			if !errors.Is(capturedError, tt.wantErr) {
+				t.Errorf("OnError received %v, want %v", capturedError, tt.wantErr)
+			}
+			for _, other := range []error{djsession.ErrNoSessionCookie, djsession.ErrSessionNotFound, djsession.ErrInvalidSignature} {
+				if other != tt.wantErr && errors.Is(capturedError, other) {
+					t.Errorf("error %v should not match %v", capturedError, other)
+				}
+			}
+		})
+	}
+
+	t.Run("signature not checked by default", func(t *testing.T) {
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		if w := serveWithCookie(router, "forged"); w.Code != http.StatusOK {
+			t.Errorf("Expected fast path to accept row, got status %d", w.Code)
+		}
+	})
+}
+
+func TestAuthMiddlewareDecodeEagerly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+
+	t.Run("user ID stored in context", func(t *testing.T) {
+		sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: secretKey})
+
+		var userID interface{}
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client, DecodeEagerly: true}))
+		router.GET("/test", func(c *gin.Context) {
+			userID, _ = c.Get(DefaultUserIDKey)
+			if _, exists := c.Get("django_session"); !exists {
+				t.Error("Expected raw session in context")
+			}
+			c.Status(http.StatusOK)
+		})
+
+		w := serveWithCookie(router, "abc")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if userID != "42" {
+			t.Errorf("user ID in context = %v, want 42", userID)
+		}
+	})
+
+	t.Run("decode error aborts", func(t *testing.T) {
+		forgedData, _ := djsession.EncodeSessionData("42", "other-secret", nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", forgedData), SecretKey: secretKey})
+
+		var capturedError error
+		handlerCalled := false
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client:        client,
+			DecodeEagerly: true,
+			OnError: func(c *gin.Context, err error) {
+				capturedError = err
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) {
+			handlerCalled = true
+		})
+
+		w := serveWithCookie(router, "abc")
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if !errors.Is(capturedError, djsession.ErrInvalidSignature) {
+			t.Errorf("OnError received %v, want ErrInvalidSignature", capturedError)
+		}
+		if handlerCalled {
+			t.Error("Expected handler NOT to be called")
+		}
+	})
+
+	t.Run("lazy by default", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", "unreadable"), SecretKey: secretKey})
+
+		var found bool
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			_, found = c.Get(DefaultUserIDKey)
+			c.Status(http.StatusOK)
+		})
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if found {
+			t.Error("Expected no user ID in context without DecodeEagerly")
+		}
+	})
+}
+
+func TestMiddlewareExpiryHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	newRouter := func(client *djsession.Client, config MiddlewareConfig) *gin.Engine {
+		config.Client = client
+		router := gin.New()
+		router.Use(AuthMiddleware(config))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	dbExpiringIn := func(d time.Duration) *MockDBTX {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData, time.Now().Add(d)))
+		return db
+	}
+
+	t.Run("remaining seconds until expire_date", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: dbExpiringIn(300*time.Second + 500*time.Millisecond), SecretKey: secretKey})
+
+		w := serveWithCookie(newRouter(client, MiddlewareConfig{SetExpiryHeader: true}), "abc")
+		if got := w.Header().Get("X-Session-Expires-In"); got != "300" {
+			t.Errorf("X-Session-Expires-In = %q, want 300", got)
+		}
+	})
+
+	t.Run("custom header name", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: dbExpiringIn(time.Minute + 500*time.Millisecond), SecretKey: secretKey})
+
+		w := serveWithCookie(newRouter(client, MiddlewareConfig{SetExpiryHeader: true, ExpiryHeaderName: "X-Expires"}), "abc")
+		if got := w.Header().Get("X-Expires"); got != "60" {
+			t.Errorf("X-Expires = %q, want 60", got)
+		}
+	})
+
+	t.Run("max age limits remaining time", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: dbExpiringIn(time.Hour), SecretKey: secretKey, MaxAge: 2 * time.Minute})
+
+		w := serveWithCookie(newRouter(client, MiddlewareConfig{SetExpiryHeader: true}), "abc")
+		if got := w.Header().Get("X-Session-Expires-In"); got != "119" && got != "120" {
+			t.Errorf("X-Session-Expires-In = %q, want about 120", got)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: dbExpiringIn(time.Hour), SecretKey: secretKey})
+
+		w := serveWithCookie(newRouter(client, MiddlewareConfig{}), "abc")
+		if got := w.Header().Get("X-Session-Expires-In"); got != "" {
+			t.Errorf("Expected no expiry header, got %q", got)
+		}
+	})
+
+	t.Run("not set on auth failure", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		w := serveWithCookie(newRouter(client, MiddlewareConfig{SetExpiryHeader: true}), "")
+		if got := w.Header().Get("X-Session-Expires-In"); got != "" {
+			t.Errorf("Expected no expiry header, got %q", got)
+		}
+	})
+}
+
+func TestMiddlewareRedirectStatusCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret-key"})
+
+	for _, code := range []int{http.StatusSeeOther, http.StatusTemporaryRedirect} {
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client, RedirectStatusCode: code}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := serveWithCookie(router, "")
+		if w.Code != code {
+			t.Errorf("Expected status %d, got %d", code, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "/account/login" {
+			t.Errorf("Expected redirect to /account/login, got %s", location)
+		}
+	}
+
+	for _, code := range []int{http.StatusOK, http.StatusUnauthorized, 99} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected AuthMiddleware to panic for RedirectStatusCode %d", code)
+				}
+			}()
+			AuthMiddleware(MiddlewareConfig{Client: client, RedirectStatusCode: code})
+		}()
+	}
+}
+
+func TestRequireSessionKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	truthy := func(v interface{}) bool { b, ok := v.(bool); return ok && b }
+
+	tests := []struct {
+		name      string
+		extra     map[string]interface{}
+		predicate func(interface{}) bool
+		wantCode  int
+	}{
+		{"key present and predicate true", map[string]interface{}{"mfa_verified": true}, truthy, http.StatusOK},
+		{"key present and predicate false", map[string]interface{}{"mfa_verified": false}, truthy, http.StatusForbidden},
+		{"key absent", nil, truthy, http.StatusForbidden},
+		{"key present with nil predicate", map[string]interface{}{"mfa_verified": false}, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionData, err := djsession.EncodeSessionData("42", secretKey, tt.extra)
+			if err != nil {
+				t.Fatalf("EncodeSessionData() error = %v", err)
+			}
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: secretKey})
+
+			var gotErr error
+			router := gin.New()
+			router.Use(RequireSessionKeyMiddleware(MiddlewareConfig{
+				Client: client,
+				OnError: func(c *gin.Context, err error) {
+					gotErr = err
+					c.Status(http.StatusForbidden)
+				},
+			}, "mfa_verified", tt.predicate))
+			router.GET("/test", func(c *gin.Context) {
+				if _, ok := c.Get(DefaultSessionDataKey); !ok {
+					t.Error("Expected decoded payload in context")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, "abc")
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode == http.StatusForbidden && !errors.Is(gotErr, djsession.ErrSessionKeyRequired) {
+				t.Errorf("OnError error = %v, want ErrSessionKeyRequired", gotErr)
+			}
+		})
+	}
+
+	t.Run("reuses payload cached on the request", func(t *testing.T) {
+		// Undecodable data proves the cached payload is used instead of decoding again
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", "not-signed"), SecretKey: secretKey})
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(DefaultSessionDataKey, map[string]interface{}{"mfa_verified": true})
+		})
+		router.Use(RequireSessionKeyMiddleware(MiddlewareConfig{Client: client}, "mfa_verified", truthy))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestOptionalAuthMiddlewareOnOptionalError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		cookie   string
+		wantHook bool
+	}{
+		{"bad cookie", "unknown", true},
+		{"no cookie", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 3))
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			var hookErr error
+			router := gin.New()
+			router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+				Client: client,
+				OnError: func(c *gin.Context, err error) {
+					t.Error("OnError should not be called by OptionalAuthMiddleware")
+				},
+				OnOptionalError: func(c *gin.Context, err error) {
+					hookErr = err
+				},
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, tt.cookie)
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if tt.wantHook && !errors.Is(hookErr, djsession.ErrSessionNotFound) {
+				t.Errorf("OnOptionalError error = %v, want ErrSessionNotFound", hookErr)
+			}
+			if !tt.wantHook && hookErr != nil {
+				t.Errorf("OnOptionalError called with %v, want no call", hookErr)
+			}
+		})
+	}
+}
+
+func TestOptionalAuthMiddlewareStoreError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		rowErr     error
+		failClosed bool
+		wantStatus int
+		wantStore  bool
+	}{
+		{"database down, fail open", dbErr, false, http.StatusOK, true},
+		{"database down, fail closed", dbErr, true, http.StatusFound, true},
+		{"session not found, fail closed", pgx.ErrNoRows, true, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(tt.rowErr, 3))
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			var optionalErr error
+			handlerRan := false
+			router := gin.New()
+			router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+				Client:                 client,
+				FailClosedOnStoreError: tt.failClosed,
+				OnOptionalError:        func(c *gin.Context, err error) { optionalErr = err },
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				handlerRan = true
+				if _, ok := SessionFromContext(c); ok {
+					t.Error("session stored in context after a failed lookup")
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, "abc")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if handlerRan == (tt.wantStatus != http.StatusOK) {
+				t.Errorf("handler ran = %v with status %d", handlerRan, w.Code)
+			}
+
+			if tt.failClosed && tt.wantStore {
+				if optionalErr != nil {
+					t.Errorf("OnOptionalError called with %v when failing closed", optionalErr)
+				}
+				return
+			}
+			if errors.Is(optionalErr, ErrStoreUnavailable) != tt.wantStore {
+				t.Errorf("OnOptionalError error = %v, ErrStoreUnavailable = %v", optionalErr, tt.wantStore)
+			}
+			if tt.wantStore && !errors.Is(optionalErr, dbErr) {
+				t.Errorf("OnOptionalError error = %v, want it to wrap the database error", optionalErr)
+			}
+			if !tt.wantStore && !errors.Is(optionalErr, djsession.ErrSessionNotFound) {
+				t.Errorf("OnOptionalError error = %v, want ErrSessionNotFound", optionalErr)
+			}
+		})
+	}
+
+	t.Run("fail closed through OnError", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(dbErr, 3))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		var onErr error
+		router := gin.New()
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{
+			Client:                 client,
+			FailClosedOnStoreError: true,
+			OnError: func(c *gin.Context, err error) {
+				onErr = err
+				c.AbortWithStatus(http.StatusServiceUnavailable)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+		if !errors.Is(onErr, ErrStoreUnavailable) || !errors.Is(onErr, dbErr) {
+			t.Errorf("OnError error = %v, want ErrStoreUnavailable wrapping the database error", onErr)
+		}
+	})
+}
+
+func TestMiddlewareSkipPaths(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &MockDBTX{}
+	client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	router := gin.New()
+	api := router.Group("/api")
+	api.Use(AuthMiddleware(MiddlewareConfig{
+		Client:    client,
+		SkipPaths: []string{"/api/health", "/api/webhooks/*"},
+	}))
+	for _, route := range []string{"/orders", "/health", "/webhooks/:provider", "/webhooks/:provider/events"} {
+		api.GET(route, func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+	}
+
+	tests := []struct {
+		path     string
+		wantCode int
+	}{
+		{"/api/orders", http.StatusFound},
+		{"/api/health", http.StatusOK},
+		{"/api/webhooks/stripe", http.StatusOK},
+		{"/api/webhooks/stripe/events", http.StatusFound}, // * does not cross "/"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			router.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+	db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for invalid SkipPaths pattern")
+		}
+	}()
+	AuthMiddleware(MiddlewareConfig{Client: client, SkipPaths: []string{"/api/["}})
+}
+
+// MockTx is a mock implementation of pgx.Tx
+type MockTx struct {
+	MockDBTX
+}
+
+func (m *MockTx) Commit(ctx context.Context) error   { return m.Called(ctx).Error(0) }
+func (m *MockTx) Rollback(ctx context.Context) error { return m.Called(ctx).Error(0) }
+func (m *MockTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+func (m *MockTx) LargeObjects() pgx.LargeObjects { return pgx.LargeObjects{} }
+func (m *MockTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, errors.New("not supported")
+}
+func (m *MockTx) Conn() *pgx.Conn { return nil }
+
+func TestMiddlewareRotateKeyOnAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+	expireDate := time.Now().Add(time.Hour)
+
+	newRotatingDB := func(cycleErr error) (*MockDBTX, *MockTx) {
+		tx := &MockTx{}
+		if cycleErr != nil {
+			tx.On("QueryRow", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{"oldkey"}).
+				Return(newErrorRow(cycleErr, 2))
+		} else {
+			tx.On("QueryRow", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{"oldkey"}).
+				Return(newMockRow(sessionData, expireDate))
+		}
+		tx.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+
+		db := newSessionDB("oldkey", sessionData)
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		return db, tx
+	}
+	newRouter := func(config MiddlewareConfig, seen *string) *gin.Engine {
+		router := gin.New()
+		router.Use(AuthMiddleware(config))
+		router.GET("/test", func(c *gin.Context) {
+			if session, ok := SessionFromContext(c); ok {
+				*seen = session.SessionKey
+			}
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	sessionCookie := func(w *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "sessionid" {
+				return cookie
+			}
+		}
+		return nil
+	}
+
+	t.Run("sets cookie with the new key", func(t *testing.T) {
+		db, tx := newRotatingDB(nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var seen string
+		w := serveWithCookie(newRouter(MiddlewareConfig{Client: client, RotateKeyOnAuth: true}, &seen), "oldkey")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		cookie := sessionCookie(w)
+		if cookie == nil || cookie.Value == "" || cookie.Value == "oldkey" {
+			t.Fatalf("Expected Set-Cookie with a new session key, got %v", cookie)
+		}
+		if seen != cookie.Value {
+			t.Errorf("Handler saw session key %q, want rotated key %q", seen, cookie.Value)
+		}
+		tx.AssertCalled(t, "Exec", mock.Anything, sqlContains("INSERT INTO django_session"),
+			mock.MatchedBy(func(args []interface{}) bool { return args[0] == cookie.Value && args[1] == sessionData }))
+		tx.AssertCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("signed cookie round-trips", func(t *testing.T) {
+		db, _ := newRotatingDB(nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+		config := MiddlewareConfig{Client: client, RotateKeyOnAuth: true, CookieValueSigned: true, CookieSalt: "wrap"}
+		signedOld, _ := client.SignCookieValue("oldkey", "wrap")
+
+		var seen string
+		w := serveWithCookie(newRouter(config, &seen), signedOld)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		cookie := sessionCookie(w)
+		if cookie == nil {
+			t.Fatal("Expected Set-Cookie with the rotated session key")
+		}
+		if key, err := client.UnsignCookieValue(cookie.Value, "wrap"); err != nil || key != seen {
+			t.Errorf("UnsignCookieValue(cookie) = %q, %v, want rotated key %q", key, err, seen)
+		}
+	})
+
+	t.Run("drops the old key from a cached store", func(t *testing.T) {
+		db, _ := newRotatingDB(nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+		cache := newMapCache()
+		cache.Set(context.Background(), &djsession.RawSession{SessionKey: "oldkey", SessionData: sessionData, ExpireDate: expireDate}, time.Hour)
+		store := djsession.NewCachedDBStore(cache, client)
+
+		var seen string
+		w := serveWithCookie(newRouter(MiddlewareConfig{Client: client, Store: store, RotateKeyOnAuth: true}, &seen), "oldkey")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if _, err := cache.Get(context.Background(), "oldkey"); !errors.Is(err, djsession.ErrSessionNotFound) {
+			t.Errorf("cache.Get(oldkey) error = %v, want the old key invalidated", err)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		db, _ := newRotatingDB(nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var seen string
+		w := serveWithCookie(newRouter(MiddlewareConfig{Client: client}, &seen), "oldkey")
+		if cookie := sessionCookie(w); cookie != nil {
+			t.Errorf("Expected no Set-Cookie, got %v", cookie)
+		}
+		if seen != "oldkey" {
+			t.Errorf("Handler saw session key %q, want oldkey", seen)
+		}
+		db.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("rotation failure is an auth failure", func(t *testing.T) {
+		db, _ := newRotatingDB(pgx.ErrNoRows)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var seen string
+		w := serveWithCookie(newRouter(MiddlewareConfig{Client: client, RotateKeyOnAuth: true}, &seen), "oldkey")
+		if w.Code != http.StatusFound || seen != "" {
+			t.Errorf("Expected login redirect, got status %d (handler saw %q)", w.Code, seen)
+		}
+		if cookie := sessionCookie(w); cookie != nil {
+			t.Errorf("Expected no Set-Cookie, got %v", cookie)
+		}
+	})
+}
+
+func TestMiddlewareMemoizePerRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	newRouter := func(config MiddlewareConfig) *gin.Engine {
+		router := gin.New()
+		router.Use(AuthMiddleware(config))
+		router.GET("/test", RequireSessionKeyMiddleware(config, "_auth_user_id", nil), func(c *gin.Context) {
+			// A batched sub-request validating the same cookie again
+			if _, _, err := getSessionFromCookie(c, config); err != nil {
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	t.Run("one query per request", func(t *testing.T) {
+		db := newSessionDB("abc", sessionData)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+		router := newRouter(MiddlewareConfig{Client: client, MemoizePerRequest: true})
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+
+		// The memo is scoped to one request
+		serveWithCookie(router, "abc")
+		db.AssertNumberOfCalls(t, "QueryRow", 2)
+	})
+
+	t.Run("failed lookup is reused", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 3))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		var errs []error
+		router := gin.New()
+		router.Use(OptionalAuthMiddleware(MiddlewareConfig{Client: client, MemoizePerRequest: true}))
+		router.GET("/test", func(c *gin.Context) {
+			_, _, err := getSessionFromCookie(c, MiddlewareConfig{Client: client, MemoizePerRequest: true})
+			errs = append(errs, err)
+			c.Status(http.StatusOK)
+		})
+
+		serveWithCookie(router, "abc")
+		if len(errs) != 1 || !errors.Is(errs[0], djsession.ErrSessionNotFound) {
+			t.Errorf("Expected memoized ErrSessionNotFound, got %v", errs)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		db := newSessionDB("abc", sessionData)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		if w := serveWithCookie(newRouter(MiddlewareConfig{Client: client}), "abc"); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 3)
+	})
+}
+
+func TestMiddlewareAuditOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	middlewares := map[string]func(MiddlewareConfig) gin.HandlerFunc{
+		"AuthMiddleware": AuthMiddleware,
+		"RequireSessionKeyMiddleware": func(config MiddlewareConfig) gin.HandlerFunc {
+			return RequireSessionKeyMiddleware(config, "_auth_user_id", nil)
+		},
+	}
+
+	tests := []struct {
+		name          string
+		cookie        string
+		wantWouldFail bool
+		wantErr       error
+	}{
+		{"valid session", "abc", false, nil},
+		{"no cookie", "", true, djsession.ErrNoSessionCookie},
+		{"unknown session", "missing", true, djsession.ErrSessionNotFound},
+	}
+
+	for name, middleware := range middlewares {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				db := newSessionDB("abc", sessionData)
+				db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"missing"}).
+					Return(newErrorRow(pgx.ErrNoRows, 3))
+				client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+				var audited []error
+				onErrorCalled := false
+				config := MiddlewareConfig{
+					Client:    client,
+					AuditOnly: true,
+					OnAudit:   func(c *gin.Context, err error) { audited = append(audited, err) },
+					OnError:   func(c *gin.Context, err error) { onErrorCalled = true },
+				}
+
+				var wouldFail, flagSet, hasSession bool
+				router := gin.New()
+				router.Use(middleware(config))
+				router.GET("/test", func(c *gin.Context) {
+					value, exists := c.Get(AuthWouldFailKey)
+					wouldFail, flagSet = value.(bool)
+					flagSet = flagSet && exists
+					_, hasSession = SessionFromContext(c)
+					c.Status(http.StatusOK)
+				})
+
+				w := serveWithCookie(router, tt.cookie)
+				if w.Code != http.StatusOK {
+					t.Fatalf("Expected request to proceed with status %d, got %d", http.StatusOK, w.Code)
+				}
+				if !flagSet || wouldFail != tt.wantWouldFail {
+					t.Errorf("%s = %v (set: %v), want %v", AuthWouldFailKey, wouldFail, flagSet, tt.wantWouldFail)
+				}
+				if hasSession == tt.wantWouldFail {
+					t.Errorf("session in context = %v, want %v", hasSession, !tt.wantWouldFail)
+				}
+				if len(audited) != 1 || !errors.Is(audited[0], tt.wantErr) {
+					t.Errorf("OnAudit errors = %v, want [%v]", audited, tt.wantErr)
+				}
+				if onErrorCalled {
+					t.Error("Expected OnError not to be called in AuditOnly mode")
+				}
+			})
+		}
+	}
+
+	t.Run("flag not set without AuditOnly", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", sessionData), SecretKey: secretKey})
+
+		flagSet := false
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client}))
+		router.GET("/test", func(c *gin.Context) {
+			_, flagSet = c.Get(AuthWouldFailKey)
+			c.Status(http.StatusOK)
+		})
+
+		serveWithCookie(router, "abc")
+		if flagSet {
+			t.Errorf("Expected %s to be unset", AuthWouldFailKey)
+		}
+		if w := serveWithCookie(router, ""); w.Code != http.StatusFound {
+			t.Errorf("Expected redirect without AuditOnly, got %d", w.Code)
+		}
+	})
+}
+
+func TestMiddlewareExpiryGrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	tests := []struct {
+		name        string
+		expiresIn   time.Duration
+		grace       time.Duration
+		wantStatus  int
+		wantInGrace bool
+	}{
+		{"valid session", time.Hour, time.Minute, http.StatusOK, false},
+		{"expired within grace", -30 * time.Second, time.Minute, http.StatusOK, true},
+		{"expired beyond grace", -5 * time.Minute, time.Minute, http.StatusFound, false},
+		{"expired without grace", -30 * time.Second, 0, http.StatusFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+				Return(newMockRow("abc", sessionData, time.Now().Add(tt.expiresIn)))
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+			inGrace := false
+			router := gin.New()
+			router.Use(AuthMiddleware(MiddlewareConfig{Client: client, ExpiryGrace: tt.grace}))
+			router.GET("/test", func(c *gin.Context) {
+				inGrace = c.GetBool(SessionInGraceKey)
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, "abc")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if inGrace != tt.wantInGrace {
+				t.Errorf("%s = %v, want %v", SessionInGraceKey, inGrace, tt.wantInGrace)
+			}
+			db.AssertNumberOfCalls(t, "QueryRow", 1)
+		})
+	}
+
+	t.Run("grace judged by the client clock", func(t *testing.T) {
+		expireDate := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		for _, tt := range []struct {
+			name        string
+			now         time.Time
+			wantStatus  int
+			wantInGrace bool
+		}{
+			{"within grace", expireDate.Add(30 * time.Second), http.StatusOK, true},
+			{"beyond grace", expireDate.Add(5 * time.Minute), http.StatusFound, false},
+			{"not yet expired", expireDate.Add(-time.Second), http.StatusOK, false},
+		} {
+			db := &MockDBTX{}
+			db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+				Return(newMockRow("abc", sessionData, expireDate))
+			now := tt.now
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey, Now: func() time.Time { return now }})
+
+			inGrace := false
+			router := gin.New()
+			router.Use(AuthMiddleware(MiddlewareConfig{Client: client, ExpiryGrace: time.Minute}))
+			router.GET("/test", func(c *gin.Context) {
+				inGrace = c.GetBool(SessionInGraceKey)
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, "abc")
+			if w.Code != tt.wantStatus || inGrace != tt.wantInGrace {
+				t.Errorf("%s: status = %d, in grace = %v, want %d, %v", tt.name, w.Code, inGrace, tt.wantStatus, tt.wantInGrace)
+			}
+		}
+	})
+
+	t.Run("revoked session within grace", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData, time.Now().Add(-30*time.Second)))
+		client, _ := djsession.NewClient(djsession.ClientConfig{
+			DB:        db,
+			SecretKey: secretKey,
+			RevocationChecker: func(ctx context.Context, sessionKey string) (bool, error) {
+				return true, nil
+			},
+		})
+
+		var gotErr error
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client:      client,
+			ExpiryGrace: time.Minute,
+			OnError: func(c *gin.Context, err error) {
+				gotErr = err
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if !errors.Is(gotErr, djsession.ErrSessionRevoked) {
+			t.Errorf("OnError error = %v, want ErrSessionRevoked", gotErr)
+		}
+	})
+
+	t.Run("tampered session within grace with client signature verification", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData+"x", time.Now().Add(-30*time.Second)))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey, VerifySignature: true})
+
+		var gotErr error
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{
+			Client:      client,
+			ExpiryGrace: time.Minute,
+			OnError: func(c *gin.Context, err error) {
+				gotErr = err
+				c.AbortWithStatus(http.StatusUnauthorized)
+			},
+		}))
+		router.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		if w := serveWithCookie(router, "abc"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if !errors.Is(gotErr, djsession.ErrInvalidSignature) {
+			t.Errorf("OnError error = %v, want ErrInvalidSignature", gotErr)
+		}
+	})
+}
+
+func TestMiddlewareTrackSessionAge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+
+	// Session data signed three hours ago, e.g. at login
+	loginTime := time.Now().Add(-3 * time.Hour)
+	signer, _ := djsession.NewClient(djsession.ClientConfig{
+		DB:        &MockDBTX{},
+		SecretKey: secretKey,
+		Now:       func() time.Time { return loginTime },
+	})
+	_, sessionData, _, _ := signer.BuildSession("42", nil)
+
+	tests := []struct {
+		name        string
+		middleware  func(MiddlewareConfig) gin.HandlerFunc
+		sessionData string
+		track       bool
+		wantAge     bool
+	}{
+		{"auth middleware", AuthMiddleware, sessionData, true, true},
+		{"optional middleware", OptionalAuthMiddleware, sessionData, true, true},
+		{"disabled", AuthMiddleware, sessionData, false, false},
+		{"signature does not verify", AuthMiddleware, sessionData[:len(sessionData)-2] + "xx", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: newSessionDB("abc", tt.sessionData), SecretKey: secretKey})
+
+			var reported time.Duration
+			var stored interface{}
+			var exists bool
+			router := gin.New()
+			router.Use(tt.middleware(MiddlewareConfig{
+				Client:          client,
+				TrackSessionAge: tt.track,
+				OnSessionAge:    func(c *gin.Context, age time.Duration) { reported = age },
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				stored, exists = c.Get(SessionAgeKey)
+				c.Status(http.StatusOK)
+			})
+
+			if w := serveWithCookie(router, "abc"); w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if exists != tt.wantAge {
+				t.Fatalf("%s set = %v, want %v", SessionAgeKey, exists, tt.wantAge)
+			}
+			if !tt.wantAge {
+				return
+			}
+			age, ok := stored.(time.Duration)
+			if !ok || age < 3*time.Hour-time.Minute || age > 3*time.Hour+time.Minute {
+				t.Errorf("%s = %v, want about 3h", SessionAgeKey, stored)
+			}
+			if reported != age {
+				t.Errorf("OnSessionAge got %v, want %v", reported, age)
+			}
+		})
+	}
+}
+
+func TestMiddlewareCookieValueSigned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionKey := "abcdefghijklmnopqrstuvwxyz012345"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+
+	// Signed like Django's response.set_signed_cookie("sessionid", sessionKey, salt="session-wrap")
+	cookieSigner := &djsession.DjangoSigner{
+		SecretKey: "django.http.cookies" + secretKey,
+		Salt:      "sessionid" + "session-wrap",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+	signed, err := cookieSigner.SignTimestamp(sessionKey)
+	if err != nil {
+		t.Fatalf("SignTimestamp() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		cookie     string
+		wantStatus int
+		wantErr    error
+	}{
+		{"signed cookie", signed, http.StatusOK, nil},
+		{"tampered cookie", signed[:len(signed)-2] + "xx", http.StatusUnauthorized, djsession.ErrInvalidSignature},
+		{"unsigned cookie", sessionKey, http.StatusUnauthorized, djsession.ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newSessionDB(sessionKey, sessionData)
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+			var gotErr error
+			var gotKey string
+			router := gin.New()
+			router.Use(AuthMiddleware(MiddlewareConfig{
+				Client:            client,
+				CookieValueSigned: true,
+				CookieSalt:        "session-wrap",
+				OnError: func(c *gin.Context, err error) {
+					gotErr = err
+					c.AbortWithStatus(http.StatusUnauthorized)
+				},
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				session, _ := SessionFromContext(c)
+				gotKey = session.SessionKey
+				c.Status(http.StatusOK)
+			})
+
+			w := serveWithCookie(router, tt.cookie)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d (error %v)", tt.wantStatus, w.Code, gotErr)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(gotErr, tt.wantErr) {
+					t.Errorf("OnError error = %v, want %v", gotErr, tt.wantErr)
+				}
+				db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			if gotKey != sessionKey {
+				t.Errorf("session key = %q, want %q", gotKey, sessionKey)
+			}
+		})
+	}
+}
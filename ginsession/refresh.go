@@ -0,0 +1,62 @@
+package ginsession
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// RefreshConfig configures the handler returned by RefreshHandler
+type RefreshConfig struct {
+	SessionKey        string // Context key the middleware stored the session under (default: "django_session")
+	CookieValueSigned bool   // Sign the reissued cookie value, matching MiddlewareConfig.CookieValueSigned
+	CookieSalt        string // Salt for the signed cookie value, matching MiddlewareConfig.CookieSalt (default: "")
+}
+
+// RefreshHandler returns a keep-alive handler: it extends the session stored by the
+// middleware with TouchSession, reissues the session cookie with the new expiry through
+// the client's CookieWriter and responds with the new expire_date. Responds 401
+// without a session or when it no longer verifies, and 500 on database errors.
+// When a tenant client was chosen by the middleware, it is used instead of base.
+func RefreshHandler(base *djsession.Client, config RefreshConfig) gin.HandlerFunc {
+	if config.SessionKey == "" {
+		config.SessionKey = "django_session"
+	}
+
+	return func(ctx *gin.Context) {
+		value, exists := ctx.Get(config.SessionKey)
+		rawSession, ok := value.(*djsession.RawSession)
+		if !exists || !ok || rawSession == nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			return
+		}
+
+		client := base
+		if tenant, ok := GetClient(ctx); ok {
+			client = tenant
+		}
+
+		refreshed, err := client.TouchSession(ctx.Request.Context(), rawSession)
+		if err != nil {
+			if errors.Is(err, djsession.ErrSessionNotFound) || errors.Is(err, djsession.ErrSessionExpired) ||
+				errors.Is(err, djsession.ErrInvalidSignature) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			} else {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			}
+			return
+		}
+
+		if err := setSessionCookie(ctx, client, refreshed, config.CookieValueSigned, config.CookieSalt); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+
+		ctx.Set(config.SessionKey, refreshed)
+		setRequestSession(ctx, refreshed)
+		ctx.JSON(http.StatusOK, gin.H{"expire_date": refreshed.ExpireDate.UTC().Format(time.RFC3339)})
+	}
+}
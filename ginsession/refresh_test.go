@@ -0,0 +1,121 @@
+package ginsession
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRefreshHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+	session := &djsession.RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: time.Now().Add(time.Minute)}
+
+	serve := func(client *djsession.Client, value interface{}, configs ...RefreshConfig) (*httptest.ResponseRecorder, *djsession.RawSession) {
+		var config RefreshConfig
+		if len(configs) > 0 {
+			config = configs[0]
+		}
+		var after *djsession.RawSession
+		router := gin.New()
+		router.POST("/refresh", func(c *gin.Context) {
+			if value != nil {
+				c.Set("django_session", value)
+			}
+			c.Next()
+			after, _ = SessionFromContext(c)
+		}, RefreshHandler(client, config))
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/refresh", nil)
+		router.ServeHTTP(w, req)
+		return w, after
+	}
+
+	t.Run("with session", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey, MaxAge: time.Hour})
+
+		w, refreshed := serve(client, session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid JSON response %q: %v", w.Body.String(), err)
+		}
+		expireDate, err := time.Parse(time.RFC3339, body["expire_date"])
+		if err != nil {
+			t.Fatalf("expire_date %q is not RFC 3339: %v", body["expire_date"], err)
+		}
+		if until := time.Until(expireDate); until < 59*time.Minute || until > time.Hour {
+			t.Errorf("expire_date %v is not an hour from now", expireDate)
+		}
+		if refreshed == nil || refreshed.SessionData == sessionData {
+			t.Errorf("context session = %+v, want the refreshed session", refreshed)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "sessionid" || cookies[0].Value != "abc" {
+			t.Fatalf("Set-Cookie = %v, want the sessionid cookie reissued", cookies)
+		}
+		if !cookies[0].Expires.Equal(expireDate) {
+			t.Errorf("cookie Expires = %v, want %v", cookies[0].Expires, expireDate)
+		}
+		if maxAge := time.Duration(cookies[0].MaxAge) * time.Second; maxAge < 59*time.Minute || maxAge > time.Hour {
+			t.Errorf("cookie Max-Age = %v, want about an hour", maxAge)
+		}
+		db.AssertNumberOfCalls(t, "Exec", 1)
+	})
+
+	t.Run("signed cookie", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 1"), nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		w, _ := serve(client, session, RefreshConfig{CookieValueSigned: true, CookieSalt: "proxy"})
+		cookies := w.Result().Cookies()
+		if w.Code != http.StatusOK || len(cookies) != 1 {
+			t.Fatalf("Expected status %d and one cookie, got %d and %v", http.StatusOK, w.Code, cookies)
+		}
+		if key, err := client.UnsignCookieValue(cookies[0].Value, "proxy"); err != nil || key != "abc" {
+			t.Errorf("UnsignCookieValue(%q) = %q, %v, want abc", cookies[0].Value, key, err)
+		}
+	})
+
+	t.Run("without session", func(t *testing.T) {
+		db := &MockDBTX{}
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		w, _ := serve(client, nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("session deleted meanwhile", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Exec", mock.Anything, sqlContains("UPDATE django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("UPDATE 0"), nil)
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		w, _ := serve(client, session)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+		if cookies := w.Result().Cookies(); len(cookies) != 0 {
+			t.Errorf("Expected no cookie, got %v", cookies)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package ginsession
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/knrd/go-gin-django-session/testutil"
+	"github.com/stretchr/testify/mock"
+)
+
+// mapCache is an in-memory djsession.SessionCache that supports Delete
+type mapCache struct {
+	mu       sync.Mutex
+	sessions map[string]*djsession.RawSession
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{sessions: make(map[string]*djsession.RawSession)}
+}
+
+func (m *mapCache) Get(ctx context.Context, sessionKey string) (*djsession.RawSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionKey]
+	if !ok {
+		return nil, djsession.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (m *mapCache) Set(ctx context.Context, session *djsession.RawSession, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.SessionKey] = session
+	return nil
+}
+
+func (m *mapCache) Delete(ctx context.Context, sessionKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionKey)
+	return nil
+}
+
+func TestAuthMiddlewareWithStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := testutil.NewMockStore(&djsession.RawSession{SessionKey: "abc", ExpireDate: time.Now().Add(time.Hour)})
+
+	db := &MockDBTX{}
+	client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	router := gin.New()
+	router.Use(AuthMiddleware(MiddlewareConfig{
+		Client: client,
+		Store:  store,
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := serveWithCookie(router, "abc")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMiddlewareStrictSessionKeyFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	validKey := "abcdefghijklmnopqrstuvwxyz012345"
+
+	malformed := []string{
+		"<script>alert(1)</script>",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ012345",
+		"abc",
+		strings.Repeat("a", 4096),
+		"abcdefghijklmnopqrstuvwxyz01234.",
+	}
+
+	for _, cookie := range malformed {
+		name := cookie
+		if len(name) > 40 {
+			name = name[:40] + "..."
+		}
+		t.Run(name, func(t *testing.T) {
+			store := &countingStore{MockStore: testutil.NewMockStore()}
+			db := &MockDBTX{}
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "test-secret"})
+
+			var gotErr error
+			router := gin.New()
+			router.Use(AuthMiddleware(MiddlewareConfig{
+				Client:                 client,
+				Store:                  store,
+				StrictSessionKeyFormat: true,
+				OnError: func(c *gin.Context, err error) {
+					gotErr = err
+					c.AbortWithStatus(http.StatusUnauthorized)
+				},
+			}))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("Cookie", "sessionid="+cookie)
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusUnauthorized || !errors.Is(gotErr, djsession.ErrSessionNotFound) {
+				t.Errorf("Expected ErrSessionNotFound auth failure, got status %d, error %v", w.Code, gotErr)
+			}
+			if store.calls != 0 {
+				t.Errorf("Expected no store lookups, got %d", store.calls)
+			}
+			db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("valid key reaches the store", func(t *testing.T) {
+		store := &countingStore{MockStore: testutil.NewMockStore(&djsession.RawSession{SessionKey: validKey, ExpireDate: time.Now().Add(time.Hour)})}
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+		router := gin.New()
+		router.Use(AuthMiddleware(MiddlewareConfig{Client: client, Store: store, StrictSessionKeyFormat: true}))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		if w := serveWithCookie(router, validKey); w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if store.calls != 1 {
+			t.Errorf("Expected 1 store lookup, got %d", store.calls)
+		}
+	})
+}
+
+// countingStore counts lookups made against a MockStore
+type countingStore struct {
+	*testutil.MockStore
+	calls int
+}
+
+func (s *countingStore) GetRawSession(ctx context.Context, sessionKey string) (*djsession.RawSession, error) {
+	s.calls++
+	return s.MockStore.GetRawSession(ctx, sessionKey)
+}
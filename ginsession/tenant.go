@@ -0,0 +1,50 @@
+package ginsession
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// tenantClients caches per-secret copies of a base client for SecretKeyResolver
+type tenantClients struct {
+	base    *djsession.Client
+	clients sync.Map // secret key -> *djsession.Client
+}
+
+// get returns the cached client for secretKey, deriving it from base on first use
+func (t *tenantClients) get(secretKey string) (*djsession.Client, error) {
+	if client, ok := t.clients.Load(secretKey); ok {
+		return client.(*djsession.Client), nil
+	}
+	client, err := t.base.WithSecretKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := t.clients.LoadOrStore(secretKey, client)
+	return actual.(*djsession.Client), nil
+}
+
+// secretKeyClientResolver adapts a SecretKeyResolver into a ClientResolver over base
+func secretKeyClientResolver(base *djsession.Client, resolve func(c *gin.Context) (string, error)) func(c *gin.Context) (*djsession.Client, error) {
+	tenants := &tenantClients{base: base}
+	return func(c *gin.Context) (*djsession.Client, error) {
+		secretKey, err := resolve(c)
+		if err != nil {
+			return nil, err
+		}
+		return tenants.get(secretKey)
+	}
+}
+
+// GetClient returns the client the middleware used for this request: the tenant client
+// chosen by ClientResolver or SecretKeyResolver, or MiddlewareConfig.Client otherwise
+func GetClient(c *gin.Context) (*djsession.Client, bool) {
+	value, exists := c.Get(DefaultClientKey)
+	if !exists {
+		return nil, false
+	}
+	client, ok := value.(*djsession.Client)
+	return client, ok
+}
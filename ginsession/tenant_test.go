@@ -0,0 +1,154 @@
+package ginsession
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTenantClientsCache(t *testing.T) {
+	base, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: "secret-a"})
+
+	tenants := &tenantClients{base: base}
+	first, _ := tenants.get("secret-b")
+	second, _ := tenants.get("secret-b")
+	if first != second {
+		t.Error("Expected tenant clients to be cached per secret")
+	}
+	if _, err := tenants.get(""); err == nil {
+		t.Error("get(\"\") expected error")
+	}
+}
+
+func TestMiddlewareSecretKeyResolver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	secrets := map[string]string{"a.example.com": "secret-a", "b.example.com": "secret-b"}
+	sessionA, _ := djsession.EncodeSessionData("1", "secret-a", nil)
+	sessionB, _ := djsession.EncodeSessionData("2", "secret-b", nil)
+
+	db := newSessionDB("keya", sessionA)
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"keyb"}).
+		Return(newMockRow("keyb", sessionB, time.Now().Add(time.Hour)))
+	base, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: "gateway-default"})
+
+	var capturedError error
+	var userID interface{}
+	router := gin.New()
+	router.Use(AuthMiddleware(MiddlewareConfig{
+		Client:        base,
+		DecodeEagerly: true,
+		SecretKeyResolver: func(c *gin.Context) (string, error) {
+			secret, ok := secrets[c.Request.Host]
+			if !ok {
+				return "", errors.New("unknown tenant")
+			}
+			return secret, nil
+		},
+		OnError: func(c *gin.Context, err error) {
+			capturedError = err
+			c.AbortWithStatus(http.StatusUnauthorized)
+		},
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		userID, _ = c.Get(DefaultUserIDKey)
+		if client, ok := GetClient(c); !ok || client == base {
+			t.Error("Expected the tenant client in context")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(host, cookie string) int {
+		capturedError, userID = nil, nil
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Host = host
+		req.AddCookie(&http.Cookie{Name: "sessionid", Value: cookie})
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name    string
+		host    string
+		cookie  string
+		want    int
+		userID  string
+		wantErr error
+	}{
+		{"tenant a", "a.example.com", "keya", http.StatusOK, "1", nil},
+		{"tenant b", "b.example.com", "keyb", http.StatusOK, "2", nil},
+		{"tenant a session on tenant b", "b.example.com", "keya", http.StatusUnauthorized, "", djsession.ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serve(tt.host, tt.cookie); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+			if tt.wantErr != nil && !errors.Is(capturedError, tt.wantErr) {
+				t.Errorf("OnError received %v, want %v", capturedError, tt.wantErr)
+			}
+			if tt.userID != "" && userID != tt.userID {
+				t.Errorf("user ID = %v, want %s", userID, tt.userID)
+			}
+		})
+	}
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		if code := serve("c.example.com", "keya"); code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", code, http.StatusUnauthorized)
+		}
+		if capturedError == nil || capturedError.Error() != "unknown tenant" {
+			t.Errorf("OnError received %v, want resolver error", capturedError)
+		}
+	})
+}
+
+func TestMiddlewareClientResolver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	sessionA, _ := djsession.EncodeSessionData("1", "secret-a", nil)
+	sessionB, _ := djsession.EncodeSessionData("2", "secret-b", nil)
+	dbA := newSessionDB("shared", sessionA)
+	dbB := newSessionDB("shared", sessionB)
+	clientA, _ := djsession.NewClient(djsession.ClientConfig{DB: dbA, SecretKey: "secret-a"})
+	clientB, _ := djsession.NewClient(djsession.ClientConfig{DB: dbB, SecretKey: "secret-b"})
+
+	var userID string
+	router := gin.New()
+	router.Use(AuthMiddleware(MiddlewareConfig{
+		Client: clientA,
+		ClientResolver: func(c *gin.Context) (*djsession.Client, error) {
+			if c.Request.Host == "b.example.com" {
+				return clientB, nil
+			}
+			return clientA, nil
+		},
+	}))
+	router.GET("/test", func(c *gin.Context) {
+		client, _ := GetClient(c)
+		raw := c.MustGet("django_session").(*djsession.RawSession)
+		userID, _ = client.DecodeSessionUserID(raw.SessionData)
+		c.Status(http.StatusOK)
+	})
+
+	for host, want := range map[string]string{"a.example.com": "1", "b.example.com": "2"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Host = host
+		req.AddCookie(&http.Cookie{Name: "sessionid", Value: "shared"})
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || userID != want {
+			t.Errorf("%s: status %d, user ID %q, want %s", host, w.Code, userID, want)
+		}
+	}
+	dbA.AssertNumberOfCalls(t, "QueryRow", 1)
+	dbB.AssertNumberOfCalls(t, "QueryRow", 1)
+}
@@ -0,0 +1,143 @@
+package ginsession
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// WhoAmIConfig configures the handler returned by WhoAmIHandler and IdentityJSON
+type WhoAmIConfig struct {
+	SessionKey string // Context key the middleware stored the session under (default: "django_session")
+	LoadUser   bool   // Load the user from auth_user and pass it to Format
+
+	// Fields selects the identity fields in IdentityJSON and the default Format response:
+	// "user_id", "username", "email", "first_name", "last_name", "is_active", "is_staff"
+	// and "is_superuser". User fields are only filled when LoadUser is set; unknown names
+	// are skipped. Default: user_id plus username, email and staff flags when the user is loaded.
+	Fields []string
+
+	// Format builds the WhoAmIHandler JSON response body. user is nil unless LoadUser is set.
+	// Default: the identity fields selected by Fields.
+	Format func(userID string, user *djsession.DjangoUser) interface{}
+}
+
+// defaultIdentityFields are the identity fields returned when WhoAmIConfig.Fields is empty
+var defaultIdentityFields = []string{"user_id", "username", "email", "is_staff", "is_superuser"}
+
+// WhoAmIHandler returns a handler that responds with the identity of the current session:
+// it reads the raw session stored by the middleware, decodes the user ID and optionally
+// loads the user. Responds 401 without a valid session and 500 on database errors.
+// When a tenant client was chosen by the middleware, it is used instead of base.
+func WhoAmIHandler(base *djsession.Client, config WhoAmIConfig) gin.HandlerFunc {
+	if config.Format == nil {
+		fields := config.Fields
+		config.Format = func(userID string, user *djsession.DjangoUser) interface{} {
+			return identityFields(userID, user, fields)
+		}
+	}
+
+	return func(ctx *gin.Context) {
+		userID, user, status := resolveIdentity(ctx, base, config)
+		if status != http.StatusOK {
+			ctx.AbortWithStatusJSON(status, identityError(status))
+			return
+		}
+		ctx.JSON(http.StatusOK, config.Format(userID, user))
+	}
+}
+
+// IdentityJSON resolves the identity of the current session like WhoAmIHandler and returns
+// the status and body to respond with, so handlers can c.JSON(IdentityJSON(c, client, config)).
+// The body holds the fields selected by config.Fields with status 200, or
+// {"error": "not authenticated"} with 401 when the context has no valid session
+// ({"error": "internal error"} with 500 on database errors). config.Format is not used.
+func IdentityJSON(c *gin.Context, base *djsession.Client, config WhoAmIConfig) (int, gin.H) {
+	userID, user, status := resolveIdentity(c, base, config)
+	if status != http.StatusOK {
+		return status, identityError(status)
+	}
+	return http.StatusOK, identityFields(userID, user, config.Fields)
+}
+
+// resolveIdentity decodes the user ID of the session stored by the middleware and loads
+// the user when LoadUser is set. status is 200 on success, 401 without a valid session or
+// user and 500 on database errors.
+func resolveIdentity(c *gin.Context, base *djsession.Client, config WhoAmIConfig) (string, *djsession.DjangoUser, int) {
+	sessionKey := config.SessionKey
+	if sessionKey == "" {
+		sessionKey = "django_session"
+	}
+	value, exists := c.Get(sessionKey)
+	rawSession, ok := value.(*djsession.RawSession)
+	if !exists || !ok || rawSession == nil {
+		return "", nil, http.StatusUnauthorized
+	}
+
+	client := base
+	if tenant, ok := GetClient(c); ok {
+		client = tenant
+	}
+
+	userID, err := client.DecodeSessionUserID(rawSession.SessionData)
+	if err != nil {
+		return "", nil, http.StatusUnauthorized
+	}
+
+	var user *djsession.DjangoUser
+	if config.LoadUser {
+		user, err = client.GetUser(c.Request.Context(), userID)
+		if errors.Is(err, djsession.ErrUserNotFound) {
+			return "", nil, http.StatusUnauthorized
+		}
+		if err != nil {
+			return "", nil, http.StatusInternalServerError
+		}
+	}
+	return userID, user, http.StatusOK
+}
+
+// identityError returns the error body for a resolveIdentity failure status
+func identityError(status int) gin.H {
+	if status == http.StatusUnauthorized {
+		return gin.H{"error": "not authenticated"}
+	}
+	return gin.H{"error": "internal error"}
+}
+
+// identityFields builds an identity body with the requested fields (defaultIdentityFields
+// when empty). User fields are omitted when user is nil.
+func identityFields(userID string, user *djsession.DjangoUser, fields []string) gin.H {
+	if len(fields) == 0 {
+		fields = defaultIdentityFields
+	}
+	body := gin.H{}
+	for _, field := range fields {
+		if field == "user_id" {
+			body[field] = userID
+			continue
+		}
+		if user == nil {
+			continue
+		}
+		switch field {
+		case "username":
+			body[field] = user.Username
+		case "email":
+			body[field] = user.Email
+		case "first_name":
+			body[field] = user.FirstName
+		case "last_name":
+			body[field] = user.LastName
+		case "is_active":
+			body[field] = user.IsActive
+		case "is_staff":
+			body[field] = user.IsStaff
+		case "is_superuser":
+			body[field] = user.IsSuperuser
+		}
+	}
+	return body
+}
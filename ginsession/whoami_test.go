@@ -0,0 +1,199 @@
+package ginsession
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWhoAmIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+	session := &djsession.RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: time.Now().Add(time.Hour)}
+
+	serve := func(handler gin.HandlerFunc, contextKey string, value interface{}) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/me", func(c *gin.Context) {
+			if value != nil {
+				c.Set(contextKey, value)
+			}
+		}, handler)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/me", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid JSON response %q: %v", w.Body.String(), err)
+		}
+		return body
+	}
+
+	t.Run("session in context", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		w := serve(WhoAmIHandler(client, WhoAmIConfig{}), "django_session", session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if body := decode(t, w); body["user_id"] != "42" {
+			t.Errorf("user_id = %v, want 42", body["user_id"])
+		}
+	})
+
+	t.Run("no session in context", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+		w := serve(WhoAmIHandler(client, WhoAmIConfig{}), "django_session", nil)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("undecodable session", func(t *testing.T) {
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: &MockDBTX{}, SecretKey: "other-secret"})
+
+		w := serve(WhoAmIHandler(client, WhoAmIConfig{}), "django_session", session)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("custom key with user and formatter", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, true, false))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		handler := WhoAmIHandler(client, WhoAmIConfig{
+			SessionKey: "sess",
+			LoadUser:   true,
+			Format: func(userID string, user *djsession.DjangoUser) interface{} {
+				return gin.H{"id": userID, "name": user.FirstName + " " + user.LastName}
+			},
+		})
+
+		w := serve(handler, "sess", session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		body := decode(t, w)
+		if body["id"] != "42" || body["name"] != "Alice Smith" {
+			t.Errorf("Unexpected body %v", body)
+		}
+	})
+
+	t.Run("default format with user", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, true, false))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		body := decode(t, serve(WhoAmIHandler(client, WhoAmIConfig{LoadUser: true}), "django_session", session))
+		if body["username"] != "alice" || body["is_staff"] != true {
+			t.Errorf("Unexpected body %v", body)
+		}
+	})
+
+	t.Run("user lookup database error", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), mock.Anything).
+			Return(newErrorRow(errors.New("connection refused"), 8))
+		client, _ := djsession.NewClient(djsession.ClientConfig{DB: db, SecretKey: secretKey})
+
+		w := serve(WhoAmIHandler(client, WhoAmIConfig{LoadUser: true}), "django_session", session)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestIdentityJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret-key"
+	sessionData, _ := djsession.EncodeSessionData("42", secretKey, nil)
+	session := &djsession.RawSession{SessionKey: "abc", SessionData: sessionData, ExpireDate: time.Now().Add(time.Hour)}
+
+	userDB := func() *MockDBTX {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, true, false))
+		return db
+	}
+
+	tests := []struct {
+		name       string
+		db         *MockDBTX
+		session    *djsession.RawSession
+		config     WhoAmIConfig
+		wantStatus int
+		wantBody   gin.H
+	}{
+		{
+			name:       "authenticated",
+			db:         &MockDBTX{},
+			session:    session,
+			wantStatus: http.StatusOK,
+			wantBody:   gin.H{"user_id": "42"},
+		},
+		{
+			name:       "authenticated with user",
+			db:         userDB(),
+			session:    session,
+			config:     WhoAmIConfig{LoadUser: true},
+			wantStatus: http.StatusOK,
+			wantBody:   gin.H{"user_id": "42", "username": "alice", "email": "alice@example.com", "is_staff": true, "is_superuser": false},
+		},
+		{
+			name:       "selected fields",
+			db:         userDB(),
+			session:    session,
+			config:     WhoAmIConfig{LoadUser: true, Fields: []string{"username", "first_name", "is_active", "unknown"}},
+			wantStatus: http.StatusOK,
+			wantBody:   gin.H{"username": "alice", "first_name": "Alice", "is_active": true},
+		},
+		{
+			name:       "unauthenticated",
+			db:         &MockDBTX{},
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   gin.H{"error": "not authenticated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := djsession.NewClient(djsession.ClientConfig{DB: tt.db, SecretKey: secretKey})
+
+			router := gin.New()
+			router.GET("/me", func(c *gin.Context) {
+				if tt.session != nil {
+					c.Set("django_session", tt.session)
+				}
+				c.JSON(IdentityJSON(c, client, tt.config))
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/me", nil)
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			want, _ := json.Marshal(tt.wantBody)
+			if w.Body.String() != string(want) {
+				t.Errorf("body = %s, want %s", w.Body.String(), want)
+			}
+		})
+	}
+}
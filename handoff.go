@@ -0,0 +1,53 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HandoffSalt is the salt ExportSession signs handoff tokens with, keeping them from
+// verifying as session data or under any other salt
+const HandoffSalt = "django_session.handoff"
+
+// ExportSession verifies sessionData (applying MaxAge) and re-signs its payload as a
+// short-lived token under HandoffSalt, for passing an authenticated user to another
+// service that shares the secret key. The token expires ttl after it is issued; the
+// receiving side verifies it with ImportSession.
+func (c *Client) ExportSession(sessionData string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", errors.New("handoff ttl must be positive")
+	}
+	data, err := c.DecodeSessionMap(sessionData)
+	if err != nil {
+		return "", err
+	}
+	return c.EncodeWithSalt(map[string]interface{}{
+		"data":    data,
+		"expires": c.nowFunc().Add(ttl).UnixMilli(),
+	}, HandoffSalt, true)
+}
+
+// ImportSession verifies a token made by ExportSession and returns the session payload.
+// Returns ErrInvalidSignature for forged tokens and ErrSessionExpired once the ttl has
+// passed; MaxAge does not apply.
+func (c *Client) ImportSession(token string) (map[string]interface{}, error) {
+	signer, err := c.signerWithSalt(HandoffSalt)
+	if err != nil {
+		return nil, err
+	}
+	envelope, err := signer.UnsignObject(token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	expires, err := numericValue(envelope["expires"])
+	data, ok := envelope["data"].(map[string]interface{})
+	if err != nil || expires == 0 || !ok {
+		return nil, errors.New("malformed handoff token")
+	}
+	if c.nowFunc().UnixMilli() > int64(expires) {
+		return nil, fmt.Errorf("%w: handoff token expired", ErrSessionExpired)
+	}
+	return data, nil
+}
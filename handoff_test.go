@@ -0,0 +1,78 @@
+package django_session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExportImportSession(t *testing.T) {
+	secretKey := "test-secret"
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"theme": "dark"})
+
+	token, err := client.ExportSession(sessionData, time.Minute)
+	if err != nil {
+		t.Fatalf("ExportSession() error = %v", err)
+	}
+
+	// The receiving service shares only the secret key
+	receiver, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	data, err := receiver.ImportSession(token)
+	if err != nil {
+		t.Fatalf("ImportSession() error = %v", err)
+	}
+	if data["_auth_user_id"] != "42" || data["theme"] != "dark" {
+		t.Errorf("ImportSession() = %v", data)
+	}
+
+	t.Run("expired token", func(t *testing.T) {
+		expired, _ := client.EncodeWithSalt(map[string]interface{}{
+			"data":    map[string]interface{}{"_auth_user_id": "42"},
+			"expires": time.Now().Add(-time.Second).UnixMilli(),
+		}, HandoffSalt, true)
+		if _, err := receiver.ImportSession(expired); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("ImportSession() error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("not interchangeable with session data", func(t *testing.T) {
+		if _, err := receiver.ImportSession(sessionData); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ImportSession(session data) error = %v, want ErrInvalidSignature", err)
+		}
+		if _, err := receiver.DecodeSessionUserID(token); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("DecodeSessionUserID(handoff token) error = %v, want ErrInvalidSignature", err)
+		}
+	})
+
+	t.Run("other secret key", func(t *testing.T) {
+		other, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "other-secret"})
+		if _, err := other.ImportSession(token); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ImportSession() error = %v, want ErrInvalidSignature", err)
+		}
+	})
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		malformed, _ := client.EncodeWithSalt(map[string]interface{}{"data": "42"}, HandoffSalt, false)
+		if _, err := receiver.ImportSession(malformed); err == nil {
+			t.Error("ImportSession() expected error")
+		}
+	})
+
+	t.Run("json.Number codec", func(t *testing.T) {
+		numbers, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, JSONCodec: StdJSONCodec{UseNumber: true}})
+		if data, err := numbers.ImportSession(token); err != nil || data["_auth_user_id"] != "42" {
+			t.Errorf("ImportSession() = %v, %v", data, err)
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		if _, err := client.ExportSession(sessionData, 0); err == nil {
+			t.Error("ExportSession(ttl 0) expected error")
+		}
+		forged, _ := EncodeSessionData("42", "other-secret", nil)
+		if _, err := client.ExportSession(forged, time.Minute); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ExportSession(forged) error = %v, want ErrInvalidSignature", err)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package django_session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPSessionStoreConfig configures an HTTPSessionStore
+type HTTPSessionStoreConfig struct {
+	BaseURL    string        // Endpoint prefix; the session key is appended as a path segment (required)
+	AuthHeader string        // Optional: header carrying AuthToken (default: "Authorization")
+	AuthToken  string        // Optional: value sent in AuthHeader on every request
+	Timeout    time.Duration // Optional: per-request timeout (default: 5s)
+	HTTPClient *http.Client  // Optional: client used for requests (default: a new http.Client)
+}
+
+// HTTPSessionStore reads raw sessions from an internal Django endpoint such as
+// GET /_session/<key>, for services without direct database access. The endpoint responds
+// 404 for unknown keys and 200 with {"session_key", "session_data", "expire_date"} otherwise,
+// where expire_date is RFC 3339.
+type HTTPSessionStore struct {
+	baseURL    string
+	authHeader string
+	authToken  string
+	timeout    time.Duration
+	client     *http.Client
+}
+
+// httpSessionResponse is the JSON body returned by the session endpoint
+type httpSessionResponse struct {
+	SessionKey  string    `json:"session_key"`
+	SessionData string    `json:"session_data"`
+	ExpireDate  time.Time `json:"expire_date"`
+}
+
+// maxHTTPSessionResponse caps the response body read from the session endpoint
+const maxHTTPSessionResponse = 2 << 20
+
+// NewHTTPSessionStore creates an HTTPSessionStore
+func NewHTTPSessionStore(config HTTPSessionStoreConfig) (*HTTPSessionStore, error) {
+	if config.BaseURL == "" {
+		return nil, errors.New("base URL is required")
+	}
+	if _, err := url.Parse(config.BaseURL); err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if config.AuthHeader == "" {
+		config.AuthHeader = "Authorization"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+
+	return &HTTPSessionStore{
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		authHeader: config.AuthHeader,
+		authToken:  config.AuthToken,
+		timeout:    config.Timeout,
+		client:     config.HTTPClient,
+	}, nil
+}
+
+// GetRawSession fetches the session from the endpoint and rejects expired sessions,
+// returning ErrSessionNotFound or ErrSessionExpired like Client.GetRawSession
+func (s *HTTPSessionStore) GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error) {
+	if sessionKey == "" || len(sessionKey) > 255 {
+		return nil, ErrSessionNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/"+url.PathEscape(sessionKey), nil)
+	if err != nil {
+		return nil, fmt.Errorf("session request failed: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.authToken != "" {
+		req.Header.Set(s.authHeader, s.authToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("session request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrSessionNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("session request failed: unexpected status %d", resp.StatusCode)
+	}
+
+	var body httpSessionResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHTTPSessionResponse)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid session response: %w", err)
+	}
+	if body.SessionKey != sessionKey {
+		return nil, fmt.Errorf("invalid session response: key mismatch")
+	}
+
+	session := &RawSession{
+		SessionKey:  body.SessionKey,
+		SessionData: body.SessionData,
+		ExpireDate:  body.ExpireDate.UTC(),
+		EncodedSize: len(body.SessionData),
+	}
+	if session.IsExpired() {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
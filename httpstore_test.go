@@ -0,0 +1,102 @@
+package django_session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPSessionStore(t *testing.T) {
+	ctx := context.Background()
+	expireDate := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Internal-Token") != "s3cret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/_session/")
+		switch key {
+		case "valid":
+			json.NewEncoder(w).Encode(map[string]string{
+				"session_key":  key,
+				"session_data": "payload",
+				"expire_date":  expireDate.Format(time.RFC3339),
+			})
+		case "expired":
+			json.NewEncoder(w).Encode(map[string]string{
+				"session_key":  key,
+				"session_data": "payload",
+				"expire_date":  time.Now().Add(-time.Hour).Format(time.RFC3339),
+			})
+		case "garbage":
+			w.Write([]byte("not json"))
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewHTTPSessionStore(HTTPSessionStoreConfig{
+		BaseURL:    server.URL + "/_session/",
+		AuthHeader: "X-Internal-Token",
+		AuthToken:  "s3cret",
+		Timeout:    50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSessionStore() error = %v", err)
+	}
+
+	t.Run("found", func(t *testing.T) {
+		session, err := store.GetRawSession(ctx, "valid")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionKey != "valid" || session.SessionData != "payload" || !session.ExpireDate.Equal(expireDate) {
+			t.Errorf("GetRawSession() = %+v", session)
+		}
+	})
+
+	tests := []struct {
+		key     string
+		wantErr error
+	}{
+		{"missing", ErrSessionNotFound},
+		{"expired", ErrSessionExpired},
+		{"", ErrSessionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if _, err := store.GetRawSession(ctx, tt.key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetRawSession() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	for _, key := range []string{"garbage", "slow"} {
+		t.Run(key, func(t *testing.T) {
+			_, err := store.GetRawSession(ctx, key)
+			if err == nil || errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("GetRawSession() error = %v, want upstream failure", err)
+			}
+		})
+	}
+
+	t.Run("missing auth token", func(t *testing.T) {
+		unauthenticated, _ := NewHTTPSessionStore(HTTPSessionStoreConfig{BaseURL: server.URL + "/_session"})
+		if _, err := unauthenticated.GetRawSession(ctx, "valid"); err == nil || errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GetRawSession() error = %v, want status error", err)
+		}
+	})
+
+	if _, err := NewHTTPSessionStore(HTTPSessionStoreConfig{}); err == nil {
+		t.Error("NewHTTPSessionStore() expected error without BaseURL")
+	}
+}
@@ -0,0 +1,29 @@
+package django_session
+
+import (
+	"context"
+	"time"
+)
+
+// Identity is the authenticated user of a request, stored in the request's context.Context
+// by the middleware when MiddlewareConfig.IdentityInContext is set
+type Identity struct {
+	UserID     string
+	SessionKey string
+	ExpireDate time.Time
+}
+
+// identityKey is the context.Context key for Identity
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx carrying identity
+func ContextWithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the Identity stored in ctx, such as c.Request.Context() downstream
+// of the middleware. Works with any code holding the context, not only gin handlers.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,18 @@
+package django_session
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Expected no identity in empty context")
+	}
+
+	ctx := ContextWithIdentity(context.Background(), Identity{UserID: "42", SessionKey: "abc"})
+	identity, ok := FromContext(ctx)
+	if !ok || identity.UserID != "42" || identity.SessionKey != "abc" {
+		t.Errorf("FromContext() = %+v, %v", identity, ok)
+	}
+}
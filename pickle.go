@@ -0,0 +1,545 @@
+package django_session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pickle opcodes used by the session dicts Django's cache backends store. Only the subset
+// needed for JSON-like values (dicts, lists, tuples, str, int, float, bool, None) is handled.
+const (
+	pickleMark            = '('
+	pickleStop            = '.'
+	pickleNone            = 'N'
+	pickleBinInt          = 'J'
+	pickleBinInt1         = 'K'
+	pickleBinInt2         = 'M'
+	pickleBinFloat        = 'G'
+	pickleBinUnicode      = 'X'
+	pickleEmptyDict       = '}'
+	pickleEmptyList       = ']'
+	pickleEmptyTuple      = ')'
+	pickleAppend          = 'a'
+	pickleAppends         = 'e'
+	pickleSetItem         = 's'
+	pickleSetItems        = 'u'
+	pickleTuple           = 't'
+	pickleBinGet          = 'h'
+	pickleLongBinGet      = 'j'
+	pickleBinPut          = 'q'
+	pickleLongBinPut      = 'r'
+	pickleProto           = 0x80
+	pickleTuple1          = 0x85
+	pickleTuple2          = 0x86
+	pickleTuple3          = 0x87
+	pickleNewTrue         = 0x88
+	pickleNewFalse        = 0x89
+	pickleLong1           = 0x8a
+	pickleShortBinUnicode = 0x8c
+	pickleBinUnicode8     = 0x8d
+	pickleMemoize         = 0x94
+	pickleFrame           = 0x95
+)
+
+// maxPickleDepth bounds container nesting in both directions; memo references could
+// otherwise make a decoded pickle cyclic
+const maxPickleDepth = 64
+
+// maxPickleValues bounds the values a decoded pickle may expand to, since memo references
+// let a small pickle share one list many times over
+const maxPickleValues = 1 << 20
+
+// pickleSession encodes a session dict with pickle protocol 2, which every Python 3
+// pickle.loads (and so Django's cache backends) reads. json.Number values become ints
+// when they have no fraction or exponent, as Python's json module would decode them.
+func pickleSession(data map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write([]byte{pickleProto, 2})
+	if err := writePickleValue(&buf, "", data, 0); err != nil {
+		return nil, err
+	}
+	buf.WriteByte(pickleStop)
+	return buf.Bytes(), nil
+}
+
+// writePickleValue appends the opcodes for v; path names the value in errors
+func writePickleValue(buf *bytes.Buffer, path string, v interface{}, depth int) error {
+	if depth > maxPickleDepth {
+		return fmt.Errorf("session value %q: nested too deeply", path)
+	}
+
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(pickleNone)
+	case bool:
+		if v {
+			buf.WriteByte(pickleNewTrue)
+		} else {
+			buf.WriteByte(pickleNewFalse)
+		}
+	case string:
+		buf.WriteByte(pickleBinUnicode)
+		binary.Write(buf, binary.LittleEndian, uint32(len(v)))
+		buf.WriteString(v)
+	case json.Number:
+		if !strings.ContainsAny(string(v), ".eE") {
+			n, ok := new(big.Int).SetString(string(v), 10)
+			if !ok {
+				return fmt.Errorf("session value %q: invalid number %q", path, v)
+			}
+			writePickleInt(buf, n)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("session value %q: invalid number %q", path, v)
+		}
+		writePickleFloat(buf, f)
+	case int64:
+		writePickleInt(buf, big.NewInt(v))
+	case *big.Int:
+		writePickleInt(buf, v)
+	case float64:
+		writePickleFloat(buf, v)
+	case []interface{}:
+		buf.WriteByte(pickleEmptyList)
+		if len(v) == 0 {
+			return nil
+		}
+		buf.WriteByte(pickleMark)
+		for i, item := range v {
+			if err := writePickleValue(buf, fmt.Sprintf("%s[%d]", path, i), item, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(pickleAppends)
+	case map[string]interface{}:
+		buf.WriteByte(pickleEmptyDict)
+		if len(v) == 0 {
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteByte(pickleMark)
+		for _, key := range keys {
+			if err := writePickleValue(buf, path, key, depth+1); err != nil {
+				return err
+			}
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := writePickleValue(buf, child, v[key], depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(pickleSetItems)
+	default:
+		return fmt.Errorf("session value %q: unsupported type %T", path, v)
+	}
+	return nil
+}
+
+// writePickleInt appends n as BININT when it fits in 32 bits and as LONG1 otherwise
+func writePickleInt(buf *bytes.Buffer, n *big.Int) {
+	if n.IsInt64() && n.Int64() >= math.MinInt32 && n.Int64() <= math.MaxInt32 {
+		buf.WriteByte(pickleBinInt)
+		binary.Write(buf, binary.LittleEndian, int32(n.Int64()))
+		return
+	}
+
+	// LONG1: little-endian two's complement, as short as possible
+	size := n.BitLen()/8 + 1
+	encoded := make([]byte, size)
+	value := new(big.Int).Set(n)
+	if n.Sign() < 0 {
+		value.Add(value, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	value.FillBytes(encoded)
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+	buf.WriteByte(pickleLong1)
+	buf.WriteByte(byte(size))
+	buf.Write(encoded)
+}
+
+// writePickleFloat appends f as a big-endian BINFLOAT
+func writePickleFloat(buf *bytes.Buffer, f float64) {
+	buf.WriteByte(pickleBinFloat)
+	binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+}
+
+// pickleList is a list under construction; lists are memoized before their items are
+// appended, so they are shared by pointer until decoding finishes
+type pickleList struct {
+	items []interface{}
+}
+
+// errPickleTruncated is returned for pickles that end before STOP
+var errPickleTruncated = errors.New("pickle: unexpected end of data")
+
+// unpickleSession decodes a pickled session dict as written by Django's cache backends
+// (any protocol). Ints become int64, or *big.Int when they do not fit; tuples become
+// slices like lists.
+func unpickleSession(data []byte) (map[string]interface{}, error) {
+	var (
+		stack []interface{}
+		marks []int
+		memo  = make(map[uint32]interface{})
+		pos   int
+	)
+
+	read := func(n int) ([]byte, error) {
+		if n < 0 || pos+n > len(data) {
+			return nil, errPickleTruncated
+		}
+		b := data[pos : pos+n]
+		pos += n
+		return b, nil
+	}
+	pop := func() (interface{}, error) {
+		if len(stack) == 0 || (len(marks) > 0 && len(stack) == marks[len(marks)-1]) {
+			return nil, errors.New("pickle: stack underflow")
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+	popMark := func() ([]interface{}, error) {
+		if len(marks) == 0 {
+			return nil, errors.New("pickle: missing mark")
+		}
+		mark := marks[len(marks)-1]
+		marks = marks[:len(marks)-1]
+		items := append([]interface{}(nil), stack[mark:]...)
+		stack = stack[:mark]
+		return items, nil
+	}
+	top := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errors.New("pickle: stack underflow")
+		}
+		return stack[len(stack)-1], nil
+	}
+	readString := func(n int) (string, error) {
+		b, err := read(n)
+		if err != nil {
+			return "", err
+		}
+		if !utf8.Valid(b) {
+			return "", errors.New("pickle: string is not valid UTF-8")
+		}
+		return string(b), nil
+	}
+	setItems := func(items []interface{}) error {
+		target, err := top()
+		if err != nil {
+			return err
+		}
+		dict, ok := target.(map[string]interface{})
+		if !ok || len(items)%2 != 0 {
+			return errors.New("pickle: SETITEMS outside a dict")
+		}
+		for i := 0; i < len(items); i += 2 {
+			key, ok := items[i].(string)
+			if !ok {
+				return fmt.Errorf("pickle: unsupported dict key type %T", items[i])
+			}
+			dict[key] = items[i+1]
+		}
+		return nil
+	}
+	appendItems := func(items []interface{}) error {
+		target, err := top()
+		if err != nil {
+			return err
+		}
+		list, ok := target.(*pickleList)
+		if !ok {
+			return errors.New("pickle: APPENDS outside a list")
+		}
+		list.items = append(list.items, items...)
+		return nil
+	}
+
+	for {
+		op, err := read(1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op[0] {
+		case pickleProto:
+			if _, err := read(1); err != nil {
+				return nil, err
+			}
+		case pickleFrame:
+			if _, err := read(8); err != nil {
+				return nil, err
+			}
+		case pickleMark:
+			marks = append(marks, len(stack))
+		case pickleStop:
+			if len(stack) != 1 || len(marks) != 0 {
+				return nil, errors.New("pickle: malformed stack at STOP")
+			}
+			budget := maxPickleValues
+			resolved, err := resolvePickleValue(stack[0], 0, &budget)
+			if err != nil {
+				return nil, err
+			}
+			dict, ok := resolved.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("pickle: payload is %T, not a dict", resolved)
+			}
+			return dict, nil
+		case pickleNone:
+			stack = append(stack, nil)
+		case pickleNewTrue:
+			stack = append(stack, true)
+		case pickleNewFalse:
+			stack = append(stack, false)
+		case pickleBinInt1:
+			b, err := read(1)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(b[0]))
+		case pickleBinInt2:
+			b, err := read(2)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(binary.LittleEndian.Uint16(b)))
+		case pickleBinInt:
+			b, err := read(4)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, int64(int32(binary.LittleEndian.Uint32(b))))
+		case pickleLong1:
+			size, err := read(1)
+			if err != nil {
+				return nil, err
+			}
+			b, err := read(int(size[0]))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, decodePickleLong(b))
+		case pickleBinFloat:
+			b, err := read(8)
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, math.Float64frombits(binary.BigEndian.Uint64(b)))
+		case pickleShortBinUnicode:
+			size, err := read(1)
+			if err != nil {
+				return nil, err
+			}
+			s, err := readString(int(size[0]))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, s)
+		case pickleBinUnicode:
+			b, err := read(4)
+			if err != nil {
+				return nil, err
+			}
+			s, err := readString(int(binary.LittleEndian.Uint32(b)))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, s)
+		case pickleBinUnicode8:
+			b, err := read(8)
+			if err != nil {
+				return nil, err
+			}
+			size := binary.LittleEndian.Uint64(b)
+			if size > uint64(len(data)) {
+				return nil, errPickleTruncated
+			}
+			s, err := readString(int(size))
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, s)
+		case pickleEmptyDict:
+			stack = append(stack, map[string]interface{}{})
+		case pickleEmptyList:
+			stack = append(stack, &pickleList{})
+		case pickleEmptyTuple:
+			stack = append(stack, []interface{}{})
+		case pickleTuple:
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			stack = append(stack, items)
+		case pickleTuple1, pickleTuple2, pickleTuple3:
+			n := int(op[0]-pickleTuple1) + 1
+			items := make([]interface{}, n)
+			for i := n - 1; i >= 0; i-- {
+				if items[i], err = pop(); err != nil {
+					return nil, err
+				}
+			}
+			stack = append(stack, items)
+		case pickleSetItem:
+			value, err := pop()
+			if err != nil {
+				return nil, err
+			}
+			key, err := pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := setItems([]interface{}{key, value}); err != nil {
+				return nil, err
+			}
+		case pickleSetItems:
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			if err := setItems(items); err != nil {
+				return nil, err
+			}
+		case pickleAppend:
+			value, err := pop()
+			if err != nil {
+				return nil, err
+			}
+			if err := appendItems([]interface{}{value}); err != nil {
+				return nil, err
+			}
+		case pickleAppends:
+			items, err := popMark()
+			if err != nil {
+				return nil, err
+			}
+			if err := appendItems(items); err != nil {
+				return nil, err
+			}
+		case pickleMemoize:
+			value, err := top()
+			if err != nil {
+				return nil, err
+			}
+			memo[uint32(len(memo))] = value
+		case pickleBinPut, pickleLongBinPut:
+			size := 1
+			if op[0] == pickleLongBinPut {
+				size = 4
+			}
+			b, err := read(size)
+			if err != nil {
+				return nil, err
+			}
+			value, err := top()
+			if err != nil {
+				return nil, err
+			}
+			memo[pickleIndex(b)] = value
+		case pickleBinGet, pickleLongBinGet:
+			size := 1
+			if op[0] == pickleLongBinGet {
+				size = 4
+			}
+			b, err := read(size)
+			if err != nil {
+				return nil, err
+			}
+			value, ok := memo[pickleIndex(b)]
+			if !ok {
+				return nil, errors.New("pickle: unknown memo reference")
+			}
+			stack = append(stack, value)
+		default:
+			return nil, fmt.Errorf("pickle: unsupported opcode 0x%02x", op[0])
+		}
+	}
+}
+
+// pickleIndex reads a 1- or 4-byte little-endian memo index
+func pickleIndex(b []byte) uint32 {
+	if len(b) == 1 {
+		return uint32(b[0])
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// decodePickleLong decodes LONG1's little-endian two's complement bytes
+func decodePickleLong(b []byte) interface{} {
+	if len(b) == 0 {
+		return int64(0)
+	}
+	bigEndian := make([]byte, len(b))
+	for i := range b {
+		bigEndian[len(b)-1-i] = b[i]
+	}
+	n := new(big.Int).SetBytes(bigEndian)
+	if b[len(b)-1]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	return n
+}
+
+// resolvePickleValue copies v, replacing the *pickleList placeholders left by decoding
+// with slices. budget counts down the values produced.
+func resolvePickleValue(v interface{}, depth int, budget *int) (interface{}, error) {
+	if depth > maxPickleDepth {
+		return nil, errors.New("pickle: nested too deeply")
+	}
+	if *budget--; *budget < 0 {
+		return nil, errors.New("pickle: too many values")
+	}
+
+	var items []interface{}
+	switch v := v.(type) {
+	case *pickleList:
+		items = v.items
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		dict := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := resolvePickleValue(item, depth+1, budget)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = resolved
+		}
+		return dict, nil
+	default:
+		return v, nil
+	}
+
+	resolved := make([]interface{}, len(items))
+	for i, item := range items {
+		var err error
+		if resolved[i], err = resolvePickleValue(item, depth+1, budget); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
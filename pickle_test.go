@@ -0,0 +1,102 @@
+package django_session
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// djangoPickledSession is pickle.dumps(session, pickle.HIGHEST_PROTOCOL) of the dict below,
+// as Django's Redis cache stores it. It uses framing, memoization (the repeated "a" is a
+// BINGET) and every int opcode.
+//
+//	{"_auth_user_id": "42", "_auth_user_backend": "django.contrib.auth.backends.ModelBackend",
+//	 "cart": [1, 2.5, None, True, False, "é"], "pair": ("a", "a"), "big": 2**70,
+//	 "neg": -300000, "small": 200, "mid": 60000, "nested": {"x": []}, "empty": {}}
+const djangoPickledSession = "800595d3000000000000007d94288c0d5f617574685f757365725f6964948c023432948c125f617574685f757365725f6261636b656e64948c29646a616e676f2e636f6e747269622e617574682e6261636b656e64732e4d6f64656c4261636b656e64948c0463617274945d94284b014740040000000000004e88898c02c3a994658c0470616972948c016194680986948c03626967948a090000000000000000408c036e6567944a206cfbff8c05736d616c6c944bc88c036d6964944d60ea8c066e6573746564947d948c0178945d94738c05656d707479947d94752e"
+
+func TestUnpickleSession(t *testing.T) {
+	payload, _ := hex.DecodeString(djangoPickledSession)
+	big70 := new(big.Int).Lsh(big.NewInt(1), 70)
+
+	got, err := unpickleSession(payload)
+	if err != nil {
+		t.Fatalf("unpickleSession() error = %v", err)
+	}
+	want := map[string]interface{}{
+		"_auth_user_id":      "42",
+		"_auth_user_backend": "django.contrib.auth.backends.ModelBackend",
+		"cart":               []interface{}{int64(1), 2.5, nil, true, false, "é"},
+		"pair":               []interface{}{"a", "a"},
+		"big":                big70,
+		"neg":                int64(-300000),
+		"small":              int64(200),
+		"mid":                int64(60000),
+		"nested":             map[string]interface{}{"x": []interface{}{}},
+		"empty":              map[string]interface{}{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unpickleSession() = %#v, want %#v", got, want)
+	}
+}
+
+func TestUnpickleSessionRejectsMalformed(t *testing.T) {
+	tests := map[string]string{
+		"empty":              "",
+		"truncated":          djangoPickledSession[:100],
+		"not a dict":         "80024b012e",                                 // 1
+		"global":             "8002635f5f6275696c74696e5f5f0a6576616c0a2e", // c__builtin__\neval\n
+		"int key":            "80027d284b014b0175" + "2e",                  // {1: 1}
+		"unknown memo":       "80027d28580100000061680575" + "2e",
+		"self-referencing":   "80027d942858010000006168" + "0075" + "2e", // d = {}; d["a"] = d
+		"invalid UTF-8":      "80027d28580100000061580100000080752e",
+		"missing STOP":       "80027d",
+		"setitems on a list": "80025d28580100000061580100000062752e",
+	}
+	for name, payload := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, _ := hex.DecodeString(payload)
+			if got, err := unpickleSession(data); err == nil {
+				t.Errorf("unpickleSession() = %v, want error", got)
+			}
+		})
+	}
+}
+
+func TestPickleSession(t *testing.T) {
+	var data map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{"_auth_user_id": "42", "cart": [1, 2.5, null, true, false, "é"],
+		"big": 1180591620717411303424, "neg": -300000, "negbig": -2147483649, "nested": {"x": []}, "f": 1.0}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	// Verified with Python: pickle.loads gives {'_auth_user_id': '42', 'big': 1180591620717411303424,
+	// 'cart': [1, 2.5, None, True, False, 'é'], 'f': 1.0, 'neg': -300000,
+	// 'negbig': -2147483649, 'nested': {'x': []}}
+	const want = "80027d28580d0000005f617574685f757365725f69645802000000343258030000006269678a090000000000000000405804000000636172745d284a010000004740040000000000004e88895802000000c3a965580100000066473ff000000000000058030000006e65674a206cfbff58060000006e65676269678a05ffffff7fff58060000006e65737465647d285801000000785d75752e"
+
+	encoded, err := pickleSession(data)
+	if err != nil {
+		t.Fatalf("pickleSession() error = %v", err)
+	}
+	if got := hex.EncodeToString(encoded); got != want {
+		t.Errorf("pickleSession() = %s, want %s", got, want)
+	}
+
+	decoded, err := unpickleSession(encoded)
+	if err != nil {
+		t.Fatalf("unpickleSession(pickleSession()) error = %v", err)
+	}
+	if decoded["negbig"] != int64(-2147483649) || decoded["f"] != 1.0 || decoded["_auth_user_id"] != "42" {
+		t.Errorf("unpickleSession(pickleSession()) = %#v", decoded)
+	}
+
+	if _, err := pickleSession(map[string]interface{}{"ch": make(chan int)}); err == nil {
+		t.Error("pickleSession() error = nil, want error for a channel")
+	}
+}
@@ -0,0 +1,183 @@
+package django_session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key prefixes Django's cache-based session backends put in front of the session key
+const (
+	CachedDBCachePrefix = "django.contrib.sessions.cached_db" // SESSION_ENGINE "django.contrib.sessions.backends.cached_db"
+	CacheCachePrefix    = "django.contrib.sessions.cache"     // SESSION_ENGINE "django.contrib.sessions.backends.cache"
+)
+
+// RedisSessionStoreConfig configures a RedisSessionStore
+type RedisSessionStoreConfig struct {
+	Redis       redis.UniversalClient // Redis server behind Django's session cache (required)
+	Client      *Client               // Signs cached payloads into session_data and verifies stored ones (required)
+	KeyPrefix   string                // Optional: the cache's KEY_PREFIX setting (default: "")
+	Version     int                   // Optional: the cache's VERSION setting (default: 1)
+	CachePrefix string                // Optional: session backend prefix (default: CachedDBCachePrefix)
+}
+
+// RedisSessionStore reads and writes sessions in the Redis cache used by Django's
+// cached_db (or cache) session backend, so Go services and Django share entries. Keys
+// follow Django's default KEY_FUNCTION, e.g. ":1:django.contrib.sessions.cached_db<key>".
+// Values are the pickled session dicts Django stores, not session_data: on reads the dict
+// is signed into fresh session_data with Client, and on writes session_data is verified
+// and unpickled. It implements both SessionCache and SessionStore.
+type RedisSessionStore struct {
+	redis     redis.UniversalClient
+	client    *Client
+	keyPrefix string
+}
+
+// NewRedisSessionStore creates a RedisSessionStore
+func NewRedisSessionStore(config RedisSessionStoreConfig) (*RedisSessionStore, error) {
+	if config.Redis == nil {
+		return nil, errors.New("redis client is required")
+	}
+	if config.Client == nil {
+		return nil, errors.New("session client is required")
+	}
+	if config.Version == 0 {
+		config.Version = 1
+	}
+	if config.CachePrefix == "" {
+		config.CachePrefix = CachedDBCachePrefix
+	}
+
+	return &RedisSessionStore{
+		redis:     config.Redis,
+		client:    config.Client,
+		keyPrefix: config.KeyPrefix + ":" + strconv.Itoa(config.Version) + ":" + config.CachePrefix,
+	}, nil
+}
+
+// cacheKey returns the Redis key Django's cache uses for sessionKey
+func (s *RedisSessionStore) cacheKey(sessionKey string) string {
+	return s.keyPrefix + sessionKey
+}
+
+// Get returns the cached session, or ErrSessionNotFound when Redis has no entry.
+// ExpireDate is derived from the key's TTL, which Django sets to the session's expiry age;
+// a key without TTL expires after the client's MaxAge. Since session_data is signed at
+// read time, its signature age does not reflect the original login.
+func (s *RedisSessionStore) Get(ctx context.Context, sessionKey string) (*RawSession, error) {
+	key := s.cacheKey(sessionKey)
+
+	var value *redis.StringCmd
+	var ttl *redis.DurationCmd
+	_, err := s.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		value = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+
+	payload, err := value.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("redis get failed: %w", err)
+	}
+	data, err := unpickleSession(payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cached session: %w", err)
+	}
+
+	now := s.client.nowFunc()
+	expireDate := now.Add(s.client.sessionAge())
+	switch remaining := ttl.Val(); {
+	case remaining > 0:
+		expireDate = now.Add(remaining)
+	case remaining == -2*time.Nanosecond: // PTTL's "no such key": deleted after GET
+		return nil, ErrSessionNotFound
+	}
+
+	signer, err := s.client.currentSigner()
+	if err != nil {
+		return nil, err
+	}
+	sessionData, err := signer.SignObject(data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RawSession{
+		SessionKey:  sessionKey,
+		SessionData: sessionData,
+		ExpireDate:  expireDate.UTC(),
+		EncodedSize: len(sessionData),
+	}, nil
+}
+
+// Set stores session's payload as Django would, expiring after ttl. The session_data must
+// verify with the client's keys; signing age is not checked. A non-positive ttl stores nothing.
+func (s *RedisSessionStore) Set(ctx context.Context, session *RawSession, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	signer, err := s.client.currentSigner()
+	if err != nil {
+		return err
+	}
+	payload, err := signer.unsignJSON(session.SessionData, nil)
+	if err != nil {
+		return err
+	}
+	// Decode numbers as json.Number so ints stay ints in the pickle, as in Django
+	var data map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return fmt.Errorf("json decode error: %w", err)
+	}
+	if data == nil {
+		return errors.New("json decode error: payload is not an object")
+	}
+
+	value, err := pickleSession(data)
+	if err != nil {
+		return err
+	}
+	if err := s.redis.Set(ctx, s.cacheKey(session.SessionKey), value, ttl).Err(); err != nil {
+		return fmt.Errorf("redis set failed: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the cached session, implementing SessionCacheDeleter
+func (s *RedisSessionStore) Delete(ctx context.Context, sessionKey string) error {
+	if err := s.redis.Del(ctx, s.cacheKey(sessionKey)).Err(); err != nil {
+		return fmt.Errorf("redis delete failed: %w", err)
+	}
+	return nil
+}
+
+// GetRawSession returns the cached session like Get and rejects expired ones, so the store
+// can serve sessions on its own, e.g. for Django's cache-only session backend
+func (s *RedisSessionStore) GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error) {
+	if sessionKey == "" || len(sessionKey) > 255 {
+		return nil, ErrSessionNotFound
+	}
+	session, err := s.Get(ctx, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	if s.client.isExpired(session.ExpireDate) {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
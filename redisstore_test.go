@@ -0,0 +1,215 @@
+package django_session
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/mock"
+)
+
+// newRedisSessionStore starts a miniredis server and a store over it for client
+func newRedisSessionStore(t *testing.T, client *Client) (*miniredis.Miniredis, *RedisSessionStore) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	store, err := NewRedisSessionStore(RedisSessionStoreConfig{Redis: rdb, Client: client})
+	if err != nil {
+		t.Fatalf("NewRedisSessionStore() error = %v", err)
+	}
+	return mr, store
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	ctx := context.Background()
+	const djangoKey = ":1:django.contrib.sessions.cached_db" + "abc"
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", Now: clock.Now})
+
+	t.Run("reads entries written by Django", func(t *testing.T) {
+		mr, store := newRedisSessionStore(t, client)
+		payload, _ := hex.DecodeString(djangoPickledSession)
+		mr.Set(djangoKey, string(payload))
+		mr.SetTTL(djangoKey, time.Hour)
+
+		session, err := store.Get(ctx, "abc")
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if want := clock.now.Add(time.Hour); !session.ExpireDate.Equal(want) {
+			t.Errorf("ExpireDate = %v, want %v", session.ExpireDate, want)
+		}
+		decoded, err := client.DecodeSession(session)
+		if err != nil {
+			t.Fatalf("DecodeSession() error = %v", err)
+		}
+		if backend, _ := decoded.GetString("_auth_user_backend"); decoded.UserID != "42" || backend != "django.contrib.auth.backends.ModelBackend" {
+			t.Errorf("DecodeSession() = %+v", decoded)
+		}
+	})
+
+	t.Run("writes entries Django can read", func(t *testing.T) {
+		mr, store := newRedisSessionStore(t, client)
+		sessionData, _ := client.signer.SignObject(map[string]interface{}{"_auth_user_id": "42", "visits": 3}, true)
+
+		if err := store.Set(ctx, &RawSession{SessionKey: "abc", SessionData: sessionData}, time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		raw, err := mr.Get(djangoKey)
+		if err != nil {
+			t.Fatalf("miniredis Get(%q) error = %v", djangoKey, err)
+		}
+		data, err := unpickleSession([]byte(raw))
+		if err != nil {
+			t.Fatalf("unpickleSession() error = %v", err)
+		}
+		if data["_auth_user_id"] != "42" || data["visits"] != int64(3) {
+			t.Errorf("stored payload = %#v, want user 42 with int visits", data)
+		}
+		if ttl := mr.TTL(djangoKey); ttl != time.Hour {
+			t.Errorf("TTL = %v, want 1h", ttl)
+		}
+	})
+
+	t.Run("rejects session data that does not verify", func(t *testing.T) {
+		mr, store := newRedisSessionStore(t, client)
+		forged, _ := EncodeSessionData("42", "other-secret", nil)
+
+		if err := store.Set(ctx, &RawSession{SessionKey: "abc", SessionData: forged}, time.Hour); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Set() error = %v, want ErrInvalidSignature", err)
+		}
+		if mr.Exists(djangoKey) {
+			t.Error("Expected nothing to be stored")
+		}
+	})
+
+	t.Run("missing and deleted keys", func(t *testing.T) {
+		mr, store := newRedisSessionStore(t, client)
+		if _, err := store.Get(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("Get() error = %v, want ErrSessionNotFound", err)
+		}
+
+		payload, _ := hex.DecodeString(djangoPickledSession)
+		mr.Set(djangoKey, string(payload))
+		if err := store.Delete(ctx, "abc"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GetRawSession() after Delete error = %v, want ErrSessionNotFound", err)
+		}
+	})
+
+	t.Run("key prefix and version", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		defer rdb.Close()
+		store, _ := NewRedisSessionStore(RedisSessionStoreConfig{
+			Redis:       rdb,
+			Client:      client,
+			KeyPrefix:   "shop",
+			Version:     2,
+			CachePrefix: CacheCachePrefix,
+		})
+		sessionData, _ := EncodeSessionData("42", "test-secret", nil)
+
+		if err := store.Set(ctx, &RawSession{SessionKey: "abc", SessionData: sessionData}, time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if !mr.Exists("shop:2:django.contrib.sessions.cacheabc") {
+			t.Errorf("Expected Django's key layout, got keys %v", mr.Keys())
+		}
+	})
+
+	t.Run("config validation", func(t *testing.T) {
+		if _, err := NewRedisSessionStore(RedisSessionStoreConfig{Client: client}); err == nil {
+			t.Error("NewRedisSessionStore() without Redis error = nil")
+		}
+		if _, err := NewRedisSessionStore(RedisSessionStoreConfig{Redis: redis.NewClient(&redis.Options{})}); err == nil {
+			t.Error("NewRedisSessionStore() without Client error = nil")
+		}
+	})
+}
+
+func TestCachedDBStoreWithRedis(t *testing.T) {
+	ctx := context.Background()
+	const djangoKey = ":1:django.contrib.sessions.cached_dbabc"
+	sessionData, _ := EncodeSessionData("42", "test-secret", nil)
+	expireDate := time.Now().Add(time.Hour)
+
+	t.Run("cache hit skips database", func(t *testing.T) {
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		mr, redisStore := newRedisSessionStore(t, client)
+		payload, _ := hex.DecodeString(djangoPickledSession)
+		mr.Set(djangoKey, string(payload))
+		mr.SetTTL(djangoKey, time.Hour)
+
+		session, err := NewCachedDBStore(redisStore, client).GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if userID, _ := client.DecodeSessionUserID(session.SessionData); userID != "42" {
+			t.Errorf("cached session user = %q, want 42", userID)
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cache miss then database hit populates Redis", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData, expireDate))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		mr, redisStore := newRedisSessionStore(t, client)
+		store := NewCachedDBStore(redisStore, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if !mr.Exists(djangoKey) {
+			t.Fatal("Expected the session to be cached in Redis")
+		}
+		if ttl := mr.TTL(djangoKey); ttl <= 0 || ttl > time.Hour {
+			t.Errorf("Unexpected TTL %v", ttl)
+		}
+
+		if _, err := store.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	t.Run("both miss", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 3))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		mr, redisStore := newRedisSessionStore(t, client)
+
+		if _, err := NewCachedDBStore(redisStore, client).GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GetRawSession() error = %v, want ErrSessionNotFound", err)
+		}
+		if keys := mr.Keys(); len(keys) != 0 {
+			t.Errorf("Expected nothing cached, got %v", keys)
+		}
+	})
+
+	t.Run("invalidate drops the Redis entry", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+		mr, redisStore := newRedisSessionStore(t, client)
+		payload, _ := hex.DecodeString(djangoPickledSession)
+		mr.Set(djangoKey, string(payload))
+
+		if err := NewCachedDBStore(redisStore, client).Invalidate(ctx, "abc"); err != nil {
+			t.Fatalf("Invalidate() error = %v", err)
+		}
+		if mr.Exists(djangoKey) {
+			t.Error("Expected the Redis entry to be deleted")
+		}
+	})
+}
@@ -0,0 +1,14 @@
+package django_session
+
+import "net/http"
+
+// SessionFromRequest reads the session cookie from r and returns the validated raw session,
+// for net/http services that do not use gin. Returns ErrNoSessionCookie when the request
+// has no session cookie; the payload is not decoded.
+func (c *Client) SessionFromRequest(r *http.Request) (*RawSession, error) {
+	cookie, err := r.Cookie(c.sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, ErrNoSessionCookie
+	}
+	return c.GetRawSession(r.Context(), cookie.Value)
+}
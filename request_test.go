@@ -0,0 +1,48 @@
+package django_session
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// TestSessionFromRequest exercises the net/http path without gin
+func TestSessionFromRequest(t *testing.T) {
+	sessionData, _ := EncodeSessionData("42", "test-secret", nil)
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+		Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+	t.Run("valid cookie", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(&http.Cookie{Name: "sessionid", Value: "abc"})
+
+		session, err := client.SessionFromRequest(r)
+		if err != nil {
+			t.Fatalf("SessionFromRequest() error = %v", err)
+		}
+		if userID, err := client.DecodeSessionUserID(session.SessionData); err != nil || userID != "42" {
+			t.Errorf("DecodeSessionUserID() = %v, %v, want 42", userID, err)
+		}
+	})
+
+	t.Run("no cookie", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		if _, err := client.SessionFromRequest(r); !errors.Is(err, ErrNoSessionCookie) {
+			t.Errorf("SessionFromRequest() error = %v, want ErrNoSessionCookie", err)
+		}
+	})
+
+	t.Run("empty cookie", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(&http.Cookie{Name: "sessionid", Value: ""})
+		if _, err := client.SessionFromRequest(r); !errors.Is(err, ErrNoSessionCookie) {
+			t.Errorf("SessionFromRequest() error = %v, want ErrNoSessionCookie", err)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsTransientDBError reports whether err is a database error worth retrying:
+// connection failures, server shutdown during failover (57P01-57P03) and errors
+// pgconn marks as safe to retry. pgx.ErrNoRows is never transient.
+func IsTransientDBError(err error) bool {
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", "57P02", "57P03": // admin_shutdown, crash_shutdown, cannot_connect_now
+			return true
+		}
+		// Class 08: connection exception
+		return len(pgErr.Code) == 5 && pgErr.Code[:2] == "08"
+	}
+
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetry runs fn, retrying transient failures up to maxRetries times with
+// exponential backoff starting at retryBackoff
+func (c *Client) withRetry(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 0; attempt < c.maxRetries && err != nil && c.isTransient(err); attempt++ {
+		if sleepErr := c.sleep(ctx, c.retryBackoff<<attempt); sleepErr != nil {
+			return err
+		}
+		err = fn()
+	}
+	return err
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
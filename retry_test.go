@@ -0,0 +1,137 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsTransientDBError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"wrapped no rows", fmt.Errorf("scan: %w", pgx.ErrNoRows), false},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"cannot connect now", &pgconn.PgError{Code: "57P03"}, true},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, false},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"context canceled", context.Canceled, false},
+		{"generic", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransientDBError(tt.err); got != tt.want {
+				t.Errorf("IsTransientDBError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// newRetryClient returns a client whose QueryRow fails `failures` times with err before succeeding
+func newRetryClient(t *testing.T, failures int, err error, config ClientConfig) (*Client, *MockDBTX, *[]time.Duration) {
+	t.Helper()
+	db := &MockDBTX{}
+	if failures > 0 {
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(err, 3)).Times(failures)
+	}
+	db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newMockRow("abc", "data", time.Now().Add(time.Hour)))
+
+	config.DB = db
+	config.SecretKey = "test-secret"
+	client, clientErr := NewClient(config)
+	if clientErr != nil {
+		t.Fatalf("Failed to create client: %v", clientErr)
+	}
+
+	var sleeps []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return client, db, &sleeps
+}
+
+func TestGetRawSessionRetry(t *testing.T) {
+	ctx := context.Background()
+	shutdown := &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}
+
+	t.Run("retry then succeed", func(t *testing.T) {
+		client, db, sleeps := newRetryClient(t, 2, shutdown, ClientConfig{MaxRetries: 3, RetryBackoff: 10 * time.Millisecond})
+
+		session, err := client.GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionKey != "abc" {
+			t.Errorf("SessionKey = %v, want abc", session.SessionKey)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 3)
+		want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}
+		if fmt.Sprint(*sleeps) != fmt.Sprint(want) {
+			t.Errorf("backoffs = %v, want %v", *sleeps, want)
+		}
+	})
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		client, db, _ := newRetryClient(t, 5, shutdown, ClientConfig{MaxRetries: 2, RetryBackoff: time.Millisecond})
+
+		_, err := client.GetRawSession(ctx, "abc")
+		var pgErr *pgconn.PgError
+		if !errors.As(err, &pgErr) || pgErr.Code != "57P01" {
+			t.Errorf("GetRawSession() error = %v, want 57P01", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 3)
+	})
+
+	t.Run("no rows is never retried", func(t *testing.T) {
+		client, db, _ := newRetryClient(t, 1, pgx.ErrNoRows, ClientConfig{MaxRetries: 3})
+
+		if _, err := client.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GetRawSession() error = %v, want ErrSessionNotFound", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	t.Run("retries disabled by default", func(t *testing.T) {
+		client, db, _ := newRetryClient(t, 1, shutdown, ClientConfig{})
+
+		if _, err := client.GetRawSession(ctx, "abc"); err == nil {
+			t.Error("GetRawSession() expected error but got none")
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	t.Run("custom classifier", func(t *testing.T) {
+		flaky := errors.New("flaky proxy")
+		client, db, _ := newRetryClient(t, 1, flaky, ClientConfig{
+			MaxRetries:  1,
+			IsTransient: func(err error) bool { return errors.Is(err, flaky) },
+		})
+
+		if _, err := client.GetRawSession(ctx, "abc"); err != nil {
+			t.Errorf("GetRawSession() error = %v", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 2)
+	})
+
+	t.Run("negative retries rejected", func(t *testing.T) {
+		if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "s", MaxRetries: -1}); err == nil {
+			t.Error("NewClient() expected error for negative MaxRetries")
+		}
+	})
+}
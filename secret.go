@@ -0,0 +1,65 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultSecretProviderTTL is how long a key returned by a SecretProvider is reused
+const DefaultSecretProviderTTL = time.Minute
+
+// secretCache resolves the signing key through a SecretProvider, caching it for ttl so
+// the key store is queried at most once per ttl however many sessions are verified
+type secretCache struct {
+	provider func() (string, error)
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	signer    *DjangoSigner // derived from the client's signer with the current key
+	fetchedAt time.Time
+}
+
+// signerFor returns base with the provider's current key, refreshing it once the TTL has
+// passed. If a refresh fails, the previous key keeps being used until the next refresh;
+// an error is only returned when no key has been fetched yet.
+func (s *secretCache) signerFor(base *DjangoSigner) (*DjangoSigner, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if s.signer != nil && now.Sub(s.fetchedAt) < s.ttl {
+		return s.signer, nil
+	}
+
+	key, err := s.provider()
+	if err == nil && key == "" {
+		err = errors.New("empty secret key")
+	}
+	if err != nil {
+		if s.signer != nil {
+			s.fetchedAt = now // retry after another TTL instead of on every request
+			return s.signer, nil
+		}
+		return nil, fmt.Errorf("secret provider failed: %w", err)
+	}
+
+	if s.signer == nil || s.signer.SecretKey != key {
+		signer := *base
+		signer.SecretKey = key
+		s.signer = &signer
+	}
+	s.fetchedAt = now
+	return s.signer, nil
+}
+
+// currentSigner returns the signer to use for the next operation: the static one, or
+// one carrying the SecretProvider's current key
+func (c *Client) currentSigner() (*DjangoSigner, error) {
+	if c.secrets == nil {
+		return c.signer, nil
+	}
+	return c.secrets.signerFor(c.signer)
+}
@@ -0,0 +1,90 @@
+package django_session
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSecretProvider(t *testing.T) {
+	keys := []string{"key-v1", "key-v2"}
+	var calls int
+	var providerErr error
+	current := 0
+
+	client, err := NewClient(ClientConfig{
+		DB: &MockDBTX{},
+		SecretProvider: func() (string, error) {
+			calls++
+			return keys[current], providerErr
+		},
+		SecretProviderTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	now := time.Now()
+	client.secrets.now = func() time.Time { return now }
+
+	v1Session, _ := EncodeSessionData("1", "key-v1", nil)
+	v2Session, _ := EncodeSessionData("2", "key-v2", nil)
+
+	if userID, err := client.DecodeSessionUserID(v1Session); err != nil || userID != "1" {
+		t.Fatalf("DecodeSessionUserID(v1) = %v, %v", userID, err)
+	}
+	if _, err := client.DecodeSessionUserID(v2Session); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeSessionUserID(v2) before rotation error = %v, want ErrInvalidSignature", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the key to be cached within the TTL, provider called %d times", calls)
+	}
+
+	// Vault rotates the key; it is picked up once the TTL has passed
+	current = 1
+	now = now.Add(30 * time.Second)
+	if _, err := client.DecodeSessionUserID(v2Session); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("DecodeSessionUserID(v2) within TTL error = %v, want ErrInvalidSignature", err)
+	}
+	now = now.Add(time.Minute)
+	if userID, err := client.DecodeSessionUserID(v2Session); err != nil || userID != "2" {
+		t.Errorf("DecodeSessionUserID(v2) after TTL = %v, %v", userID, err)
+	}
+
+	// New sessions are signed with the current key
+	_, sessionData, _, err := client.BuildSession("3", nil)
+	if err != nil {
+		t.Fatalf("BuildSession() error = %v", err)
+	}
+	if userID, err := DecodeSessionData(sessionData, "key-v2"); err != nil || userID != "3" {
+		t.Errorf("BuildSession() not signed with current key: %v, %v", userID, err)
+	}
+
+	// A failing provider keeps the last good key
+	providerErr = errors.New("vault unavailable")
+	now = now.Add(2 * time.Minute)
+	if _, err := client.DecodeSessionUserID(v2Session); err != nil {
+		t.Errorf("DecodeSessionUserID() with failing provider error = %v, want last key reused", err)
+	}
+}
+
+func TestSecretProviderErrors(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		DB:             &MockDBTX{},
+		SecretProvider: func() (string, error) { return "", errors.New("vault unavailable") },
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	sessionData, _ := EncodeSessionData("1", "key-v1", nil)
+	if _, err := client.DecodeSessionUserID(sessionData); err == nil {
+		t.Error("DecodeSessionUserID() expected error when no key was ever fetched")
+	}
+
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}}); err == nil {
+		t.Error("NewClient() expected error without SecretKey or SecretProvider")
+	}
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "k", SecretProviderTTL: -time.Second}); err == nil {
+		t.Error("NewClient() expected error for negative SecretProviderTTL")
+	}
+}
@@ -0,0 +1,30 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+)
+
+// sessionDataText scans session_data into a string from either a text or a bytea column.
+//
+// Django's own backend uses text, but some custom backends store the signed payload as
+// bytea, which pgx refuses to scan into a plain *string. The payload is ASCII either way.
+type sessionDataText struct {
+	dest *string
+}
+
+// Scan implements sql.Scanner. pgx decodes text columns to string and bytea columns
+// (hex-encoded in text format) to []byte before calling it.
+func (s sessionDataText) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		*s.dest = v
+	case []byte:
+		*s.dest = string(v)
+	case nil:
+		return errors.New("session_data is NULL")
+	default:
+		return fmt.Errorf("cannot scan %T into session_data", src)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package django_session
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestSessionDataText(t *testing.T) {
+	const data = "eyJfYXV0aF91c2VyX2lkIjoiNDIifQ:1rg2Ku:moXz3rsbJFgUAlMcIV7bqCcDyYqy8iqi8aUMb7sGve0"
+	typeMap := pgtype.NewMap()
+
+	tests := []struct {
+		name   string
+		oid    uint32
+		format int16
+		src    []byte
+	}{
+		{"text, text format", pgtype.TextOID, pgtype.TextFormatCode, []byte(data)},
+		{"text, binary format", pgtype.TextOID, pgtype.BinaryFormatCode, []byte(data)},
+		{"varchar, binary format", pgtype.VarcharOID, pgtype.BinaryFormatCode, []byte(data)},
+		{"bytea, binary format", pgtype.ByteaOID, pgtype.BinaryFormatCode, []byte(data)},
+		{"bytea, text format", pgtype.ByteaOID, pgtype.TextFormatCode, []byte(`\x` + hexString(data))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := typeMap.Scan(tt.oid, tt.format, tt.src, sessionDataText{&got}); err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if got != data {
+				t.Errorf("got %q, want %q", got, data)
+			}
+		})
+	}
+
+	t.Run("NULL", func(t *testing.T) {
+		var got string
+		for _, oid := range []uint32{pgtype.TextOID, pgtype.ByteaOID} {
+			if err := typeMap.Scan(oid, pgtype.BinaryFormatCode, nil, sessionDataText{&got}); err == nil {
+				t.Errorf("Scan(oid %d) expected error for NULL", oid)
+			}
+		}
+	})
+}
+
+// hexString returns the lowercase hex encoding of s, as in Postgres's bytea text output
+func hexString(s string) string {
+	const digits = "0123456789abcdef"
+	out := make([]byte, 0, len(s)*2)
+	for i := 0; i < len(s); i++ {
+		out = append(out, digits[s[i]>>4], digits[s[i]&0x0f])
+	}
+	return string(out)
+}
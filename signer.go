@@ -2,28 +2,159 @@ package django_session
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/zlib"
 	"crypto/hmac"
+	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"math"
+	"reflect"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
 )
 
 const (
 	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// defaultSignerSalt and defaultTimestampSignerSalt are the salts Django's Signer and
+	// TimestampSigner fall back to when constructed with an empty salt: "%s.%s" % (module,
+	// class name). The constructors apply them; a DjangoSigner literal with an empty Salt
+	// signs with the bare "signer" suffix.
+	defaultSignerSalt          = "django.core.signing.Signer"
+	defaultTimestampSignerSalt = "django.core.signing.TimestampSigner"
 )
 
+// DefaultMaxDecompressedSize is the payload size limit used when MaxDecompressedSize is unset
+const DefaultMaxDecompressedSize int64 = 1 << 20 // 1 MiB
+
 // DjangoSigner handles Django's cryptographic signing
 type DjangoSigner struct {
 	SecretKey string
 	Salt      string
 	Sep       string
 	Algorithm string
+
+	// FallbackKeys are previous secret keys still accepted by Unsign, like Django's
+	// SECRET_KEY_FALLBACKS. New signatures always use SecretKey.
+	FallbackKeys []string
+
+	// LegacyAlgorithm, when set, is also accepted by Unsign, like Django 3.x's
+	// Signer.legacy_algorithm ("sha1") used during the sha256 transition
+	LegacyAlgorithm string
+
+	// AllowStandardBase64 makes UnsignObject fall back to the standard base64 alphabet
+	// ('+' and '/') when the payload is not valid URL-safe base64, for non-Django signers
+	AllowStandardBase64 bool
+
+	// Codec marshals and unmarshals payloads in SignObject and UnsignObject
+	// (default: StdJSONCodec, i.e. encoding/json)
+	Codec JSONCodec
+
+	// MaxDecompressedSize caps both the signed input length and the decoded (decompressed)
+	// payload size in UnsignObject (default: DefaultMaxDecompressedSize)
+	MaxDecompressedSize int64
+
+	// ValidateUTF8 makes UnsignObject reject payloads containing invalid UTF-8 with
+	// ErrInvalidEncoding. encoding/json would otherwise silently replace such bytes with
+	// U+FFFD, and other codecs may pass them through to logs and templates.
+	ValidateUTF8 bool
+
+	// CompressionLevel is the zlib level used when signing compressed objects, from
+	// zlib.HuffmanOnly (-2) to zlib.BestCompression (9). Zero selects
+	// zlib.DefaultCompression; to store data uncompressed, sign with compress=false.
+	CompressionLevel int
+
+	// CompressionPrefix marks compressed payloads in SignObject and UnsignObject, for
+	// third-party signers that use another character than Django's "." (default: ".").
+	// It must be a single character outside the base64 alphabets and the separator.
+	CompressionPrefix string
+
+	// DiagnoseSignatures makes Unsign classify failures for troubleshooting: a signature
+	// that is base64 of the expected digest length but does not match yields
+	// ErrSignatureMismatch (pointing at a wrong secret key or salt), anything else
+	// ErrMalformedSignature (pointing at a format problem). Both wrap ErrInvalidSignature.
+	DiagnoseSignatures bool
+
+	// Now is the clock SignTimestamp and UnsignTimestamp read, so tests can control
+	// signature ages without sleeping (default: time.Now)
+	Now func() time.Time
+}
+
+// now returns the current time from Now, or time.Now when unset
+func (ds *DjangoSigner) now() time.Time {
+	if ds.Now != nil {
+		return ds.Now()
+	}
+	return time.Now()
+}
+
+// validateCompressionLevel rejects levels zlib.NewWriterLevel does not accept
+func validateCompressionLevel(level int) error {
+	if level < zlib.HuffmanOnly || level > zlib.BestCompression {
+		return fmt.Errorf("invalid compression level %d: must be between %d and %d", level, zlib.HuffmanOnly, zlib.BestCompression)
+	}
+	return nil
+}
+
+// compressionLevel returns the configured zlib level, or zlib.DefaultCompression when unset
+func (ds *DjangoSigner) compressionLevel() int {
+	if ds.CompressionLevel == 0 {
+		return zlib.DefaultCompression
+	}
+	return ds.CompressionLevel
+}
+
+// validateCompressionPrefix rejects prefixes that could be confused with base64 payload
+// characters or the separator, which would make compressed data ambiguous
+func validateCompressionPrefix(prefix, sep string) error {
+	if prefix == "" {
+		return nil
+	}
+	if len(prefix) != 1 || prefix[0] > 0x7e || prefix[0] <= ' ' {
+		return fmt.Errorf("compression prefix must be a single printable ASCII character: %q", prefix)
+	}
+	if strings.ContainsAny(prefix, base62Alphabet+"-_+/=") || strings.Contains(sep, prefix) {
+		return fmt.Errorf("unsafe compression prefix: %q", prefix)
+	}
+	return nil
+}
+
+// compressionPrefix returns the configured compression marker, or Django's "." when unset
+func (ds *DjangoSigner) compressionPrefix() string {
+	if ds.CompressionPrefix == "" {
+		return "."
+	}
+	return ds.CompressionPrefix
+}
+
+// codec returns the configured JSON codec, or encoding/json when unset
+func (ds *DjangoSigner) codec() JSONCodec {
+	if ds.Codec != nil {
+		return ds.Codec
+	}
+	return StdJSONCodec{}
+}
+
+// maxPayloadSize returns the configured payload limit, or the default when unset
+func (ds *DjangoSigner) maxPayloadSize() int64 {
+	if ds.MaxDecompressedSize > 0 {
+		return ds.MaxDecompressedSize
+	}
+	return DefaultMaxDecompressedSize
 }
 
 // NewDjangoSigner creates a new signer with default values matching Django's TimestampSigner
@@ -36,6 +167,41 @@ func NewDjangoSigner(secretKey string) *DjangoSigner {
 	}
 }
 
+// NewDjangoSignerWithSalt is NewDjangoSigner for Signer(key, salt=salt), for plain Sign and
+// Unsign. Like Django, an empty salt is replaced with "django.core.signing.Signer".
+func NewDjangoSignerWithSalt(secretKey, salt string) *DjangoSigner {
+	if salt == "" {
+		salt = defaultSignerSalt
+	}
+	signer := NewDjangoSigner(secretKey)
+	signer.Salt = salt
+	return signer
+}
+
+// NewTimestampSignerWithSalt is NewDjangoSigner for TimestampSigner(key, salt=salt), for the
+// timestamped methods (SignTimestamp, SignObject and their Unsign counterparts). Like
+// Django, an empty salt is replaced with "django.core.signing.TimestampSigner".
+func NewTimestampSignerWithSalt(secretKey, salt string) *DjangoSigner {
+	if salt == "" {
+		salt = defaultTimestampSignerSalt
+	}
+	signer := NewDjangoSigner(secretKey)
+	signer.Salt = salt
+	return signer
+}
+
+// validateSeparator rejects separators Django considers unsafe: empty, or containing
+// characters from the URL-safe base64 alphabet (which would make splitting ambiguous)
+func validateSeparator(sep string) error {
+	if sep == "" {
+		return errors.New("separator must not be empty")
+	}
+	if strings.ContainsAny(sep, base62Alphabet+"-_=") {
+		return fmt.Errorf("unsafe signer separator: %q", sep)
+	}
+	return nil
+}
+
 // b64Decode decodes URL-safe base64 with padding handling
 func b64Decode(s string) ([]byte, error) {
 	// Add padding if needed
@@ -44,31 +210,55 @@ func b64Decode(s string) ([]byte, error) {
 	return base64.URLEncoding.DecodeString(padded)
 }
 
+// b64DecodeStd decodes standard-alphabet base64 ('+' and '/') with padding handling
+func b64DecodeStd(s string) ([]byte, error) {
+	padding := (4 - len(s)%4) % 4
+	padded := s + strings.Repeat("=", padding)
+	return base64.StdEncoding.DecodeString(padded)
+}
+
 // b64Encode encodes to URL-safe base64 without padding
 func b64Encode(data []byte) string {
 	encoded := base64.URLEncoding.EncodeToString(data)
 	return strings.TrimRight(encoded, "=")
 }
 
-// b62Encode encodes a number to base62 (used for timestamps)
+// b62Encode encodes a number to base62 (used for timestamps).
+// Digits are written backwards into a fixed buffer, so only the result string is allocated.
 func b62Encode(n int64) string {
 	if n == 0 {
 		return "0"
 	}
-	sign := ""
+	// 11 base62 digits cover the magnitude of any int64, plus one byte for the sign
+	var buf [12]byte
+	i := len(buf)
+	magnitude := uint64(n)
 	if n < 0 {
-		sign = "-"
-		n = -n
+		magnitude = -magnitude // two's complement, correct for math.MinInt64 too
 	}
-	encoded := ""
-	for n > 0 {
-		remainder := n % 62
-		encoded = string(base62Alphabet[remainder]) + encoded
-		n = n / 62
+	for magnitude > 0 {
+		i--
+		buf[i] = base62Alphabet[magnitude%62]
+		magnitude /= 62
 	}
-	return sign + encoded
+	if n < 0 {
+		i--
+		buf[i] = '-'
+	}
+	return string(buf[i:])
 }
 
+// base62Index maps an ASCII byte to its base62 digit value, or -1 if it is not a digit
+var base62Index = func() (index [256]int8) {
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base62Alphabet); i++ {
+		index[base62Alphabet[i]] = int8(i)
+	}
+	return index
+}()
+
 // b62Decode decodes a base62 encoded number (used for timestamps)
 func b62Decode(s string) (int64, error) {
 	if s == "0" {
@@ -80,11 +270,15 @@ func b62Decode(s string) (int64, error) {
 		s = s[1:]
 		sign = -1
 	}
+	if s == "" {
+		return 0, errors.New("empty base62 value")
+	}
 
 	var decoded int64
-	for _, char := range s {
-		index := strings.IndexRune(base62Alphabet, char)
+	for i := 0; i < len(s); i++ {
+		index := base62Index[s[i]]
 		if index == -1 {
+			char, _ := utf8.DecodeRuneInString(s[i:])
 			return 0, fmt.Errorf("invalid base62 character: %c", char)
 		}
 		decoded = decoded*62 + int64(index)
@@ -93,19 +287,73 @@ func b62Decode(s string) (int64, error) {
 	return sign * decoded, nil
 }
 
-// saltedHMAC generates a salted HMAC like Django's salted_hmac function
+// Base62Encode encodes a number using Django's base62 alphabet (as used for signing timestamps).
+// Negative numbers are prefixed with "-".
+func Base62Encode(n int64) string {
+	return b62Encode(n)
+}
+
+// Base62Decode decodes a Django base62 string produced by Base62Encode
+func Base62Decode(s string) (int64, error) {
+	return b62Decode(s)
+}
+
+// Base64URLEncode encodes data as URL-safe base64 with padding stripped, matching Django's b64_encode
+func Base64URLEncode(data []byte) string {
+	return b64Encode(data)
+}
+
+// Base64URLDecode decodes URL-safe base64, restoring any stripped padding, matching Django's b64_decode
+func Base64URLDecode(s string) ([]byte, error) {
+	return b64Decode(s)
+}
+
+// hashForAlgorithm maps a Django/hashlib algorithm name to a Go hash constructor.
+// An empty name selects sha256, Django's default.
+func hashForAlgorithm(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "blake2b":
+		// hashlib.blake2b() defaults to a 64-byte digest
+		return func() hash.Hash {
+			h, _ := blake2b.New512(nil) // only fails for oversized keys
+			return h
+		}, nil
+	case "blake2s":
+		// hashlib.blake2s() defaults to a 32-byte digest
+		return func() hash.Hash {
+			h, _ := blake2s.New256(nil) // only fails for oversized keys
+			return h
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %q", algorithm)
+	}
+}
+
+// saltedHMAC generates a salted HMAC like Django's salted_hmac function.
+// Returns nil if the signer's algorithm is unsupported; signing paths go through sign,
+// which rejects it first.
 func (ds *DjangoSigner) saltedHMAC(salt, value string) []byte {
 	// Django's salted_hmac implementation:
-	// 1. key_salt = hashlib.sha256((salt + secret).encode()).digest()
-	// 2. return hmac.new(key_salt, msg=value.encode(), digestmod=hashlib.sha256)
+	// 1. key_salt = hasher((salt + secret).encode()).digest()
+	// 2. return hmac.new(key_salt, msg=value.encode(), digestmod=hasher)
+	newHash, err := hashForAlgorithm(ds.Algorithm)
+	if err != nil {
+		return nil
+	}
 
-	// Step 1: Derive key from salt + secret using SHA256
-	h := sha256.New()
+	// Step 1: Derive key from salt + secret using the configured hash
+	h := newHash()
 	h.Write([]byte(salt + ds.SecretKey))
 	derivedKey := h.Sum(nil)
 
 	// Step 2: HMAC the value with the derived key
-	mac := hmac.New(sha256.New, derivedKey)
+	mac := hmac.New(newHash, derivedKey)
 	mac.Write([]byte(value))
 
 	return mac.Sum(nil)
@@ -118,6 +366,15 @@ func (ds *DjangoSigner) signature(value string) string {
 	return b64Encode(hashBytes)
 }
 
+// sign appends the separator and value's signature. An unsupported Algorithm is an error
+// rather than a token with an empty signature that could never verify.
+func (ds *DjangoSigner) sign(value string) (string, error) {
+	if _, err := hashForAlgorithm(ds.Algorithm); err != nil {
+		return "", err
+	}
+	return value + ds.Sep + ds.signature(value), nil
+}
+
 // constantTimeCompare performs constant-time string comparison
 func constantTimeCompare(a, b string) bool {
 	return hmac.Equal([]byte(a), []byte(b))
@@ -125,6 +382,9 @@ func constantTimeCompare(a, b string) bool {
 
 // Unsign verifies and extracts the original value from a signed string
 func (ds *DjangoSigner) Unsign(signedValue string) (string, error) {
+	if _, err := hashForAlgorithm(ds.Algorithm); err != nil {
+		return "", err
+	}
 	if !strings.Contains(signedValue, ds.Sep) {
 		return "", errors.New("no separator found in value")
 	}
@@ -132,162 +392,673 @@ func (ds *DjangoSigner) Unsign(signedValue string) (string, error) {
 	// Split from the right to get the last separator
 	lastSepIndex := strings.LastIndex(signedValue, ds.Sep)
 	value := signedValue[:lastSepIndex]
-	sig := signedValue[lastSepIndex+1:]
+	sig := signedValue[lastSepIndex+len(ds.Sep):]
 
 	// Verify signature
 	expectedSig := ds.signature(value)
-	if !constantTimeCompare(sig, expectedSig) {
-		return "", fmt.Errorf("signature does not match")
+	if !constantTimeCompare(sig, expectedSig) && !ds.legacySignatureMatches(value, sig) && !ds.fallbackSignatureMatches(value, sig) {
+		if ds.DiagnoseSignatures {
+			return "", ds.signatureFailure(sig)
+		}
+		return "", ErrInvalidSignature
 	}
 
 	return value, nil
 }
 
-// UnsignTimestamp verifies and extracts value from a timestamped signed string
+// signatureFailure classifies a signature that failed to verify: ErrSignatureMismatch if
+// it decodes to a digest of the signing (or legacy) algorithm's size, so only the key or
+// salt can be wrong, ErrMalformedSignature otherwise
+func (ds *DjangoSigner) signatureFailure(sig string) error {
+	decoded, err := b64Decode(sig)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+	algorithms := []string{ds.Algorithm}
+	if ds.LegacyAlgorithm != "" {
+		algorithms = append(algorithms, ds.LegacyAlgorithm)
+	}
+	for _, algorithm := range algorithms {
+		if newHash, err := hashForAlgorithm(algorithm); err == nil && len(decoded) == newHash().Size() {
+			return ErrSignatureMismatch
+		}
+	}
+	return ErrMalformedSignature
+}
+
+// fallbackSignatureMatches reports whether sig is value's signature under any FallbackKeys entry
+func (ds *DjangoSigner) fallbackSignatureMatches(value, sig string) bool {
+	for _, key := range ds.FallbackKeys {
+		fallback := *ds
+		fallback.SecretKey = key
+		fallback.FallbackKeys = nil
+		if constantTimeCompare(sig, fallback.signature(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// legacySignatureMatches reports whether sig is value's signature under LegacyAlgorithm
+func (ds *DjangoSigner) legacySignatureMatches(value, sig string) bool {
+	if ds.LegacyAlgorithm == "" {
+		return false
+	}
+	if _, err := hashForAlgorithm(ds.LegacyAlgorithm); err != nil {
+		return false
+	}
+	legacy := *ds
+	legacy.Algorithm = ds.LegacyAlgorithm
+	return constantTimeCompare(sig, legacy.signature(value))
+}
+
+// SignerParams is a salt/algorithm combination to try when verifying a signature
+type SignerParams struct {
+	Salt      string
+	Algorithm string
+}
+
+// UnsignAny verifies signedValue against each candidate salt/algorithm combination in
+// order (using this signer's secret key and separator) and returns the value for the
+// first one that matches. Useful when payloads were signed under per-purpose settings,
+// e.g. legacy sha1 alongside sha256.
+func (ds *DjangoSigner) UnsignAny(signedValue string, candidates []SignerParams) (string, error) {
+	if len(candidates) == 0 {
+		return "", errors.New("no signer candidates provided")
+	}
+
+	for _, candidate := range candidates {
+		signer := *ds
+		signer.Salt = candidate.Salt
+		signer.Algorithm = candidate.Algorithm
+
+		if value, err := signer.Unsign(signedValue); err == nil {
+			return value, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no candidate matched", ErrInvalidSignature)
+}
+
+// ErrExpired is returned when a signature is older than the allowed max age. It matches
+// ErrSessionExpired with errors.Is; use errors.As to read the ages, e.g. to tell sessions
+// just over the limit from very old ones.
+type ErrExpired struct {
+	age    time.Duration
+	maxAge time.Duration
+}
+
+// Error implements error
+func (e *ErrExpired) Error() string {
+	return fmt.Sprintf("signature age %v > %v", e.age, e.maxAge)
+}
+
+// Is reports whether target is ErrSessionExpired
+func (e *ErrExpired) Is(target error) bool {
+	return target == ErrSessionExpired
+}
+
+// Age returns how long ago the value was signed
+func (e *ErrExpired) Age() time.Duration {
+	return e.age
+}
+
+// MaxAge returns the max age the signature was checked against
+func (e *ErrExpired) MaxAge() time.Duration {
+	return e.maxAge
+}
+
+// UnsignTimestamp verifies and extracts value from a timestamped signed string.
+// A signature older than maxAge fails with *ErrExpired.
 func (ds *DjangoSigner) UnsignTimestamp(signedValue string, maxAge *time.Duration) (string, error) {
+	value, signedAt, err := ds.unsignTimestamp(signedValue)
+	if err != nil {
+		return "", err
+	}
+
+	// Check age if maxAge is specified
+	if maxAge != nil {
+		age := ds.now().Sub(signedAt)
+		if age > *maxAge {
+			return "", &ErrExpired{age: age, maxAge: *maxAge}
+		}
+	}
+
+	return value, nil
+}
+
+// unsignTimestamp verifies a timestamped signed string and returns the value and signing time
+func (ds *DjangoSigner) unsignTimestamp(signedValue string) (string, time.Time, error) {
 	// First unsign to verify the signature
 	result, err := ds.Unsign(signedValue)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
 	// Split to get value and timestamp
 	if !strings.Contains(result, ds.Sep) {
-		return "", errors.New("no timestamp separator found")
+		return "", time.Time{}, errors.New("no timestamp separator found")
 	}
 
 	lastSepIndex := strings.LastIndex(result, ds.Sep)
 	value := result[:lastSepIndex]
-	timestampStr := result[lastSepIndex+1:]
+	timestampStr := result[lastSepIndex+len(ds.Sep):]
 
 	// Decode base62 timestamp
 	timestamp, err := b62Decode(timestampStr)
 	if err != nil {
-		return "", fmt.Errorf("invalid timestamp: %w", err)
+		return "", time.Time{}, fmt.Errorf("invalid timestamp: %w", err)
 	}
 
-	// Check age if maxAge is specified
-	if maxAge != nil {
-		age := time.Since(time.Unix(timestamp, 0))
-		if age > *maxAge {
-			return "", fmt.Errorf("signature age %v > %v", age, *maxAge)
-		}
-	}
-
-	return value, nil
+	return value, time.Unix(timestamp, 0), nil
 }
 
-// SignTimestamp signs a value with a timestamp
-func (ds *DjangoSigner) SignTimestamp(value string) string {
-	timestamp := time.Now().Unix()
+// SignTimestamp signs a value with a timestamp. An unsupported Algorithm is an error, as
+// the result could never verify.
+func (ds *DjangoSigner) SignTimestamp(value string) (string, error) {
+	timestamp := ds.now().Unix()
 	timestampB62 := b62Encode(timestamp)
-	valueWithTimestamp := value + ds.Sep + timestampB62
-	sig := ds.signature(valueWithTimestamp)
-	return valueWithTimestamp + ds.Sep + sig
+	return ds.sign(value + ds.Sep + timestampB62)
 }
 
-// SignObject encodes and signs a map as JSON with timestamp and optional compression
+// SignObject encodes and signs a map as JSON with timestamp and optional compression.
+// Values Django's JSONSerializer cannot read back (channels, funcs, complex numbers, NaN,
+// infinity) are rejected with an error naming the offending key.
 func (ds *DjangoSigner) SignObject(obj map[string]interface{}, compress bool) (string, error) {
+	for key, value := range obj {
+		if err := validateSessionValue(key, reflect.ValueOf(value), 0); err != nil {
+			return "", fmt.Errorf("json encode error: %w", err)
+		}
+	}
+
 	// Marshal to JSON
-	jsonData, err := json.Marshal(obj)
+	jsonData, err := ds.codec().Marshal(obj)
 	if err != nil {
 		return "", fmt.Errorf("json encode error: %w", err)
 	}
 
-	var dataToEncode []byte
-	var prefix string
+	return ds.signJSON(jsonData, compress, false)
+}
+
+// maxSessionValueDepth bounds how deeply nested session values may be, which also stops
+// validateSessionValue from looping on self-referencing maps and slices
+const maxSessionValueDepth = 64
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// validateSessionValue reports values that cannot round-trip through Django's JSONSerializer
+// before json.Marshal fails on them (or silently changes them). Supported are nil, bools,
+// strings, integers, finite floats, json.Number, slices, arrays, maps with string or integer
+// keys, pointers to any of these, structs (encoded through their json tags) and types
+// implementing json.Marshaler or encoding.TextMarshaler, such as time.Time (stored as an
+// RFC 3339 string, which Django reads back as a plain str). Channels, funcs and complex
+// numbers are rejected. path names the offending value in errors.
+func validateSessionValue(path string, v reflect.Value, depth int) error {
+	if depth > maxSessionValueDepth {
+		return fmt.Errorf("session value %q is nested too deeply", path)
+	}
+	if !v.IsValid() {
+		return nil // nil interface
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("session value %q: NaN and infinity are not valid JSON", path)
+		}
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return validateSessionValue(path, v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil // []byte is encoded as a base64 string
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := validateSessionValue(fmt.Sprintf("%s[%d]", path, i), v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		switch keyType := v.Type().Key(); {
+		case keyType.Kind() == reflect.String, keyType.Implements(textMarshalerType):
+		case keyType.Kind() >= reflect.Int && keyType.Kind() <= reflect.Uintptr:
+		default:
+			return fmt.Errorf("session value %q: unsupported map key type %s", path, keyType)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateSessionValue(fmt.Sprintf("%s.%v", path, iter.Key()), iter.Value(), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Tag.Get("json") == "-" {
+				continue
+			}
+			if err := validateSessionValue(path+"."+field.Name, v.Field(i), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("session value %q: unsupported type %s", path, v.Type())
+	}
+}
+
+// SignRawJSON signs already-marshaled JSON without re-encoding it, so key order and
+// formatting are preserved byte for byte. Like Django's dumps, compression is only
+// applied when it makes the payload smaller.
+func (ds *DjangoSigner) SignRawJSON(jsonData []byte, compress bool) (string, error) {
+	if !json.Valid(jsonData) {
+		return "", errors.New("json encode error: invalid JSON input")
+	}
+
+	return ds.signJSON(jsonData, compress, true)
+}
+
+// KeyValue is one entry of an ordered session payload for SignOrderedObject
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// SignOrderedObject signs fields as a JSON object in the given order, encoded like Django's
+// json.dumps(obj, separators=(",", ":")): no whitespace, HTML characters left as is,
+// non-ASCII and DEL escaped as \uXXXX and floats written like Python's repr (1.0, 1e-05).
+// For values built from maps, slices, strings, numbers, booleans and nil, this together
+// with Django's insertion ordering reproduces Django's tokens byte for byte, apart from the
+// timestamp. Nested maps are still encoded with sorted keys, and structs and values with
+// their own MarshalJSON are encoded as encoding/json does.
+func (ds *DjangoSigner) SignOrderedObject(fields []KeyValue, compress bool) (string, error) {
+	for _, field := range fields {
+		if err := validateSessionValue(field.Key, reflect.ValueOf(field.Value), 0); err != nil {
+			return "", fmt.Errorf("json encode error: %w", err)
+		}
+	}
+
+	jsonData, err := marshalOrdered(fields)
+	if err != nil {
+		return "", fmt.Errorf("json encode error: %w", err)
+	}
+
+	return ds.signJSON(jsonData, compress, true)
+}
+
+// marshalOrdered encodes fields as a compact JSON object in Python's json.dumps style
+func marshalOrdered(fields []KeyValue) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(field.Key); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline
+		buf.WriteByte(':')
+		if err := encoder.Encode(pythonFloats(reflect.ValueOf(field.Value))); err != nil {
+			return nil, err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteByte('}')
+
+	return asciiEscape(buf.Bytes()), nil
+}
+
+// pythonFloat is a float that encodes like Python's repr, as json.dumps writes floats
+type pythonFloat struct {
+	value   float64
+	bitSize int
+}
+
+// MarshalJSON writes f in the shortest form that round-trips, like Go, but with Python's
+// layout: a ".0" suffix on integral values and exponents below 1e-4 and from 1e16 on
+func (f pythonFloat) MarshalJSON() ([]byte, error) {
+	sci := strconv.FormatFloat(f.value, 'e', -1, f.bitSize)
+	exp, err := strconv.Atoi(sci[strings.LastIndexByte(sci, 'e')+1:])
+	if err != nil {
+		return nil, err
+	}
+	if f.value != 0 && (exp < -4 || exp >= 16) {
+		return []byte(sci), nil
+	}
+	fixed := strconv.FormatFloat(f.value, 'f', -1, f.bitSize)
+	if !strings.Contains(fixed, ".") {
+		fixed += ".0"
+	}
+	return []byte(fixed), nil
+}
+
+// pythonFloats returns v with the floats inside maps, slices, arrays and pointers replaced
+// by pythonFloat. Other values, including structs and marshalers, are returned unchanged.
+func pythonFloats(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Float32:
+		return pythonFloat{v.Float(), 32}
+	case reflect.Float64:
+		return pythonFloat{v.Float(), 64}
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return pythonFloats(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 || (v.Kind() == reflect.Slice && v.IsNil()) {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = pythonFloats(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Implements(textMarshalerType) {
+			return v.Interface()
+		}
+		entries := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var key string
+			switch k := iter.Key(); k.Kind() {
+			case reflect.String:
+				key = k.String()
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				key = strconv.FormatInt(k.Int(), 10)
+			default:
+				key = strconv.FormatUint(k.Uint(), 10)
+			}
+			entries[key] = pythonFloats(iter.Value())
+		}
+		return entries
+	default:
+		return v.Interface()
+	}
+}
+
+// asciiEscape replaces non-ASCII characters and DEL in JSON with \uXXXX escapes (UTF-16
+// surrogate pairs above U+FFFF), matching Python's ensure_ascii=True
+func asciiEscape(data []byte) []byte {
+	var out bytes.Buffer
+	for _, r := range string(data) {
+		switch {
+		case r == 0x7f:
+			out.WriteString("\\u007f")
+		case r < utf8.RuneSelf:
+			out.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&out, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&out, "\\u%04x", r)
+		}
+	}
+	return out.Bytes()
+}
+
+// signJSON optionally compresses, base64-encodes and timestamp-signs JSON data.
+// With onlyIfSmaller, compressed output is discarded unless it saves space (Django's rule).
+func (ds *DjangoSigner) signJSON(jsonData []byte, compress, onlyIfSmaller bool) (string, error) {
+	dataToEncode := jsonData
+	prefix := ""
 
 	// Compress if requested
 	if compress {
+		if err := validateCompressionLevel(ds.CompressionLevel); err != nil {
+			return "", err
+		}
+		if err := validateCompressionPrefix(ds.CompressionPrefix, ds.Sep); err != nil {
+			return "", err
+		}
 		var buf bytes.Buffer
-		writer := zlib.NewWriter(&buf)
-		_, err := writer.Write(jsonData)
+		writer, err := zlib.NewWriterLevel(&buf, ds.compressionLevel())
+		if err != nil {
+			return "", fmt.Errorf("zlib compress error: %w", err)
+		}
+		_, err = writer.Write(jsonData)
 		if err != nil {
 			writer.Close()
 			return "", fmt.Errorf("zlib compress error: %w", err)
 		}
 		writer.Close()
-		dataToEncode = buf.Bytes()
-		prefix = "."
-	} else {
-		dataToEncode = jsonData
-		prefix = ""
+
+		if !onlyIfSmaller || buf.Len() < len(jsonData)-1 {
+			dataToEncode = buf.Bytes()
+			prefix = ds.compressionPrefix()
+		}
 	}
 
 	// Encode to base64
 	base64Data := prefix + b64Encode(dataToEncode)
 
 	// Sign with timestamp
-	return ds.SignTimestamp(base64Data), nil
+	return ds.SignTimestamp(base64Data)
 }
 
 // UnsignObject decodes a signed object (JSON)
 func (ds *DjangoSigner) UnsignObject(signedObj string, maxAge *time.Duration) (map[string]interface{}, error) {
-	// Unsign with timestamp verification
-	var base64Data string
-	var err error
+	data, err := ds.unsignJSON(signedObj, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	if ds.ValidateUTF8 && !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: payload is not valid UTF-8", ErrInvalidEncoding)
+	}
 
-	if maxAge != nil {
-		base64Data, err = ds.UnsignTimestamp(signedObj, maxAge)
-	} else {
-		base64Data, err = ds.UnsignTimestamp(signedObj, nil)
+	// Parse JSON
+	var result map[string]interface{}
+	if err := ds.codec().Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("json decode error: %w", err)
+	}
+	if result == nil {
+		// JSON null decodes without error but is not a session payload
+		return nil, errors.New("json decode error: payload is not an object")
+	}
+	if ds.ValidateUTF8 {
+		if err := validateUTF8Strings("", result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// validateUTF8Strings checks every key and string value of a decoded payload with
+// utf8.ValidString, for codecs that do not sanitize strings themselves
+func validateUTF8Strings(path string, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		if !utf8.ValidString(v) {
+			return fmt.Errorf("%w: session value %q is not valid UTF-8", ErrInvalidEncoding, path)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			if !utf8.ValidString(key) {
+				return fmt.Errorf("%w: session key %q is not valid UTF-8", ErrInvalidEncoding, key)
+			}
+			if err := validateUTF8Strings(strings.TrimPrefix(path+"."+key, "."), item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := validateUTF8Strings(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
 
+// ReSignObject verifies signedObj (checking maxAge) and signs the same JSON payload again
+// with a fresh timestamp, the signed-cookie analogue of extending a session's expire_date
+// for a sliding max age. The payload bytes are kept as they are, not re-encoded.
+func (ds *DjangoSigner) ReSignObject(signedObj string, maxAge *time.Duration, compress bool) (string, error) {
+	data, err := ds.unsignJSON(signedObj, maxAge)
 	if err != nil {
+		return "", err
+	}
+
+	var result map[string]interface{}
+	if err := ds.codec().Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("json decode error: %w", err)
+	}
+	if result == nil {
+		return "", errors.New("json decode error: payload is not an object")
+	}
+
+	return ds.signJSON(data, compress, true)
+}
+
+// unsignJSON verifies a signed object and returns its decompressed JSON payload
+func (ds *DjangoSigner) unsignJSON(signedObj string, maxAge *time.Duration) ([]byte, error) {
+	// Reject oversized input before verifying or decoding it
+	limit := ds.maxPayloadSize()
+	if int64(len(signedObj)) > limit {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrPayloadTooLarge, len(signedObj), limit)
+	}
+
+	if err := validateCompressionPrefix(ds.CompressionPrefix, ds.Sep); err != nil {
 		return nil, err
 	}
 
-	// Check if compressed (starts with '.')
-	decompress := false
-	if len(base64Data) > 0 && base64Data[0] == '.' {
-		decompress = true
-		base64Data = base64Data[1:]
+	// Unsign with timestamp verification
+	base64Data, err := ds.UnsignTimestamp(signedObj, maxAge)
+	if err != nil {
+		return nil, err
 	}
 
+	// Check if compressed (starts with the compression prefix, '.' by default)
+	base64Data, decompress := strings.CutPrefix(base64Data, ds.compressionPrefix())
+
 	// Decode base64
 	data, err := b64Decode(base64Data)
+	if err != nil && ds.AllowStandardBase64 {
+		data, err = b64DecodeStd(base64Data)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("base64 decode error: %w", err)
 	}
 
 	// Decompress if needed
 	if decompress {
-		reader, err := zlib.NewReader(bytes.NewReader(data))
+		decompressed, err := decompressData(data, limit)
 		if err != nil {
-			return nil, fmt.Errorf("zlib decompress error: %w", err)
+			return nil, err
 		}
-		defer reader.Close()
+		data = decompressed
+	}
 
-		decompressed, err := io.ReadAll(reader)
-		if err != nil {
-			return nil, fmt.Errorf("zlib read error: %w", err)
+	return data, nil
+}
+
+// decompressData inflates a compressed payload. Django uses zlib, but some custom
+// signers emit raw DEFLATE (no zlib header), so fall back to flate when zlib fails.
+// Output beyond limit bytes is never read; ErrPayloadTooLarge is returned instead.
+// Truncated or otherwise unreadable streams fail with ErrCorruptSession. Readers are
+// always closed; neither decompressor starts goroutines.
+func decompressData(data []byte, limit int64) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return inflateRaw(data, limit, fmt.Errorf("%w: zlib decompress error: %v", ErrCorruptSession, err))
+	}
+	defer reader.Close()
+
+	decompressed, err := readLimited(reader, limit)
+	if err != nil {
+		if errors.Is(err, ErrPayloadTooLarge) {
+			return nil, err
 		}
-		data = decompressed
+		return inflateRaw(data, limit, fmt.Errorf("%w: zlib read error: %v", ErrCorruptSession, err))
 	}
+	return decompressed, nil
+}
 
-	// Parse JSON
-	var result map[string]interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, fmt.Errorf("json decode error: %w", err)
+// inflateRaw decompresses raw DEFLATE data, returning zlibErr if that fails too
+func inflateRaw(data []byte, limit int64, zlibErr error) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(data))
+	defer reader.Close()
+
+	decompressed, err := readLimited(reader, limit)
+	if err != nil {
+		if errors.Is(err, ErrPayloadTooLarge) {
+			return nil, err
+		}
+		return nil, zlibErr
 	}
+	return decompressed, nil
+}
 
-	return result, nil
+// readLimited reads r to EOF, failing with ErrPayloadTooLarge once more than limit bytes are produced
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: decompressed size exceeds limit of %d", ErrPayloadTooLarge, limit)
+	}
+	return data, nil
 }
 
 // DecodeSessionData decodes Django session data and returns the user ID
 // Uses the default salt for Django sessions: "django.contrib.sessions.SessionStore"
 func DecodeSessionData(sessionData, secretKey string) (string, error) {
-	return DecodeSessionDataWithMaxAge(sessionData, secretKey, 0)
+	return DecodeSessionDataMaxAge(sessionData, secretKey, 0)
+}
+
+// DecodeSessionDataMaxAge decodes Django session data, rejecting signatures older than
+// maxAge. A zero or negative maxAge disables the age check, as with ClientConfig.MaxAge.
+func DecodeSessionDataMaxAge(sessionData, secretKey string, maxAge time.Duration) (string, error) {
+	// Django sessions use a specific salt
+	return DecodeSessionDataWithSaltMaxAge(sessionData, secretKey, "django.contrib.sessions.SessionStore", maxAge)
 }
 
 // DecodeSessionDataWithMaxAge decodes Django session data with timestamp validation
+//
+// Deprecated: use DecodeSessionDataMaxAge, which takes a time.Duration.
 func DecodeSessionDataWithMaxAge(sessionData, secretKey string, maxAgeSeconds int) (string, error) {
-	// Django sessions use a specific salt
-	return DecodeSessionDataWithSalt(sessionData, secretKey, "django.contrib.sessions.SessionStore", maxAgeSeconds)
+	return DecodeSessionDataMaxAge(sessionData, secretKey, time.Duration(maxAgeSeconds)*time.Second)
 }
 
 // DecodeSessionDataWithSalt decodes Django session data with custom salt and timestamp validation
+//
+// Deprecated: use DecodeSessionDataWithSaltMaxAge, which takes a time.Duration.
 func DecodeSessionDataWithSalt(sessionData, secretKey, salt string, maxAgeSeconds int) (string, error) {
+	return DecodeSessionDataWithSaltMaxAge(sessionData, secretKey, salt, time.Duration(maxAgeSeconds)*time.Second)
+}
+
+// DecodeSessionDataWithSaltMaxAge decodes Django session data with a custom salt, rejecting
+// signatures older than maxAge. A zero or negative maxAge disables the age check.
+func DecodeSessionDataWithSaltMaxAge(sessionData, secretKey, salt string, maxAge time.Duration) (string, error) {
 	signer := &DjangoSigner{
 		SecretKey: secretKey,
 		Salt:      salt,
@@ -296,40 +1067,30 @@ func DecodeSessionDataWithSalt(sessionData, secretKey, salt string, maxAgeSecond
 	}
 
 	// Decode the session object with optional max age check
-	var sessionMap map[string]interface{}
-	var err error
-
-	if maxAgeSeconds > 0 {
-		maxAge := time.Duration(maxAgeSeconds) * time.Second
-		sessionMap, err = signer.UnsignObject(sessionData, &maxAge)
-	} else {
-		sessionMap, err = signer.UnsignObject(sessionData, nil)
-	}
-
+	sessionMap, err := signer.UnsignObject(sessionData, maxAgeLimit(maxAge))
 	if err != nil {
 		return "", fmt.Errorf("failed to unsign session: %w", err)
 	}
 
-	// Extract _auth_user_id
-	userID, ok := sessionMap["_auth_user_id"]
-	if !ok {
-		return "", errors.New("_auth_user_id not found in session")
-	}
+	// Extract _auth_user_id (might be string or number)
+	return extractUserID(sessionMap)
+}
 
-	// Convert to string (might be string or number)
-	switch v := userID.(type) {
-	case string:
-		return v, nil
-	case float64:
-		return fmt.Sprintf("%.0f", v), nil
-	case int:
-		return fmt.Sprintf("%d", v), nil
-	default:
-		return "", fmt.Errorf("unexpected user ID type: %T", v)
+// maxAgeLimit converts a duration to UnsignObject's max age argument: nil (no age check)
+// for zero or negative durations
+func maxAgeLimit(maxAge time.Duration) *time.Duration {
+	if maxAge <= 0 {
+		return nil
 	}
+	return &maxAge
 }
 
 // EncodeSessionData creates a new Django session with the given user ID and additional data
+//
+// additionalData values may be nil, bools, strings, numbers, slices, maps, structs (encoded
+// through their json tags) or json.Marshaler/encoding.TextMarshaler types. Django has no
+// JSON type for datetimes, so a time.Time is stored as an RFC 3339 string and read back by
+// Django as a str; convert it to the format your Django code parses if that matters.
 func EncodeSessionData(userID string, secretKey string, additionalData map[string]interface{}) (string, error) {
 	return EncodeSessionDataWithSalt(userID, secretKey, "django.contrib.sessions.SessionStore", additionalData, true)
 }
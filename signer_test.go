@@ -1,7 +1,14 @@
 package django_session
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
 	"strings"
 	"testing"
 	"time"
@@ -91,6 +98,269 @@ func TestDjangoSignerSignature(t *testing.T) {
 	}
 }
 
+func TestDjangoSignerEmptySalt(t *testing.T) {
+	// Reference signatures computed with Django's algorithm:
+	//   Signer(key="django-insecure-test-key", salt=...).signature("hello")
+	// Django's Signer replaces an empty salt with "django.core.signing.Signer".
+	secretKey := "django-insecure-test-key"
+	expected := "hIWUBSHRlgzjp_KSie0FnxS8BGHmCcx7zN1-vNf-afM"
+
+	constructed := NewDjangoSignerWithSalt(secretKey, "")
+	if sig := constructed.signature("hello"); sig != expected {
+		t.Errorf("NewDjangoSignerWithSalt(\"\").signature() = %v, want %v", sig, expected)
+	}
+	if value, err := constructed.Unsign("hello:" + expected); err != nil || value != "hello" {
+		t.Errorf("Unsign() = %v, %v, want hello", value, err)
+	}
+
+	explicitSalt := &DjangoSigner{SecretKey: secretKey, Salt: "django.core.signing.Signer", Sep: ":", Algorithm: "sha256"}
+	if sig := explicitSalt.signature("hello"); sig != expected {
+		t.Errorf("signature() with default salt = %v, want %v", sig, expected)
+	}
+
+	// A literal with an empty Salt keeps signing with the bare "signer" key salt
+	literal := &DjangoSigner{SecretKey: secretKey, Salt: "", Sep: ":", Algorithm: "sha256"}
+	if sig := literal.signature("hello"); sig != "PAhr82r_nOrM-JdOAk-VluOe8oIqkstaVGk1pPhDFrg" {
+		t.Errorf("signature() with empty Salt field = %v, want the bare \"signer\" signature", sig)
+	}
+
+	// Client methods taking a salt sign timestamped tokens, so an empty salt falls back to
+	// TimestampSigner's class path. Reference tokens from Django's algorithm for {"a": 1}
+	// signed at 1700000000 with TimestampSigner(salt="") and with Signer's default salt:
+	timestampToken := "eyJhIjoxfQ:1r31eq:GgJuIhHMa-BkWkBVztnLvBw1KdHpM_icyLnbbtKEUbA"
+	signerSaltToken := "eyJhIjoxfQ:1r31eq:NJRaO2awDb38whyZBOWi1jqLKrztILw4Wd5yEtg0hdc"
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+	data, err := client.VerifyToken(timestampToken, "", 0)
+	if err != nil || data["a"] != float64(1) {
+		t.Errorf("VerifyToken(salt=\"\") = %v, %v, want the TimestampSigner token to verify", data, err)
+	}
+	if _, err := client.VerifyToken(signerSaltToken, "", 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyToken(salt=\"\") error = %v, want ErrInvalidSignature for a Signer-salt token", err)
+	}
+	if _, err := NewTimestampSignerWithSalt(secretKey, "").UnsignObject(timestampToken, nil); err != nil {
+		t.Errorf("NewTimestampSignerWithSalt(\"\").UnsignObject() error = %v", err)
+	}
+
+	token, err := client.EncodeWithSalt(map[string]interface{}{"a": 1}, "", false)
+	if err != nil {
+		t.Fatalf("EncodeWithSalt() error = %v", err)
+	}
+	if _, err := NewTimestampSignerWithSalt(secretKey, "").UnsignObject(token, nil); err != nil {
+		t.Errorf("EncodeWithSalt(salt=\"\") does not verify with TimestampSigner's default salt: %v", err)
+	}
+}
+
+func TestDjangoSignerCustomSeparator(t *testing.T) {
+	for _, sep := range []string{":", "|", "~~"} {
+		t.Run(sep, func(t *testing.T) {
+			signer := &DjangoSigner{
+				SecretKey: "test-secret-key",
+				Salt:      "django.contrib.sessions.SessionStore",
+				Sep:       sep,
+				Algorithm: "sha256",
+			}
+
+			signed, err := signer.SignObject(map[string]interface{}{"_auth_user_id": "7"}, true)
+			if err != nil {
+				t.Fatalf("SignObject() error = %v", err)
+			}
+			if strings.Count(signed, sep) != 2 {
+				t.Errorf("Expected 2 separators in %q", signed)
+			}
+
+			decoded, err := signer.UnsignObject(signed, nil)
+			if err != nil {
+				t.Fatalf("UnsignObject() error = %v", err)
+			}
+			if decoded["_auth_user_id"] != "7" {
+				t.Errorf("_auth_user_id = %v, want 7", decoded["_auth_user_id"])
+			}
+
+			// A token signed with one separator must not verify with another
+			other := NewDjangoSigner("test-secret-key")
+			other.Salt = signer.Salt
+			other.Sep = "/"
+			if _, err := other.UnsignObject(signed, nil); err == nil {
+				t.Error("UnsignObject() expected error for mismatched separator")
+			}
+		})
+	}
+}
+
+func TestValidateSeparator(t *testing.T) {
+	tests := []struct {
+		sep     string
+		wantErr bool
+	}{
+		{":", false},
+		{"|", false},
+		{"", true},
+		{"a", true},
+		{"-", true},
+		{"_", true},
+		{"=", true},
+	}
+
+	for _, tt := range tests {
+		if err := validateSeparator(tt.sep); (err != nil) != tt.wantErr {
+			t.Errorf("validateSeparator(%q) error = %v, wantErr %v", tt.sep, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDjangoSignerAlgorithms(t *testing.T) {
+	// Reference signatures computed with Django's salted_hmac for each algorithm:
+	//   Signer(key="django-insecure-test-key", salt=..., algorithm=...).signature("hello")
+	tests := []struct {
+		salt      string
+		algorithm string
+		expected  string
+	}{
+		{"legacy.purpose", "sha1", "PgqXYTXKSWVEnVwguv3zbKjwuvQ"},
+		{"modern.purpose", "sha256", "cb9h3UP9Yeoer5SE9uBCwcj543nw8ljOqJGH8c62Rhw"},
+		{"modern.purpose", "", "cb9h3UP9Yeoer5SE9uBCwcj543nw8ljOqJGH8c62Rhw"},
+		{"modern.purpose", "sha512", "-LBBavFA8bjPtKs28qPel94-FycZpXawf3mFMP_-lthtlVdHwMLjpbmFHdZar1qFp4nh0nnpf96LAMWUCgYucQ"},
+		{"modern.purpose", "blake2b", "QU8AUMUB3HU-kDoExQhWQIro9T7N7QND5iDLOtrB1Ba4rqgxnq3AKwGBWoTbBmSeSduOhQm0i1fQsUZ_ziorkQ"},
+		{"modern.purpose", "blake2s", "ehf7t5f-ZP93GWzAJrQCecAXyqJ1yN1PHdz3l84IjrI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			signer := &DjangoSigner{SecretKey: "django-insecure-test-key", Salt: tt.salt, Sep: ":", Algorithm: tt.algorithm}
+			if sig := signer.signature("hello"); sig != tt.expected {
+				t.Errorf("signature() = %v, want %v", sig, tt.expected)
+			}
+		})
+	}
+
+	unsupported := &DjangoSigner{SecretKey: "k", Salt: "s", Sep: ":", Algorithm: "md5"}
+	if _, err := unsupported.Unsign("hello:abc"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("Unsign() with unsupported algorithm error = %v", err)
+	}
+	if signed, err := unsupported.SignObject(map[string]interface{}{"a": 1}, false); err == nil || !strings.Contains(err.Error(), "unsupported signing algorithm") {
+		t.Errorf("SignObject() with unsupported algorithm = %q, %v, want error", signed, err)
+	}
+	if signed, err := unsupported.SignOrderedObject([]KeyValue{{Key: "a", Value: 1}}, true); err == nil {
+		t.Errorf("SignOrderedObject() with unsupported algorithm = %q, want error", signed)
+	}
+	if signed, err := unsupported.SignTimestamp("hello"); err == nil || !strings.Contains(err.Error(), "unsupported signing algorithm") {
+		t.Errorf("SignTimestamp() with unsupported algorithm = %q, %v, want error", signed, err)
+	}
+}
+
+func TestDjangoSignerUnsignAny(t *testing.T) {
+	signer := &DjangoSigner{SecretKey: "django-insecure-test-key", Sep: ":"}
+	candidates := []SignerParams{
+		{Salt: "modern.purpose", Algorithm: "sha256"},
+		{Salt: "legacy.purpose", Algorithm: "sha1"},
+	}
+
+	tests := []struct {
+		name    string
+		signed  string
+		wantErr bool
+	}{
+		{"first candidate", "hello:cb9h3UP9Yeoer5SE9uBCwcj543nw8ljOqJGH8c62Rhw", false},
+		{"second candidate", "hello:PgqXYTXKSWVEnVwguv3zbKjwuvQ", false},
+		{"no candidate matches", "hello:-LBBavFA8bjPtKs28qPel94-FycZpXawf3mFMP_-lthtlVdHwMLjpbmFHdZar1qFp4nh0nnpf96LAMWUCgYucQ", true},
+		{"tampered value", "hellx:PgqXYTXKSWVEnVwguv3zbKjwuvQ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := signer.UnsignAny(tt.signed, candidates)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("UnsignAny() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnsignAny() error = %v", err)
+			}
+			if value != "hello" {
+				t.Errorf("UnsignAny() = %v, want hello", value)
+			}
+		})
+	}
+
+	if _, err := signer.UnsignAny("hello:abc", nil); err == nil {
+		t.Error("UnsignAny() expected error with no candidates")
+	}
+}
+
+func TestDjangoSignerFallbackKeys(t *testing.T) {
+	oldSigner := &DjangoSigner{SecretKey: "old-secret", Salt: "s", Sep: ":", Algorithm: "sha256"}
+	signer := &DjangoSigner{SecretKey: "new-secret", Salt: "s", Sep: ":", Algorithm: "sha256", FallbackKeys: []string{"older-secret", "old-secret"}}
+
+	if value, err := signer.UnsignTimestamp(mustSignTimestamp(t, oldSigner, "hello"), nil); err != nil || value != "hello" {
+		t.Errorf("UnsignTimestamp(fallback) = %v, %v, want hello", value, err)
+	}
+	if value, err := signer.UnsignTimestamp(mustSignTimestamp(t, signer, "hello"), nil); err != nil || value != "hello" {
+		t.Errorf("UnsignTimestamp(current) = %v, %v, want hello", value, err)
+	}
+	if mustSignTimestamp(t, signer, "hello") == mustSignTimestamp(t, oldSigner, "hello") {
+		t.Error("Expected new signatures to use SecretKey, not a fallback")
+	}
+
+	other := &DjangoSigner{SecretKey: "unknown", Salt: "s", Sep: ":", Algorithm: "sha256"}
+	if _, err := signer.UnsignTimestamp(mustSignTimestamp(t, other, "hello"), nil); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("UnsignTimestamp(unknown key) error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestDjangoSignerDiagnoseSignatures(t *testing.T) {
+	const secretKey = "django-insecure-secret"
+	sign := func(signer *DjangoSigner, value string) string { return value + ":" + signer.signature(value) }
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: "s", Sep: ":", Algorithm: "sha256", DiagnoseSignatures: true}
+	signed := sign(signer, "hello")
+
+	// The classic misconfiguration: the other side uses the key base64-encoded
+	wrongKey := &DjangoSigner{SecretKey: base64.StdEncoding.EncodeToString([]byte(secretKey)), Salt: "s", Sep: ":", Algorithm: "sha256"}
+	sha1Signer := &DjangoSigner{SecretKey: "other", Salt: "s", Sep: ":", Algorithm: "sha1"}
+
+	tests := []struct {
+		name    string
+		signed  string
+		wantErr error
+	}{
+		{"valid", signed, nil},
+		{"wrong key", sign(wrongKey, "hello"), ErrSignatureMismatch},
+		{"tampered value", strings.Replace(signed, "hello", "hellO", 1), ErrSignatureMismatch},
+		{"not base64", "hello:not*base64!", ErrMalformedSignature},
+		{"truncated", signed[:len(signed)-4], ErrMalformedSignature},
+		{"digest of another algorithm", sign(sha1Signer, "hello"), ErrMalformedSignature},
+		{"empty signature", "hello:", ErrMalformedSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signer.Unsign(tt.signed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unsign() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Unsign() error = %v, want it to wrap ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	// Legacy sha1 digests count as well-formed when LegacyAlgorithm is set
+	legacy := *signer
+	legacy.LegacyAlgorithm = "sha1"
+	if _, err := legacy.Unsign(sign(sha1Signer, "hello")); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("Unsign(legacy digest) error = %v, want ErrSignatureMismatch", err)
+	}
+
+	// Without the diagnostic mode the plain sentinel is returned
+	plain := *signer
+	plain.DiagnoseSignatures = false
+	if _, err := plain.Unsign(sign(wrongKey, "hello")); err != ErrInvalidSignature {
+		t.Errorf("Unsign() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
 func TestConstantTimeCompare(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -252,6 +522,194 @@ func TestUnsignObject_Compressed(t *testing.T) {
 	}
 }
 
+func TestUnsignObject_CompressionFormats(t *testing.T) {
+	signer := &DjangoSigner{
+		SecretKey: "your-secret-key-here-change-in-production",
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+	jsonData := []byte(`{"_auth_user_id":"123","cart":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}`)
+
+	zlibCompress := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+	deflateCompress := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name       string
+		compressed []byte
+		wantErr    bool
+	}{
+		{"zlib", zlibCompress(jsonData), false},
+		{"raw deflate", deflateCompress(jsonData), false},
+		{"garbage", []byte("definitely not compressed"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed := mustSignTimestamp(t, signer, "."+b64Encode(tt.compressed))
+
+			result, err := signer.UnsignObject(signed, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("UnsignObject() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnsignObject() error = %v", err)
+			}
+			if result["_auth_user_id"] != "123" {
+				t.Errorf("_auth_user_id = %v, want 123", result["_auth_user_id"])
+			}
+		})
+	}
+}
+
+func TestUnsignObject_SizeLimits(t *testing.T) {
+	newSigner := func(limit int64) *DjangoSigner {
+		return &DjangoSigner{
+			SecretKey:           "your-secret-key-here-change-in-production",
+			Salt:                "django.contrib.sessions.SessionStore",
+			Sep:                 ":",
+			Algorithm:           "sha256",
+			MaxDecompressedSize: limit,
+		}
+	}
+
+	// 8 MiB of JSON that compresses to a few kilobytes
+	bomb := append(append([]byte(`{"_auth_user_id":"1","pad":"`), bytes.Repeat([]byte("0"), 8<<20)...), '"', '}')
+	var zlibBomb, deflateBomb bytes.Buffer
+	zw := zlib.NewWriter(&zlibBomb)
+	zw.Write(bomb)
+	zw.Close()
+	fw, _ := flate.NewWriter(&deflateBomb, flate.BestCompression)
+	fw.Write(bomb)
+	fw.Close()
+
+	tests := []struct {
+		name    string
+		limit   int64
+		payload string
+		wantErr bool
+	}{
+		{"zlib bomb over default limit", 0, "." + b64Encode(zlibBomb.Bytes()), true},
+		{"raw deflate bomb over default limit", 0, "." + b64Encode(deflateBomb.Bytes()), true},
+		{"zlib bomb within raised limit", 16 << 20, "." + b64Encode(zlibBomb.Bytes()), false},
+		{"input longer than limit", 64, b64Encode([]byte(`{"_auth_user_id":"1","pad":"` + strings.Repeat("x", 64) + `"}`)), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := newSigner(tt.limit)
+			signed := mustSignTimestamp(t, signer, tt.payload)
+
+			result, err := signer.UnsignObject(signed, nil)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPayloadTooLarge) {
+					t.Errorf("UnsignObject() error = %v, want ErrPayloadTooLarge", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnsignObject() error = %v", err)
+			}
+			if result["_auth_user_id"] != "1" {
+				t.Errorf("_auth_user_id = %v, want 1", result["_auth_user_id"])
+			}
+		})
+	}
+}
+
+func TestUnsignObject_StandardBase64(t *testing.T) {
+	jsonData := []byte(`{"_auth_user_id":"1","q":"???~~~"}`)
+	std := strings.TrimRight(base64.StdEncoding.EncodeToString(jsonData), "=")
+	if !strings.ContainsAny(std, "+/") {
+		t.Fatalf("test payload %q must contain standard-only characters", std)
+	}
+
+	newSigner := func(allowStd bool) *DjangoSigner {
+		return &DjangoSigner{
+			SecretKey:           "test-secret",
+			Salt:                "django.contrib.sessions.SessionStore",
+			Sep:                 ":",
+			Algorithm:           "sha256",
+			AllowStandardBase64: allowStd,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		allowStd bool
+		payload  string
+		wantErr  bool
+	}{
+		{"url-safe strict", false, b64Encode(jsonData), false},
+		{"url-safe with fallback", true, b64Encode(jsonData), false},
+		{"standard strict", false, std, true},
+		{"standard with fallback", true, std, false},
+		{"mixed alphabets", true, strings.Replace(std, "/", "_", 1) + "+-", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := newSigner(tt.allowStd)
+			result, err := signer.UnsignObject(mustSignTimestamp(t, signer, tt.payload), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("UnsignObject() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnsignObject() error = %v", err)
+			}
+			if result["q"] != "???~~~" {
+				t.Errorf("q = %v, want ???~~~", result["q"])
+			}
+		})
+	}
+}
+
+func TestUnsignObject_TruncatedCompression(t *testing.T) {
+	signer := &DjangoSigner{
+		SecretKey: "your-secret-key-here-change-in-production",
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte(`{"_auth_user_id":"123","cart":"` + strings.Repeat("item,", 100) + `"}`))
+	w.Close()
+	compressed := buf.Bytes()
+
+	for _, cut := range []int{len(compressed) / 2, len(compressed) - 4, 3} {
+		// Signed correctly, so only the truncated stream is at fault
+		signed := mustSignTimestamp(t, signer, "."+b64Encode(compressed[:cut]))
+
+		_, err := signer.UnsignObject(signed, nil)
+		if !errors.Is(err, ErrCorruptSession) {
+			t.Errorf("cut at %d: UnsignObject() error = %v, want ErrCorruptSession", cut, err)
+		}
+		if errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("cut at %d: truncated payload must not be reported as a bad signature", cut)
+		}
+	}
+}
+
 func TestDecodeSessionData_InvalidData(t *testing.T) {
 	secretKey := "your-secret-key-here-change-in-production"
 
@@ -328,6 +786,149 @@ func TestB64EncodeDecode(t *testing.T) {
 	}
 }
 
+func TestBase62PublicHelpers(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       int64
+		encoded string
+	}{
+		{"zero", 0, "0"},
+		{"single digit", 61, "z"},
+		{"carry", 62, "10"},
+		{"negative", -62, "-10"},
+		{"negative single digit", -5, "-5"},
+		{"timestamp", 1768739851, "1vhS27"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Base62Encode(tt.n); got != tt.encoded {
+				t.Errorf("Base62Encode(%d) = %v, want %v", tt.n, got, tt.encoded)
+			}
+			got, err := Base62Decode(tt.encoded)
+			if err != nil {
+				t.Fatalf("Base62Decode(%q) error = %v", tt.encoded, err)
+			}
+			if got != tt.n {
+				t.Errorf("Base62Decode(%q) = %v, want %v", tt.encoded, got, tt.n)
+			}
+		})
+	}
+
+	if _, err := Base62Decode("abc!"); err == nil {
+		t.Error("Base62Decode() expected error for invalid character")
+	}
+}
+
+// b62EncodeConcat is the original string-concatenation encoder, kept as a reference
+// for the buffer-based b62Encode
+func b62EncodeConcat(n int64) string {
+	if n == 0 {
+		return "0"
+	}
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	encoded := ""
+	for n > 0 {
+		encoded = string(base62Alphabet[n%62]) + encoded
+		n = n / 62
+	}
+	return sign + encoded
+}
+
+func TestB62RoundTrip(t *testing.T) {
+	values := []int64{0, 1, -1, 61, 62, -62, 3843, 3844, 1768739851, math.MaxInt64, math.MaxInt64 - 1, math.MinInt64 + 1, math.MinInt64}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		// Spread magnitudes across the whole int64 range, not just large values
+		n := rng.Int63() >> uint(rng.Intn(63))
+		if rng.Intn(2) == 0 {
+			n = -n
+		}
+		values = append(values, n)
+	}
+
+	for _, n := range values {
+		encoded := b62Encode(n)
+		decoded, err := b62Decode(encoded)
+		if err != nil {
+			t.Fatalf("b62Decode(%q) error = %v", encoded, err)
+		}
+		if decoded != n {
+			t.Fatalf("b62Decode(b62Encode(%d)) = %d (encoded %q)", n, decoded, encoded)
+		}
+		if n != math.MinInt64 {
+			if want := b62EncodeConcat(n); encoded != want {
+				t.Fatalf("b62Encode(%d) = %q, want %q", n, encoded, want)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"abc!", "1-2", "é"} {
+		if _, err := b62Decode(invalid); err == nil {
+			t.Errorf("b62Decode(%q) expected error", invalid)
+		}
+	}
+}
+
+func BenchmarkB62Encode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b62Encode(1768739851)
+	}
+}
+
+func BenchmarkB62EncodeConcat(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b62EncodeConcat(1768739851)
+	}
+}
+
+func BenchmarkB62Decode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b62Decode("1vhS27")
+	}
+}
+
+func TestBase64URLPublicHelpers(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		encoded string
+	}{
+		{"empty", []byte{}, ""},
+		{"two padding chars stripped", []byte("H"), "SA"},
+		{"one padding char stripped", []byte("Hi"), "SGk"},
+		{"no padding", []byte("Hi!"), "SGkh"},
+		{"url-safe alphabet", []byte{0xfb, 0xff}, "-_8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Base64URLEncode(tt.input); got != tt.encoded {
+				t.Errorf("Base64URLEncode() = %v, want %v", got, tt.encoded)
+			}
+			got, err := Base64URLDecode(tt.encoded)
+			if err != nil {
+				t.Fatalf("Base64URLDecode(%q) error = %v", tt.encoded, err)
+			}
+			if string(got) != string(tt.input) {
+				t.Errorf("Base64URLDecode(%q) = %v, want %v", tt.encoded, got, tt.input)
+			}
+		})
+	}
+
+	// Already-padded input is accepted as well
+	if got, err := Base64URLDecode("SGk="); err != nil || string(got) != "Hi" {
+		t.Errorf("Base64URLDecode(padded) = %q, %v", got, err)
+	}
+}
+
 func TestDecodeSessionDataWithMaxAge(t *testing.T) {
 	secretKey := "your-secret-key-here-change-in-production"
 
@@ -411,6 +1012,19 @@ func TestUnsignTimestampExpiry(t *testing.T) {
 	if err == nil {
 		t.Error("UnsignTimestamp() should fail for old timestamp with short max age")
 	}
+	var expired *ErrExpired
+	if !errors.As(err, &expired) {
+		t.Fatalf("UnsignTimestamp() error = %T, want *ErrExpired", err)
+	}
+	if expired.Age() < 2*time.Hour || expired.Age() > 2*time.Hour+time.Minute || expired.MaxAge() != maxAge {
+		t.Errorf("ErrExpired Age() = %v, MaxAge() = %v, want about 2h and 1h", expired.Age(), expired.MaxAge())
+	}
+	if !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("UnsignTimestamp() error = %v, want it to match ErrSessionExpired", err)
+	}
+	if errors.Is(err, ErrInvalidSignature) {
+		t.Error("Expected an expired signature not to match ErrInvalidSignature")
+	}
 
 	// Test with long max age (should pass)
 	maxAge = 3 * time.Hour
@@ -508,6 +1122,79 @@ func TestSignObject(t *testing.T) {
 	}
 }
 
+func TestSignRawJSON(t *testing.T) {
+	signer := &DjangoSigner{
+		SecretKey: "your-secret-key-here-change-in-production",
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+
+	// payload returns the base64 segment of a signed token (before the timestamp)
+	payload := func(signed string) string {
+		return strings.Split(signed, ":")[0]
+	}
+
+	t.Run("matches SignObject for sorted keys", func(t *testing.T) {
+		obj := map[string]interface{}{
+			"_auth_user_id": "123",
+			"cart":          strings.Repeat("item,", 20),
+		}
+		jsonData, _ := json.Marshal(obj)
+
+		for _, compress := range []bool{false, true} {
+			fromObject, err := signer.SignObject(obj, compress)
+			if err != nil {
+				t.Fatalf("SignObject() error = %v", err)
+			}
+			fromRaw, err := signer.SignRawJSON(jsonData, compress)
+			if err != nil {
+				t.Fatalf("SignRawJSON() error = %v", err)
+			}
+			if payload(fromRaw) != payload(fromObject) {
+				t.Errorf("compress=%v: SignRawJSON payload %q != SignObject payload %q", compress, payload(fromRaw), payload(fromObject))
+			}
+		}
+	})
+
+	t.Run("preserves key order", func(t *testing.T) {
+		jsonData := []byte(`{"zeta":1,"_auth_user_id":"123","alpha":2}`)
+
+		signed, err := signer.SignRawJSON(jsonData, false)
+		if err != nil {
+			t.Fatalf("SignRawJSON() error = %v", err)
+		}
+
+		value, err := signer.UnsignTimestamp(signed, nil)
+		if err != nil {
+			t.Fatalf("UnsignTimestamp() error = %v", err)
+		}
+		decoded, err := b64Decode(value)
+		if err != nil {
+			t.Fatalf("b64Decode() error = %v", err)
+		}
+		if string(decoded) != string(jsonData) {
+			t.Errorf("Payload = %s, want %s", decoded, jsonData)
+		}
+	})
+
+	t.Run("skips compression when not beneficial", func(t *testing.T) {
+		signed, err := signer.SignRawJSON([]byte(`{"a":1}`), true)
+		if err != nil {
+			t.Fatalf("SignRawJSON() error = %v", err)
+		}
+		if strings.HasPrefix(signed, ".") {
+			t.Errorf("Expected uncompressed token for tiny payload, got %s", signed)
+		}
+	})
+
+	t.Run("rejects invalid JSON", func(t *testing.T) {
+		if _, err := signer.SignRawJSON([]byte(`{not json`), false); err == nil {
+			t.Error("SignRawJSON() expected error for invalid JSON")
+		}
+	})
+}
+
 func TestEncodeSessionData(t *testing.T) {
 	secretKey := "your-secret-key-here-change-in-production"
 
@@ -731,3 +1418,531 @@ func TestRoundTripEncodeDecodeSession(t *testing.T) {
 
 	t.Logf("Round-trip test successful. Decoded data: %+v", decoded)
 }
+
+func TestMaxAgeSecondsAndDurationAgree(t *testing.T) {
+	secretKey := "test-secret"
+	signer := &DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+
+	// Signed two hours ago
+	value := b64Encode([]byte(`{"_auth_user_id":"42"}`)) + ":" + b62Encode(time.Now().Add(-2*time.Hour).Unix())
+	sessionData := value + ":" + signer.signature(value)
+
+	client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey})
+
+	tests := []struct {
+		name    string
+		seconds int
+		wantErr bool
+	}{
+		{"zero disables check", 0, false},
+		{"negative disables check", -5, false},
+		{"too old", 3600, true},
+		{"within limit", 3 * 3600, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duration := time.Duration(tt.seconds) * time.Second
+
+			_, errSeconds := DecodeSessionDataWithMaxAge(sessionData, secretKey, tt.seconds)
+			_, errDuration := DecodeSessionDataMaxAge(sessionData, secretKey, duration)
+			_, errSaltSeconds := DecodeSessionDataWithSalt(sessionData, secretKey, "django.contrib.sessions.SessionStore", tt.seconds)
+			_, errSaltDuration := DecodeSessionDataWithSaltMaxAge(sessionData, secretKey, "django.contrib.sessions.SessionStore", duration)
+			_, errClient := client.DecodeSessionUserIDMaxAge(sessionData, duration)
+
+			for name, err := range map[string]error{
+				"DecodeSessionDataWithMaxAge":      errSeconds,
+				"DecodeSessionDataMaxAge":          errDuration,
+				"DecodeSessionDataWithSalt":        errSaltSeconds,
+				"DecodeSessionDataWithSaltMaxAge":  errSaltDuration,
+				"Client.DecodeSessionUserIDMaxAge": errClient,
+			} {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s error = %v, wantErr %v", name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestSignOrderedObject(t *testing.T) {
+	signer := &DjangoSigner{
+		SecretKey: "your-secret-key-here-change-in-production",
+		Salt:      "django.contrib.sessions.SessionStore",
+		Sep:       ":",
+		Algorithm: "sha256",
+	}
+
+	fields := []KeyValue{
+		{"_auth_user_id", "42"},
+		{"_auth_user_backend", "django.contrib.auth.backends.ModelBackend"},
+		{"_auth_user_hash", "5f2b8c"},
+		{"note", "café <b>&😀"},
+		{"cart", []interface{}{1, 2.5, nil, true}},
+	}
+
+	// Payload segment of Django's signing.dumps() for the same dict, built in this order:
+	// base64(json.dumps(obj, separators=(",", ":")))
+	const djangoPayload = "eyJfYXV0aF91c2VyX2lkIjoiNDIiLCJfYXV0aF91c2VyX2JhY2tlbmQiOiJkamFuZ28uY29udHJpYi5hdXRoLmJhY2tlbmRzLk1vZGVsQmFja2VuZCIsIl9hdXRoX3VzZXJfaGFzaCI6IjVmMmI4YyIsIm5vdGUiOiJjYWZcdTAwZTkgPGI-Jlx1ZDgzZFx1ZGUwMCIsImNhcnQiOlsxLDIuNSxudWxsLHRydWVdfQ"
+
+	signed, err := signer.SignOrderedObject(fields, false)
+	if err != nil {
+		t.Fatalf("SignOrderedObject() error = %v", err)
+	}
+	if payload := strings.Split(signed, ":")[0]; payload != djangoPayload {
+		t.Errorf("SignOrderedObject() payload = %q, want %q", payload, djangoPayload)
+	}
+
+	data, err := signer.UnsignObject(signed, nil)
+	if err != nil {
+		t.Fatalf("UnsignObject() error = %v", err)
+	}
+	if data["note"] != "café <b>&😀" || data["_auth_user_id"] != "42" {
+		t.Errorf("UnsignObject() = %v", data)
+	}
+
+	// Small payloads are left uncompressed, as Django only keeps smaller output
+	compressed, err := signer.SignOrderedObject(fields[:1], true)
+	if err != nil {
+		t.Fatalf("SignOrderedObject() error = %v", err)
+	}
+	if strings.HasPrefix(compressed, ".") {
+		t.Errorf("Expected short payload to stay uncompressed, got %q", compressed)
+	}
+}
+
+func TestMarshalOrderedPythonParity(t *testing.T) {
+	fields := []KeyValue{
+		{"a", 1.0},
+		{"b", []interface{}{0.0, math.Copysign(0, -1), 1e16, 1e15, 0.0001, 0.00001, 1.5e300, 123.456, float32(0.1), -2.5e-7}},
+		{"c", map[string]interface{}{"x": 2.0, "y": []float64{3}}},
+		{"n", map[int]float64{1: 1}},
+		{"d", "del\x7f"},
+		{"i", 7},
+	}
+
+	// json.dumps(obj, separators=(",", ":")) in Python for the same dict
+	const want = `{"a":1.0,"b":[0.0,-0.0,1e+16,1000000000000000.0,0.0001,1e-05,1.5e+300,123.456,0.1,-2.5e-07],"c":{"x":2.0,"y":[3.0]},"n":{"1":1.0},"d":"del\u007f","i":7}`
+
+	got, err := marshalOrdered(fields)
+	if err != nil {
+		t.Fatalf("marshalOrdered() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("marshalOrdered() = %s, want %s", got, want)
+	}
+}
+
+func TestEncodeSessionDataValueTypes(t *testing.T) {
+	secretKey := "test-secret"
+	type cartItem struct {
+		SKU      string `json:"sku"`
+		Quantity int    `json:"qty"`
+		internal chan int
+	}
+	stamp := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	supported := map[string]interface{}{
+		"nil":     nil,
+		"bool":    true,
+		"string":  "value",
+		"int":     7,
+		"uint8":   uint8(3),
+		"float":   1.5,
+		"number":  json.Number("12345678901234567890"),
+		"list":    []interface{}{"a", 1, nil},
+		"strings": []string{"x", "y"},
+		"map":     map[string]interface{}{"nested": []int{1, 2}},
+		"intkeys": map[int]string{1: "one"},
+		"struct":  cartItem{SKU: "A1", Quantity: 2},
+		"pointer": &cartItem{SKU: "B2"},
+		"time":    stamp,
+		"bytes":   []byte("raw"),
+	}
+	for key, value := range supported {
+		t.Run("supported "+key, func(t *testing.T) {
+			if _, err := EncodeSessionData("42", secretKey, map[string]interface{}{key: value}); err != nil {
+				t.Errorf("EncodeSessionData(%T) error = %v", value, err)
+			}
+		})
+	}
+
+	sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"login_at": stamp})
+	decoded, _ := DecodeSessionData(sessionData, secretKey)
+	if decoded != "42" {
+		t.Errorf("Expected round trip of session with time value, got %q", decoded)
+	}
+
+	unsupported := map[string]struct {
+		value   interface{}
+		errPath string
+	}{
+		"channel":        {make(chan int), `"channel"`},
+		"func":           {func() {}, `"func"`},
+		"complex":        {complex(1, 2), `"complex"`},
+		"nan":            {math.NaN(), `"nan"`},
+		"infinity":       {math.Inf(1), `"infinity"`},
+		"nested in list": {[]interface{}{1, func() {}}, `"nested in list[1]"`},
+		"nested in map":  {map[string]interface{}{"cb": make(chan bool)}, `"nested in map.cb"`},
+		"struct key map": {map[cartItem]int{}, `unsupported map key type`},
+	}
+	for name, tt := range unsupported {
+		t.Run("unsupported "+name, func(t *testing.T) {
+			_, err := EncodeSessionData("42", secretKey, map[string]interface{}{name: tt.value})
+			if err == nil || !strings.Contains(err.Error(), tt.errPath) {
+				t.Errorf("EncodeSessionData(%T) error = %v, want mention of %s", tt.value, err, tt.errPath)
+			}
+		})
+	}
+
+	selfRef := map[string]interface{}{}
+	selfRef["self"] = selfRef
+	if _, err := EncodeSessionData("42", secretKey, map[string]interface{}{"loop": selfRef}); err == nil || !strings.Contains(err.Error(), "nested too deeply") {
+		t.Errorf("EncodeSessionData(self-referencing map) error = %v", err)
+	}
+}
+
+func TestReSignObject(t *testing.T) {
+	signer := &DjangoSigner{SecretKey: "test-secret", Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+	jsonData := []byte(`{"zeta":1,"_auth_user_id":"42","cart":"` + strings.Repeat("item,", 20) + `"}`)
+
+	// signedAt builds a token for jsonData as if it had been signed at the given time
+	signedAt := func(at time.Time, compress bool) string {
+		fresh, err := signer.SignRawJSON(jsonData, compress)
+		if err != nil {
+			t.Fatalf("SignRawJSON() error = %v", err)
+		}
+		value := strings.Split(fresh, ":")[0] + ":" + b62Encode(at.Unix())
+		return value + ":" + signer.signature(value)
+	}
+	timestamp := func(token string) int64 {
+		ts, err := b62Decode(strings.Split(token, ":")[1])
+		if err != nil {
+			t.Fatalf("b62Decode() error = %v", err)
+		}
+		return ts
+	}
+
+	for _, compress := range []bool{false, true} {
+		old := signedAt(time.Now().Add(-10*time.Minute), compress)
+		maxAge := time.Hour
+
+		renewed, err := signer.ReSignObject(old, &maxAge, compress)
+		if err != nil {
+			t.Fatalf("ReSignObject() error = %v", err)
+		}
+		if timestamp(renewed) <= timestamp(old) {
+			t.Errorf("compress=%v: timestamp did not advance: %d -> %d", compress, timestamp(old), timestamp(renewed))
+		}
+
+		before, _ := signer.unsignJSON(old, nil)
+		after, err := signer.unsignJSON(renewed, &maxAge)
+		if err != nil || !bytes.Equal(before, after) {
+			t.Errorf("compress=%v: payload changed: %s -> %s (%v)", compress, before, after, err)
+		}
+	}
+
+	tooOld := time.Minute
+	if _, err := signer.ReSignObject(signedAt(time.Now().Add(-time.Hour), false), &tooOld, false); err == nil {
+		t.Error("ReSignObject() expected error for expired token")
+	}
+	if _, err := signer.ReSignObject("tampered:abc:def", nil, false); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ReSignObject() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// rawCodec encodes with encoding/json but decodes strings byte for byte, like codecs that
+// do not sanitize invalid UTF-8
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*map[string]interface{})) = map[string]interface{}{"_auth_user_id": "42", "name": "bad\xff"}
+	return nil
+}
+
+func TestUnsignObjectValidateUTF8(t *testing.T) {
+	newSigner := func(validate bool, codec JSONCodec) *DjangoSigner {
+		return &DjangoSigner{SecretKey: "test-secret", Salt: "s", Sep: ":", Algorithm: "sha256", ValidateUTF8: validate, Codec: codec}
+	}
+
+	// Invalid byte sequences smuggled into JSON string values
+	smuggled := [][]byte{
+		[]byte("{\"_auth_user_id\":\"42\",\"name\":\"bad\xff\xfe\"}"),
+		[]byte("{\"_auth_user_id\":\"42\",\"tags\":[\"ok\",\"\xc3\x28\"]}"),
+		[]byte("{\"_auth_user_id\":\"42\",\"\xed\xa0\x80\":\"surrogate key\"}"),
+	}
+	for i, payload := range smuggled {
+		for _, compress := range []bool{false, true} {
+			signed, err := newSigner(false, nil).signJSON(payload, compress, false)
+			if err != nil {
+				t.Fatalf("signJSON() error = %v", err)
+			}
+
+			if _, err := newSigner(false, nil).UnsignObject(signed, nil); err != nil {
+				t.Errorf("payload %d: UnsignObject() without ValidateUTF8 error = %v", i, err)
+			}
+			if _, err := newSigner(true, nil).UnsignObject(signed, nil); !errors.Is(err, ErrInvalidEncoding) {
+				t.Errorf("payload %d: UnsignObject() error = %v, want ErrInvalidEncoding", i, err)
+			}
+		}
+	}
+
+	valid, _ := newSigner(true, nil).SignObject(map[string]interface{}{"_auth_user_id": "42", "name": "Zoë 😀"}, false)
+	if _, err := newSigner(true, nil).UnsignObject(valid, nil); err != nil {
+		t.Errorf("UnsignObject(valid UTF-8) error = %v", err)
+	}
+
+	// Decoded strings are checked too, for codecs that pass invalid bytes through
+	if _, err := newSigner(true, rawCodec{}).UnsignObject(valid, nil); !errors.Is(err, ErrInvalidEncoding) || !strings.Contains(err.Error(), `"name"`) {
+		t.Errorf("UnsignObject() with raw codec error = %v, want ErrInvalidEncoding for name", err)
+	}
+}
+
+func TestUnsignRejectsMalformedTokens(t *testing.T) {
+	signer := fuzzSigner()
+	sign := func(value string) string { return value + signer.Sep + signer.signature(value) }
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty timestamp", sign(b64Encode([]byte(`{"a":1}`)) + ":")},
+		{"sign only timestamp", sign(b64Encode([]byte(`{"a":1}`)) + ":-")},
+		{"null payload", mustSignTimestamp(t, signer, b64Encode([]byte("null")))},
+		{"array payload", mustSignTimestamp(t, signer, b64Encode([]byte("[1,2]")))},
+		{"compressed null payload", mustSignTimestamp(t, signer, "."+b64Encode(zlibCompress([]byte("null"))))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := signer.UnsignObject(tt.token, nil)
+			if err == nil || result != nil {
+				t.Errorf("UnsignObject() = %v, %v, want error", result, err)
+			}
+		})
+	}
+}
+
+// mustSignTimestamp returns signer.SignTimestamp(value), failing tb on error
+func mustSignTimestamp(tb testing.TB, signer *DjangoSigner, value string) string {
+	tb.Helper()
+	signed, err := signer.SignTimestamp(value)
+	if err != nil {
+		tb.Fatalf("SignTimestamp(%q) error = %v", value, err)
+	}
+	return signed
+}
+
+// zlibCompress compresses data with zlib at the default level
+func zlibCompress(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestSignObjectCompressionLevel(t *testing.T) {
+	obj := map[string]interface{}{"_auth_user_id": "42", "cart": strings.Repeat("item-", 500)}
+
+	signed := map[int]string{}
+	for _, level := range []int{zlib.BestSpeed, zlib.BestCompression, zlib.HuffmanOnly} {
+		signer := NewDjangoSigner("test-secret")
+		signer.CompressionLevel = level
+
+		token, err := signer.SignObject(obj, true)
+		if err != nil {
+			t.Fatalf("SignObject(level %d) error = %v", level, err)
+		}
+		if !strings.HasPrefix(token, ".") {
+			t.Errorf("SignObject(level %d) = %q, want compressed payload", level, token)
+		}
+
+		// Decoding is level-agnostic: a signer with the default level reads it
+		decoded, err := NewDjangoSigner("test-secret").UnsignObject(token, nil)
+		if err != nil {
+			t.Fatalf("UnsignObject(level %d) error = %v", level, err)
+		}
+		if decoded["cart"] != obj["cart"] {
+			t.Errorf("UnsignObject(level %d) returned a different payload", level)
+		}
+		signed[level] = token
+	}
+	if len(signed[zlib.BestCompression]) > len(signed[zlib.HuffmanOnly]) {
+		t.Errorf("Expected level 9 output (%d bytes) to be no larger than Huffman-only (%d bytes)",
+			len(signed[zlib.BestCompression]), len(signed[zlib.HuffmanOnly]))
+	}
+
+	for _, level := range []int{-3, 10} {
+		signer := NewDjangoSigner("test-secret")
+		signer.CompressionLevel = level
+		if _, err := signer.SignObject(obj, true); err == nil {
+			t.Errorf("SignObject(level %d) expected error", level)
+		}
+		if _, err := signer.SignObject(obj, false); err != nil {
+			t.Errorf("SignObject(level %d, uncompressed) error = %v", level, err)
+		}
+		if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", CompressionLevel: level}); err == nil {
+			t.Errorf("NewClient(CompressionLevel: %d) expected error", level)
+		}
+	}
+}
+
+func TestSignObjectCompressionPrefix(t *testing.T) {
+	obj := map[string]interface{}{"_auth_user_id": "42", "cart": strings.Repeat("item-", 100)}
+
+	signer := NewDjangoSigner("test-secret")
+	signer.CompressionPrefix = "!"
+
+	token, err := signer.SignObject(obj, true)
+	if err != nil {
+		t.Fatalf("SignObject() error = %v", err)
+	}
+	if !strings.HasPrefix(token, "!") {
+		t.Errorf("SignObject() = %q, want the custom prefix", token)
+	}
+	decoded, err := signer.UnsignObject(token, nil)
+	if err != nil || decoded["cart"] != obj["cart"] {
+		t.Errorf("UnsignObject() = %v, %v", decoded, err)
+	}
+
+	// Uncompressed payloads are unaffected
+	plain, _ := signer.SignObject(obj, false)
+	if decoded, err := signer.UnsignObject(plain, nil); err != nil || decoded["_auth_user_id"] != "42" {
+		t.Errorf("UnsignObject(uncompressed) = %v, %v", decoded, err)
+	}
+
+	// A Django signer does not recognize the custom marker, and vice versa
+	django := NewDjangoSigner("test-secret")
+	if _, err := django.UnsignObject(token, nil); err == nil {
+		t.Error("UnsignObject() with default prefix expected error")
+	}
+	djangoToken, _ := django.SignObject(obj, true)
+	if _, err := signer.UnsignObject(djangoToken, nil); err == nil {
+		t.Error("UnsignObject() of a '.' payload with custom prefix expected error")
+	}
+
+	for _, prefix := range []string{"a", "Z", "0", "-", "_", "+", "/", "=", ":", "!!", " ", "é"} {
+		invalid := NewDjangoSigner("test-secret")
+		invalid.CompressionPrefix = prefix
+		if _, err := invalid.SignObject(obj, true); err == nil {
+			t.Errorf("SignObject(prefix %q) expected error", prefix)
+		}
+		if _, err := invalid.UnsignObject(djangoToken, nil); err == nil {
+			t.Errorf("UnsignObject(prefix %q) expected error", prefix)
+		}
+	}
+
+	client, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", CompressionPrefix: "!"})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	sessionData, _ := client.signer.SignObject(obj, true)
+	if userID, err := client.DecodeSessionUserID(sessionData); err != nil || userID != "42" {
+		t.Errorf("DecodeSessionUserID() = %q, %v", userID, err)
+	}
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", CompressionPrefix: "x"}); err == nil {
+		t.Error("NewClient(CompressionPrefix: x) expected error")
+	}
+}
+
+// fuzzSigner is the signer used by the fuzz targets
+func fuzzSigner() *DjangoSigner {
+	return &DjangoSigner{SecretKey: "fuzz-secret", Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha256"}
+}
+
+// addTokenSeeds seeds f with valid tokens and every truncation of them
+func addTokenSeeds(f *testing.F, tokens ...string) {
+	for _, token := range tokens {
+		for i := 0; i <= len(token); i++ {
+			f.Add([]byte(token[:i]))
+		}
+	}
+	f.Add([]byte("::"))
+	f.Add([]byte(".:"))
+	f.Add([]byte("-:"))
+}
+
+func FuzzUnsign(f *testing.F) {
+	signer := fuzzSigner()
+	addTokenSeeds(f, mustSignTimestamp(f, signer, "hello"), mustSignTimestamp(f, signer, ""), "value:"+signer.signature("value"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		input := string(data)
+		value, err := signer.Unsign(input)
+		if err != nil {
+			if value != "" {
+				t.Fatalf("Unsign(%q) = %q with error %v", input, value, err)
+			}
+			return
+		}
+		if input != value+signer.Sep+signer.signature(value) {
+			t.Fatalf("Unsign(%q) accepted a value without its signature: %q", input, value)
+		}
+
+		value, signedAt, err := signer.unsignTimestamp(input)
+		if err != nil {
+			if value != "" || !signedAt.IsZero() {
+				t.Fatalf("unsignTimestamp(%q) = %q, %v with error %v", input, value, signedAt, err)
+			}
+			return
+		}
+		if signed, err := signer.SignTimestamp(value); err != nil || signed == "" {
+			t.Fatalf("unsignTimestamp(%q) returned unsignable value", input)
+		}
+	})
+}
+
+func FuzzUnsignObject(f *testing.F) {
+	signer := fuzzSigner()
+	compressed, _ := signer.SignObject(map[string]interface{}{"_auth_user_id": "42", "padding": strings.Repeat("x", 200)}, true)
+	plain, _ := signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, false)
+	addTokenSeeds(f, compressed, plain)
+	f.Add([]byte("null"))
+	f.Add([]byte("[]"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		check := func(input string) {
+			result, err := signer.UnsignObject(input, nil)
+			if err != nil {
+				if result != nil {
+					t.Fatalf("UnsignObject(%q) = %v with error %v", input, result, err)
+				}
+				return
+			}
+			if result == nil {
+				t.Fatalf("UnsignObject(%q) returned a nil payload without error", input)
+			}
+		}
+
+		// Raw input rarely carries a valid signature, so also sign it to reach the
+		// base64, zlib and JSON decoding stages with arbitrary payloads
+		check(string(data))
+		check(mustSignTimestamp(t, signer, string(data)))
+		check(mustSignTimestamp(t, signer, "."+b64Encode(data)))
+		check(mustSignTimestamp(t, signer, b64Encode(data)))
+	})
+}
+
+func TestDjangoSignerNow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	signer := NewDjangoSigner("test-secret")
+	signer.Now = clock.Now
+
+	signed := mustSignTimestamp(t, signer, "hello")
+	if signed != "hello:"+b62Encode(1700000000)+":"+signer.signature("hello:"+b62Encode(1700000000)) {
+		t.Errorf("SignTimestamp() = %q, want the fake clock's timestamp", signed)
+	}
+
+	maxAge := time.Minute
+	clock.Advance(time.Minute)
+	if _, err := signer.UnsignTimestamp(signed, &maxAge); err != nil {
+		t.Errorf("UnsignTimestamp() at max age error = %v", err)
+	}
+	clock.Advance(time.Second)
+	if _, err := signer.UnsignTimestamp(signed, &maxAge); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("UnsignTimestamp() past max age error = %v, want ErrSessionExpired", err)
+	}
+}
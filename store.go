@@ -0,0 +1,154 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SessionStore retrieves validated raw sessions by session key. *Client implements it
+// by querying django_session directly.
+type SessionStore interface {
+	GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error)
+}
+
+// SessionCache is a cache of raw sessions, such as a Redis-backed implementation.
+// Get returns ErrSessionNotFound on a cache miss.
+type SessionCache interface {
+	Get(ctx context.Context, sessionKey string) (*RawSession, error)
+	Set(ctx context.Context, session *RawSession, ttl time.Duration) error
+}
+
+// SessionCacheDeleter is implemented by SessionCache implementations that can drop an
+// entry before its TTL runs out, which CachedDBStore.Invalidate requires
+type SessionCacheDeleter interface {
+	Delete(ctx context.Context, sessionKey string) error
+}
+
+// CachedDBStore mirrors Django's cached_db session backend: sessions are read from the
+// cache first and fall back to the database on a miss, populating the cache on a DB hit.
+type CachedDBStore struct {
+	cache SessionCache
+	db    SessionStore
+}
+
+// sessionChecker is a SessionStore that can check sessions it did not load itself, as *Client
+// does with CheckSession
+type sessionChecker interface {
+	CheckSession(ctx context.Context, session *RawSession) error
+}
+
+// clock is a SessionStore with its own notion of the current time, as *Client has with
+// ClientConfig.Now
+type clock interface {
+	Now() time.Time
+}
+
+// NewCachedDBStore creates a read-through store over cache and db (usually a *Client)
+func NewCachedDBStore(cache SessionCache, db SessionStore) *CachedDBStore {
+	return &CachedDBStore{cache: cache, db: db}
+}
+
+// GetRawSession returns the session from the cache, or from the database on a cache miss.
+// Cache errors are treated as misses so a cache outage degrades to plain DB reads.
+// Cache hits are still checked with the db's VerifySignature and RevocationChecker when it
+// is a *Client. Expiry and cache TTLs follow the db's clock (ClientConfig.Now) when it has one.
+func (s *CachedDBStore) GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error) {
+	session, err := s.cache.Get(ctx, sessionKey)
+	if err == nil && session != nil {
+		if s.now().After(session.ExpireDate) {
+			return nil, ErrSessionExpired
+		}
+		if checker, ok := s.db.(sessionChecker); ok {
+			if err := checker.CheckSession(ctx, session); err != nil {
+				return nil, err
+			}
+		}
+		return session, nil
+	}
+
+	session, err = s.db.GetRawSession(ctx, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Populate the cache until the session expires, like Django's cached_db backend.
+	// A failed write only costs a future cache miss.
+	_ = s.cache.Set(ctx, session, session.ExpireDate.Sub(s.now()))
+
+	return session, nil
+}
+
+// now returns the db's current time, falling back to the wall clock
+func (s *CachedDBStore) now() time.Time {
+	if c, ok := s.db.(clock); ok {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// Invalidate drops sessionKey from the cache so it stops being served once the database
+// row is gone, e.g. after CycleKey moved the session to a new key. Fails when the cache
+// does not implement SessionCacheDeleter.
+func (s *CachedDBStore) Invalidate(ctx context.Context, sessionKey string) error {
+	deleter, ok := s.cache.(SessionCacheDeleter)
+	if !ok {
+		return fmt.Errorf("session cache %T does not support Delete", s.cache)
+	}
+	return deleter.Delete(ctx, sessionKey)
+}
+
+// WarmCacheConcurrency is the number of sessions WarmCache loads at once
+const WarmCacheConcurrency = 8
+
+// WarmCache loads keys through the store, at most WarmCacheConcurrency at a time, so
+// sessions missing from the cache are read from the database and cached before traffic
+// arrives, e.g. after a deploy with keys of recently active sessions exported from Redis.
+// Unknown and expired keys are skipped. Other failures are joined into the returned
+// error; when ctx is canceled, the remaining keys are not loaded and ctx's error is included.
+func (s *CachedDBStore) WarmCache(ctx context.Context, keys []string) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	slots := make(chan struct{}, WarmCacheConcurrency)
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		// Check ctx first: select picks randomly when a slot is free as well
+		if ctx.Err() == nil {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			_, err := s.GetRawSession(ctx, key)
+			if err != nil && !errors.Is(err, ErrSessionNotFound) && !errors.Is(err, ErrSessionExpired) {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("warming session cache: %w", err))
+				mu.Unlock()
+			}
+		}(key)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
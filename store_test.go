@@ -0,0 +1,351 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryCache is an in-memory SessionCache used in tests
+type memoryCache struct {
+	mu       sync.Mutex
+	sessions map[string]*RawSession
+	ttls     map[string]time.Duration
+	err      error
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{
+		sessions: make(map[string]*RawSession),
+		ttls:     make(map[string]time.Duration),
+	}
+}
+
+func (m *memoryCache) Get(ctx context.Context, sessionKey string) (*RawSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	session, ok := m.sessions[sessionKey]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, session *RawSession, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sessions[session.SessionKey] = session
+	m.ttls[session.SessionKey] = ttl
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, sessionKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionKey)
+	return nil
+}
+
+// setOnlyCache is a SessionCache without Delete
+type setOnlyCache struct{ SessionCache }
+
+func TestCachedDBStore(t *testing.T) {
+	ctx := context.Background()
+	expireDate := time.Now().Add(time.Hour)
+
+	t.Run("cache hit skips database", func(t *testing.T) {
+		cache := newMemoryCache()
+		cache.Set(ctx, &RawSession{SessionKey: "abc", SessionData: "cached", ExpireDate: expireDate}, time.Hour)
+
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		store := NewCachedDBStore(cache, client)
+
+		session, err := store.GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionData != "cached" {
+			t.Errorf("SessionData = %v, want cached", session.SessionData)
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("expired cache entry", func(t *testing.T) {
+		cache := newMemoryCache()
+		cache.Set(ctx, &RawSession{SessionKey: "abc", ExpireDate: time.Now().Add(-time.Minute)}, time.Hour)
+
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+		store := NewCachedDBStore(cache, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("GetRawSession() error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("expiry and TTL follow the client clock", func(t *testing.T) {
+		// The session expires long before the wall clock's now, but not by the client's clock
+		clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+		cache := newMemoryCache()
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"abc"}).
+			Return(newMockRow("abc", "from-db", clock.now.Add(time.Hour)))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", Now: clock.Now})
+		store := NewCachedDBStore(cache, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if ttl := cache.ttls["abc"]; ttl != time.Hour {
+			t.Errorf("cache TTL = %v, want 1h", ttl)
+		}
+		if _, err := store.GetRawSession(ctx, "abc"); err != nil {
+			t.Errorf("GetRawSession() error = %v for a cache hit", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+
+		clock.Advance(2 * time.Hour)
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("GetRawSession() error = %v after the clock passed expiry, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("revoked cache entry", func(t *testing.T) {
+		cache := newMemoryCache()
+		cache.Set(ctx, &RawSession{SessionKey: "abc", SessionData: "cached", ExpireDate: expireDate}, time.Hour)
+
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{
+			DB:        db,
+			SecretKey: "test-secret",
+			RevocationChecker: func(ctx context.Context, sessionKey string) (bool, error) {
+				return sessionKey == "abc", nil
+			},
+		})
+		store := NewCachedDBStore(cache, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionRevoked) {
+			t.Errorf("GetRawSession() error = %v, want ErrSessionRevoked", err)
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("tampered cache entry with signature verification", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", "test-secret", nil)
+		cache := newMemoryCache()
+		cache.Set(ctx, &RawSession{SessionKey: "abc", SessionData: sessionData + "x", ExpireDate: expireDate}, time.Hour)
+		cache.Set(ctx, &RawSession{SessionKey: "def", SessionData: sessionData, ExpireDate: expireDate}, time.Hour)
+
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", VerifySignature: true})
+		store := NewCachedDBStore(cache, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("GetRawSession() error = %v, want ErrInvalidSignature", err)
+		}
+		if _, err := store.GetRawSession(ctx, "def"); err != nil {
+			t.Errorf("GetRawSession() error = %v for a valid cache entry", err)
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("cache miss then database hit populates cache", func(t *testing.T) {
+		cache := newMemoryCache()
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"abc"}).
+			Return(newMockRow("abc", "from-db", expireDate))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		store := NewCachedDBStore(cache, client)
+
+		session, err := store.GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionData != "from-db" {
+			t.Errorf("SessionData = %v, want from-db", session.SessionData)
+		}
+
+		cached, err := cache.Get(ctx, "abc")
+		if err != nil || cached.SessionData != "from-db" {
+			t.Errorf("Expected session to be cached, got %v, %v", cached, err)
+		}
+		if ttl := cache.ttls["abc"]; ttl <= 0 || ttl > time.Hour {
+			t.Errorf("Unexpected cache TTL %v", ttl)
+		}
+
+		// Second read is served from cache
+		if _, err := store.GetRawSession(ctx, "abc"); err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	t.Run("both miss", func(t *testing.T) {
+		cache := newMemoryCache()
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 3))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		store := NewCachedDBStore(cache, client)
+
+		if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GetRawSession() error = %v, want ErrSessionNotFound", err)
+		}
+		if len(cache.sessions) != 0 {
+			t.Error("Expected nothing to be cached")
+		}
+	})
+
+	t.Run("cache error falls back to database", func(t *testing.T) {
+		cache := newMemoryCache()
+		cache.err = errors.New("connection refused")
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newMockRow("abc", "from-db", expireDate))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+		store := NewCachedDBStore(cache, client)
+
+		session, err := store.GetRawSession(ctx, "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v", err)
+		}
+		if session.SessionData != "from-db" {
+			t.Errorf("SessionData = %v, want from-db", session.SessionData)
+		}
+	})
+}
+
+func TestCachedDBStoreInvalidate(t *testing.T) {
+	ctx := context.Background()
+	session := &RawSession{SessionKey: "abc", SessionData: "cached", ExpireDate: time.Now().Add(time.Hour)}
+
+	cache := newMemoryCache()
+	cache.Set(ctx, session, time.Hour)
+	db := &MockDBTX{}
+	db.On("QueryRow", mock.Anything, mock.Anything, []interface{}{"abc"}).Return(newErrorRow(pgx.ErrNoRows, 3))
+	client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+	store := NewCachedDBStore(cache, client)
+
+	if err := store.Invalidate(ctx, "abc"); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if _, err := store.GetRawSession(ctx, "abc"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("GetRawSession() after Invalidate error = %v, want ErrSessionNotFound", err)
+	}
+
+	withoutDelete := NewCachedDBStore(setOnlyCache{newMemoryCache()}, client)
+	if err := withoutDelete.Invalidate(ctx, "abc"); err == nil {
+		t.Error("Invalidate() error = nil, want error for a cache without Delete")
+	}
+}
+
+// slowStore is a SessionStore that tracks how many lookups run at once
+type slowStore struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	calls    map[string]int
+	failKey  string
+}
+
+func (s *slowStore) GetRawSession(ctx context.Context, sessionKey string) (*RawSession, error) {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.calls[sessionKey]++
+	s.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	switch sessionKey {
+	case "missing":
+		return nil, ErrSessionNotFound
+	case s.failKey:
+		return nil, errors.New("connection reset")
+	}
+	return &RawSession{SessionKey: sessionKey, SessionData: "data-" + sessionKey, ExpireDate: time.Now().Add(time.Hour)}, nil
+}
+
+func TestCachedDBStoreWarmCache(t *testing.T) {
+	ctx := context.Background()
+
+	keys := []string{"missing"}
+	for i := 0; i < 5*WarmCacheConcurrency; i++ {
+		keys = append(keys, fmt.Sprintf("key%02d", i))
+	}
+	keys = append(keys, "key00") // duplicates are loaded once
+
+	t.Run("bounded concurrency then cache hits", func(t *testing.T) {
+		db := &slowStore{calls: make(map[string]int)}
+		cache := newMemoryCache()
+		store := NewCachedDBStore(cache, db)
+
+		if err := store.WarmCache(ctx, keys); err != nil {
+			t.Fatalf("WarmCache() error = %v", err)
+		}
+		if db.peak > WarmCacheConcurrency || db.peak < 2 {
+			t.Errorf("peak concurrency = %d, want between 2 and %d", db.peak, WarmCacheConcurrency)
+		}
+		if len(cache.sessions) != len(keys)-2 {
+			t.Errorf("cached %d sessions, want %d", len(cache.sessions), len(keys)-2)
+		}
+
+		for _, key := range keys[1:] {
+			session, err := store.GetRawSession(ctx, key)
+			if err != nil || session.SessionData != "data-"+key {
+				t.Errorf("GetRawSession(%q) = %v, %v", key, session, err)
+			}
+			if db.calls[key] != 1 {
+				t.Errorf("store queried %d times for %q, want 1 (warmed)", db.calls[key], key)
+			}
+		}
+	})
+
+	t.Run("store errors are returned", func(t *testing.T) {
+		db := &slowStore{calls: make(map[string]int), failKey: "key03"}
+		store := NewCachedDBStore(newMemoryCache(), db)
+
+		err := store.WarmCache(ctx, keys)
+		if err == nil || !strings.Contains(err.Error(), "connection reset") {
+			t.Errorf("WarmCache() error = %v, want the store error", err)
+		}
+		if db.calls["key39"] != 1 {
+			t.Error("WarmCache() stopped at the first error")
+		}
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		db := &slowStore{calls: make(map[string]int)}
+		store := NewCachedDBStore(newMemoryCache(), db)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := store.WarmCache(canceled, keys); !errors.Is(err, context.Canceled) {
+			t.Errorf("WarmCache() error = %v, want context.Canceled", err)
+		}
+		if len(db.calls) != 0 {
+			t.Errorf("loaded %d keys after cancellation", len(db.calls))
+		}
+	})
+}
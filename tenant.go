@@ -0,0 +1,21 @@
+package django_session
+
+import "errors"
+
+// WithSecretKey returns a copy of the client that signs and verifies sessions with
+// secretKey. The copy shares the database, cookie name and all other settings except
+// SecretKeyFallbacks, which are dropped.
+func (c *Client) WithSecretKey(secretKey string) (*Client, error) {
+	if secretKey == "" {
+		return nil, errors.New("secret key is required")
+	}
+	signer := *c.signer
+	signer.SecretKey = secretKey
+	signer.FallbackKeys = nil // the base client's fallbacks belong to its own secret
+
+	tenant := *c
+	tenant.secretKey = secretKey
+	tenant.signer = &signer
+	tenant.secrets = nil // an explicit key replaces any SecretProvider
+	return &tenant, nil
+}
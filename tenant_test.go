@@ -0,0 +1,30 @@
+package django_session
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClientWithSecretKey(t *testing.T) {
+	base, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "secret-a", SessionCookieName: "sid"})
+
+	tenant, err := base.WithSecretKey("secret-b")
+	if err != nil {
+		t.Fatalf("WithSecretKey() error = %v", err)
+	}
+	if tenant.SessionCookieName() != "sid" {
+		t.Errorf("SessionCookieName() = %q, want sid", tenant.SessionCookieName())
+	}
+
+	sessionB, _ := EncodeSessionData("7", "secret-b", nil)
+	if userID, err := tenant.DecodeSessionUserID(sessionB); err != nil || userID != "7" {
+		t.Errorf("tenant DecodeSessionUserID() = %v, %v, want 7", userID, err)
+	}
+	if _, err := base.DecodeSessionUserID(sessionB); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("base client should still use its own secret, got %v", err)
+	}
+
+	if _, err := base.WithSecretKey(""); err == nil {
+		t.Error("WithSecretKey(\"\") expected error")
+	}
+}
@@ -0,0 +1,85 @@
+// Package testutil provides helpers for testing code that uses django_session
+// without a Django database: valid signed sessions and an in-memory session store.
+package testutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	djsession "github.com/knrd/go-gin-django-session"
+)
+
+// sessionAge is the lifetime of minted sessions (Django's SESSION_COOKIE_AGE default)
+const sessionAge = 14 * 24 * time.Hour
+
+// NewTestSession mints a random Django-format session key and a RawSession whose
+// payload is signed with secretKey for userID, expiring in two weeks.
+// It panics if the session cannot be encoded, which only happens on a broken test setup.
+func NewTestSession(userID, secretKey string) (string, *djsession.RawSession) {
+	sessionData, err := djsession.EncodeSessionData(userID, secretKey, nil)
+	if err != nil {
+		panic("testutil: failed to encode session: " + err.Error())
+	}
+
+	key := newSessionKey()
+	return key, &djsession.RawSession{
+		SessionKey:  key,
+		SessionData: sessionData,
+		ExpireDate:  time.Now().Add(sessionAge).UTC(),
+	}
+}
+
+// newSessionKey returns a random Django-format session key
+func newSessionKey() string {
+	key, err := djsession.GenerateSessionKey()
+	if err != nil {
+		panic("testutil: " + err.Error())
+	}
+	return key
+}
+
+// MockStore is an in-memory djsession.SessionStore for tests
+type MockStore struct {
+	mu       sync.Mutex
+	sessions map[string]*djsession.RawSession
+}
+
+// NewMockStore creates a MockStore seeded with the given sessions
+func NewMockStore(sessions ...*djsession.RawSession) *MockStore {
+	store := &MockStore{sessions: make(map[string]*djsession.RawSession)}
+	for _, session := range sessions {
+		store.Add(session)
+	}
+	return store
+}
+
+// Add stores session under its SessionKey, replacing any existing entry
+func (s *MockStore) Add(session *djsession.RawSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.SessionKey] = session
+}
+
+// Delete removes the session with the given key
+func (s *MockStore) Delete(sessionKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionKey)
+}
+
+// GetRawSession returns the stored session, ErrSessionNotFound for unknown keys
+// or ErrSessionExpired when its ExpireDate has passed, like Client.GetRawSession
+func (s *MockStore) GetRawSession(ctx context.Context, sessionKey string) (*djsession.RawSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionKey]
+	if !ok {
+		return nil, djsession.ErrSessionNotFound
+	}
+	if session.IsExpired() {
+		return nil, djsession.ErrSessionExpired
+	}
+	return session, nil
+}
@@ -0,0 +1,118 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	djsession "github.com/knrd/go-gin-django-session"
+	"github.com/knrd/go-gin-django-session/ginsession"
+)
+
+// noDB is a DBTX that fails every call, proving the database is never used
+type noDB struct{}
+
+var errNoDB = errors.New("database must not be used")
+
+func (noDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errNoDB
+}
+func (noDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) { return nil, errNoDB }
+func (noDB) QueryRow(context.Context, string, ...interface{}) pgx.Row        { return errRow{} }
+func (noDB) CopyFrom(context.Context, pgx.Identifier, []string, pgx.CopyFromSource) (int64, error) {
+	return 0, errNoDB
+}
+func (noDB) Begin(context.Context) (pgx.Tx, error) { return nil, errNoDB }
+
+type errRow struct{}
+
+func (errRow) Scan(...interface{}) error { return errNoDB }
+
+func TestNewTestSession(t *testing.T) {
+	key, session := NewTestSession("42", "test-secret")
+
+	if len(key) != 32 || session.SessionKey != key {
+		t.Errorf("Unexpected session key %q (session key %q)", key, session.SessionKey)
+	}
+	if session.IsExpired() {
+		t.Error("Expected a valid session")
+	}
+
+	userID, err := djsession.DecodeSessionData(session.SessionData, "test-secret")
+	if err != nil || userID != "42" {
+		t.Errorf("DecodeSessionData() = %v, %v, want 42", userID, err)
+	}
+
+	if other, _ := NewTestSession("42", "test-secret"); other == key {
+		t.Error("Expected distinct keys")
+	}
+}
+
+func TestMockStore(t *testing.T) {
+	ctx := context.Background()
+	key, session := NewTestSession("42", "test-secret")
+	expired := &djsession.RawSession{SessionKey: "old", ExpireDate: time.Now().Add(-time.Minute)}
+	store := NewMockStore(session, expired)
+
+	if got, err := store.GetRawSession(ctx, key); err != nil || got != session {
+		t.Errorf("GetRawSession() = %v, %v", got, err)
+	}
+	if _, err := store.GetRawSession(ctx, "old"); !errors.Is(err, djsession.ErrSessionExpired) {
+		t.Errorf("GetRawSession(expired) error = %v, want ErrSessionExpired", err)
+	}
+
+	store.Delete(key)
+	if _, err := store.GetRawSession(ctx, key); !errors.Is(err, djsession.ErrSessionNotFound) {
+		t.Errorf("GetRawSession(deleted) error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestMiddlewareWithSeededSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	secretKey := "test-secret"
+
+	key, session := NewTestSession("42", secretKey)
+	client, err := djsession.NewClient(djsession.ClientConfig{DB: noDB{}, SecretKey: secretKey})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	var userID string
+	router := gin.New()
+	router.Use(ginsession.AuthMiddleware(ginsession.MiddlewareConfig{
+		Client: client,
+		Store:  NewMockStore(session),
+	}))
+	router.GET("/me", func(c *gin.Context) {
+		raw := c.MustGet("django_session").(*djsession.RawSession)
+		userID, _ = client.DecodeSessionUserID(raw.SessionData)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "sessionid", Value: key})
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if userID != "42" {
+		t.Errorf("user ID = %q, want 42", userID)
+	}
+
+	// Unknown keys are rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/me", nil)
+	req.AddCookie(&http.Cookie{Name: "sessionid", Value: "unknown"})
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Errorf("Expected status %d, got %d", http.StatusFound, w.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package django_session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// utcTimestamp scans a Postgres timestamp into a UTC time.Time regardless of column type.
+//
+// Django always writes expire_date in UTC. For "timestamp with time zone" columns the
+// instant is converted to UTC. For "timestamp without time zone" columns the stored wall
+// clock IS UTC, so it is reinterpreted as UTC rather than in the server's local zone.
+type utcTimestamp struct {
+	dest *time.Time
+}
+
+// ScanTimestamp implements pgtype.TimestampScanner (timestamp without time zone)
+func (u utcTimestamp) ScanTimestamp(v pgtype.Timestamp) error {
+	if !v.Valid {
+		return errors.New("expire_date is NULL")
+	}
+	t := v.Time
+	*u.dest = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return nil
+}
+
+// ScanTimestamptz implements pgtype.TimestamptzScanner (timestamp with time zone)
+func (u utcTimestamp) ScanTimestamptz(v pgtype.Timestamptz) error {
+	if !v.Valid {
+		return errors.New("expire_date is NULL")
+	}
+	*u.dest = v.Time.UTC()
+	return nil
+}
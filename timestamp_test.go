@@ -0,0 +1,83 @@
+package django_session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/mock"
+)
+
+// withLocalZone runs fn with time.Local set to loc
+func withLocalZone(t *testing.T, loc *time.Location, fn func()) {
+	t.Helper()
+	original := time.Local
+	time.Local = loc
+	defer func() { time.Local = original }()
+	fn()
+}
+
+func TestUTCTimestamp(t *testing.T) {
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	wall := time.Date(2026, 1, 2, 3, 4, 5, 0, zone)
+
+	t.Run("naive timestamp wall clock is UTC", func(t *testing.T) {
+		var got time.Time
+		if err := (utcTimestamp{&got}).ScanTimestamp(pgtype.Timestamp{Time: wall, Valid: true}); err != nil {
+			t.Fatalf("ScanTimestamp() error = %v", err)
+		}
+		want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("ScanTimestamp() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("timestamptz keeps the instant", func(t *testing.T) {
+		var got time.Time
+		if err := (utcTimestamp{&got}).ScanTimestamptz(pgtype.Timestamptz{Time: wall, Valid: true}); err != nil {
+			t.Fatalf("ScanTimestamptz() error = %v", err)
+		}
+		if !got.Equal(wall) || got.Location() != time.UTC {
+			t.Errorf("ScanTimestamptz() = %v, want %v in UTC", got, wall)
+		}
+	})
+
+	t.Run("null rejected", func(t *testing.T) {
+		var got time.Time
+		if err := (utcTimestamp{&got}).ScanTimestamp(pgtype.Timestamp{}); err == nil {
+			t.Error("ScanTimestamp() expected error for NULL")
+		}
+		if err := (utcTimestamp{&got}).ScanTimestamptz(pgtype.Timestamptz{}); err == nil {
+			t.Error("ScanTimestamptz() expected error for NULL")
+		}
+	})
+}
+
+// TestGetRawSessionNaiveExpiryNonUTCZone tests a timestamp-without-time-zone expiry close
+// to now while the process runs in a zone ahead of UTC
+func TestGetRawSessionNaiveExpiryNonUTCZone(t *testing.T) {
+	withLocalZone(t, time.FixedZone("UTC+5", 5*60*60), func() {
+		// Django stored "now + 1 minute" as a naive UTC wall clock. A driver that attaches
+		// the local zone would place this instant 5 hours in the past.
+		utcWall := time.Now().UTC().Add(time.Minute)
+		naive := time.Date(utcWall.Year(), utcWall.Month(), utcWall.Day(), utcWall.Hour(),
+			utcWall.Minute(), utcWall.Second(), 0, time.Local)
+
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).
+			Return(newMockRow("abc", "data", pgtype.Timestamp{Time: naive, Valid: true}))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		session, err := client.GetRawSession(context.Background(), "abc")
+		if err != nil {
+			t.Fatalf("GetRawSession() error = %v (session expired prematurely?)", err)
+		}
+		if session.ExpireDate.Location() != time.UTC {
+			t.Errorf("ExpireDate location = %v, want UTC", session.ExpireDate.Location())
+		}
+		if remaining := time.Until(session.ExpireDate); remaining <= 0 || remaining > time.Minute {
+			t.Errorf("Session expires in %v, want within a minute", remaining)
+		}
+	})
+}
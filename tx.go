@@ -0,0 +1,238 @@
+package django_session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txBeginner is a DBTX that can start transactions, as *pgx.Conn, *pgxpool.Pool and pgx.Tx can.
+// DBTX does not require it so sqlc generated interfaces still satisfy DBTX.
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// beginTx starts a transaction on db, failing when db does not implement Begin
+func beginTx(ctx context.Context, db DBTX) (pgx.Tx, error) {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return nil, fmt.Errorf("database begin failed: %T does not implement Begin", db)
+	}
+	tx, err := beginner.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("database begin failed: %w", err)
+	}
+	return tx, nil
+}
+
+// WithReadTx runs fn with a client bound to a read-only REPEATABLE READ transaction on the
+// read database, so all queries made through txClient (e.g. GetRawSession then GetUser)
+// see one consistent snapshot. Writes through txClient fail. fn's error is returned as is
+// and the transaction is rolled back; otherwise it is committed.
+func (c *Client) WithReadTx(ctx context.Context, fn func(txClient *Client) error) error {
+	tx, err := beginTx(ctx, c.readDB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	if _, err := tx.Exec(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`); err != nil {
+		return fmt.Errorf("database begin failed: %w", err)
+	}
+
+	txClient := *c
+	txClient.db = tx
+	txClient.readDB = tx
+	txClient.maxRetries = 0 // a failed statement aborts the transaction, so retrying cannot help
+
+	if err := fn(&txClient); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("database commit failed: %w", err)
+	}
+	return nil
+}
+
+// IncrementSessionValue adds delta to the number stored under key in the session payload
+// and returns the new value; a missing key counts as 0. The row is locked with
+// SELECT ... FOR UPDATE for the read-modify-write, so concurrent increments of the same
+// session are serialized instead of lost. The payload is re-signed with a fresh timestamp.
+func (c *Client) IncrementSessionValue(ctx context.Context, sessionKey, key string, delta float64) (float64, error) {
+	if sessionKey == "" || len(sessionKey) > 255 {
+		return 0, ErrSessionNotFound
+	}
+	signer, err := c.currentSigner()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := beginTx(ctx, c.db)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	var sessionData string
+	var expireDate time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT session_data, expire_date FROM django_session WHERE session_key = $1 FOR UPDATE`,
+		sessionKey).Scan(sessionDataText{&sessionData}, utcTimestamp{&expireDate})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrSessionNotFound
+		}
+		return 0, fmt.Errorf("database query failed: %w", err)
+	}
+	if c.isExpired(expireDate) {
+		return 0, ErrSessionExpired
+	}
+
+	data, err := signer.UnsignObject(sessionData, maxAgeLimit(c.maxAge))
+	if err != nil {
+		return 0, err
+	}
+	current, err := numericValue(data[key])
+	if err != nil {
+		return 0, fmt.Errorf("session value %q: %w", key, err)
+	}
+
+	newValue := current + delta
+	data[key] = newValue
+	updated, err := signer.SignObject(data, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE django_session SET session_data = $2 WHERE session_key = $1`, sessionKey, updated); err != nil {
+		return 0, fmt.Errorf("database update failed: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("database commit failed: %w", err)
+	}
+	return newValue, nil
+}
+
+// CycleKey moves a session to a freshly generated key, keeping its data and expiry, like
+// Django's SessionBase.cycle_key which login() calls to prevent session fixation. The old
+// key stops working; the delete and insert run in one transaction. Caches in front of the
+// database (e.g. a CachedDBStore) may keep serving the old key until their entry expires.
+func (c *Client) CycleKey(ctx context.Context, sessionKey string) (*RawSession, error) {
+	if sessionKey == "" || len(sessionKey) > 255 {
+		return nil, ErrSessionNotFound
+	}
+
+	tx, err := beginTx(ctx, c.db)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	var sessionData string
+	var expireDate time.Time
+	err = tx.QueryRow(ctx,
+		`DELETE FROM django_session WHERE session_key = $1 RETURNING session_data, expire_date`,
+		sessionKey).Scan(sessionDataText{&sessionData}, utcTimestamp{&expireDate})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("database delete failed: %w", err)
+	}
+	if c.isExpired(expireDate) {
+		return nil, ErrSessionExpired
+	}
+
+	txClient := *c
+	txClient.db = tx
+	newKey, err := txClient.insertSession(ctx, sessionData, expireDate)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("database commit failed: %w", err)
+	}
+	return &RawSession{SessionKey: newKey, SessionData: sessionData, ExpireDate: expireDate}, nil
+}
+
+// numericValue converts a decoded session value to float64; nil (a missing key) is 0
+func numericValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	default:
+		return 0, fmt.Errorf("not a number: %T", v)
+	}
+}
+
+// CreateSessionEnforcingLimit stores a new session for userID and then evicts the user's
+// oldest active sessions so at most max remain, the new one included. Sessions expiring
+// soonest count as oldest; "active" is judged by the client's clock, like isExpired.
+// Creation, counting and eviction run in one transaction holding a per-user advisory
+// lock, so concurrent logins of the same user cannot both slip under the limit. Returns
+// the new session and the evicted keys, e.g. to drop them from a cache.
+//
+// The user ID is only known after decoding, so counting decodes every other active row
+// while the lock is held, at the cost of ListSessionsForUser. BuildSession enforces no
+// limit and scans nothing, so call this only where the limit is needed.
+func (c *Client) CreateSessionEnforcingLimit(ctx context.Context, userID string, max int, extra map[string]interface{}) (*RawSession, []string, error) {
+	if max < 1 {
+		return nil, nil, fmt.Errorf("session limit must be at least 1, got %d", max)
+	}
+	sessionData, err := c.encodeUserSession(userID, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := c.nowFunc().UTC()
+	expireDate := now.Add(c.sessionAge())
+
+	tx, err := beginTx(ctx, c.db)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx) // no-op after Commit
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('django_session:' || $1))`, userID); err != nil {
+		return nil, nil, fmt.Errorf("database lock failed: %w", err)
+	}
+
+	txClient := *c
+	txClient.db = tx
+	sessionKey, err := txClient.insertSession(ctx, sessionData, expireDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessions, err := txClient.sessionsForUser(ctx, tx, userID, `SELECT session_key, session_data, expire_date
+	          FROM django_session
+	          WHERE expire_date > $1 AND session_key <> $2
+	          ORDER BY expire_date, session_key`, now, sessionKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	existing := make([]string, len(sessions))
+	for i, session := range sessions {
+		existing[i] = session.SessionKey
+	}
+	var evicted []string
+	if excess := len(existing) - (max - 1); excess > 0 {
+		evicted = existing[:excess]
+		if _, err := tx.Exec(ctx, `DELETE FROM django_session WHERE session_key = ANY($1)`, evicted); err != nil {
+			return nil, nil, fmt.Errorf("database delete failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("database commit failed: %w", err)
+	}
+	return &RawSession{SessionKey: sessionKey, SessionData: sessionData, ExpireDate: expireDate}, evicted, nil
+}
@@ -0,0 +1,505 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTx is a mock implementation of pgx.Tx
+type MockTx struct {
+	MockDBTX
+}
+
+func (m *MockTx) Commit(ctx context.Context) error   { return m.Called(ctx).Error(0) }
+func (m *MockTx) Rollback(ctx context.Context) error { return m.Called(ctx).Error(0) }
+func (m *MockTx) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+func (m *MockTx) LargeObjects() pgx.LargeObjects { return pgx.LargeObjects{} }
+func (m *MockTx) Prepare(ctx context.Context, name, sql string) (*pgconn.StatementDescription, error) {
+	return nil, errors.New("not supported")
+}
+func (m *MockTx) Conn() *pgx.Conn { return nil }
+
+// newReadTx returns a MockTx accepting the read-only setup statement and Rollback
+func newReadTx() *MockTx {
+	tx := &MockTx{}
+	tx.On("Exec", mock.Anything, sqlContains("READ ONLY"), mock.Anything).Return(pgconn.NewCommandTag("SET"), nil)
+	tx.On("Rollback", mock.Anything).Return(nil)
+	return tx
+}
+
+func TestWithReadTx(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+
+	t.Run("reads share one transaction", func(t *testing.T) {
+		tx := newReadTx()
+		tx.On("QueryRow", mock.Anything, sqlContains("django_session"), []interface{}{"abc"}).
+			Return(newMockRow("abc", sessionData, time.Now().Add(time.Hour)))
+		tx.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+		tx.On("Commit", mock.Anything).Return(nil)
+
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil).Once()
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		var user *DjangoUser
+		err := client.WithReadTx(context.Background(), func(txClient *Client) error {
+			session, err := txClient.GetRawSession(context.Background(), "abc")
+			if err != nil {
+				return err
+			}
+			userID, err := txClient.DecodeSessionUserID(session.SessionData)
+			if err != nil {
+				return err
+			}
+			user, err = txClient.GetUser(context.Background(), userID)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithReadTx() error = %v", err)
+		}
+		if user == nil || user.Username != "alice" {
+			t.Errorf("GetUser() in transaction = %+v", user)
+		}
+
+		db.AssertNumberOfCalls(t, "Begin", 1)
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+		tx.AssertNumberOfCalls(t, "QueryRow", 2)
+		tx.AssertCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("begins on the read replica", func(t *testing.T) {
+		tx := newReadTx()
+		tx.On("Commit", mock.Anything).Return(nil)
+		primary, replica := &MockDBTX{}, &MockDBTX{}
+		replica.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: primary, ReadDB: replica, SecretKey: secretKey})
+
+		if err := client.WithReadTx(context.Background(), func(*Client) error { return nil }); err != nil {
+			t.Fatalf("WithReadTx() error = %v", err)
+		}
+		primary.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+
+	t.Run("callback error rolls back", func(t *testing.T) {
+		tx := newReadTx()
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		errCallback := errors.New("callback failed")
+		if err := client.WithReadTx(context.Background(), func(*Client) error { return errCallback }); err != errCallback {
+			t.Errorf("WithReadTx() error = %v, want callback error", err)
+		}
+		tx.AssertCalled(t, "Rollback", mock.Anything)
+		tx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("begin error", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(nil, errors.New("connection refused"))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		called := false
+		err := client.WithReadTx(context.Background(), func(*Client) error { called = true; return nil })
+		if err == nil || called {
+			t.Errorf("WithReadTx() error = %v, callback called = %v", err, called)
+		}
+	})
+
+	t.Run("database without Begin", func(t *testing.T) {
+		// Embedding the interface hides MockDBTX.Begin, like an sqlc generated DBTX
+		db := struct{ DBTX }{&MockDBTX{}}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		called := false
+		err := client.WithReadTx(context.Background(), func(*Client) error { called = true; return nil })
+		if err == nil || !strings.Contains(err.Error(), "does not implement Begin") || called {
+			t.Errorf("WithReadTx() error = %v, callback called = %v", err, called)
+		}
+		if _, err := client.CycleKey(context.Background(), "abc"); err == nil {
+			t.Error("CycleKey() error = nil, want an error without Begin")
+		}
+	})
+}
+
+// lockingDB emulates a single django_session row guarded by a row lock: a
+// SELECT ... FOR UPDATE blocks until the transaction holding the lock ends
+type lockingDB struct {
+	MockDBTX
+	lock        sync.Mutex
+	sessionData string
+	expireDate  time.Time
+}
+
+func (db *lockingDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &lockingTx{db: db}, nil
+}
+
+// lockingTx is a pgx.Tx over a lockingDB; unused methods panic via the nil embedded Tx
+type lockingTx struct {
+	pgx.Tx
+	db     *lockingDB
+	locked bool
+}
+
+func (tx *lockingTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if strings.Contains(sql, "FOR UPDATE") {
+		tx.db.lock.Lock()
+		tx.locked = true
+	}
+	return newMockRow(tx.db.sessionData, tx.db.expireDate)
+}
+
+func (tx *lockingTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tx.db.sessionData = args[1].(string)
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (tx *lockingTx) Commit(ctx context.Context) error { return tx.Rollback(ctx) }
+
+func (tx *lockingTx) Rollback(ctx context.Context) error {
+	if tx.locked {
+		tx.locked = false
+		tx.db.lock.Unlock()
+	}
+	return nil
+}
+
+func TestIncrementSessionValue(t *testing.T) {
+	secretKey := "test-secret"
+
+	t.Run("concurrent increments", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"page_views": 10})
+		db := &lockingDB{sessionData: sessionData, expireDate: time.Now().Add(time.Hour)}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		const workers = 2
+		const perWorker = 25
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*perWorker)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					if _, err := client.IncrementSessionValue(context.Background(), "abc", "page_views", 1); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Fatalf("IncrementSessionValue() error = %v", err)
+		}
+
+		data, err := client.DecodeSessionMap(db.sessionData)
+		if err != nil {
+			t.Fatalf("DecodeSessionMap() error = %v", err)
+		}
+		if data["page_views"] != float64(10+workers*perWorker) || data["_auth_user_id"] != "42" {
+			t.Errorf("payload after increments = %v, want page_views %d", data, 10+workers*perWorker)
+		}
+	})
+
+	t.Run("missing key starts at zero", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, nil)
+		db := &lockingDB{sessionData: sessionData, expireDate: time.Now().Add(time.Hour)}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		got, err := client.IncrementSessionValue(context.Background(), "abc", "credits", -2.5)
+		if err != nil || got != -2.5 {
+			t.Errorf("IncrementSessionValue() = %v, %v, want -2.5", got, err)
+		}
+	})
+
+	t.Run("non-numeric value", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, map[string]interface{}{"page_views": "many"})
+		db := &lockingDB{sessionData: sessionData, expireDate: time.Now().Add(time.Hour)}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.IncrementSessionValue(context.Background(), "abc", "page_views", 1); err == nil {
+			t.Error("IncrementSessionValue() expected error")
+		}
+		if db.sessionData != sessionData {
+			t.Error("Expected the session to be left unchanged")
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData("42", secretKey, nil)
+		db := &lockingDB{sessionData: sessionData, expireDate: time.Now().Add(-time.Hour)}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.IncrementSessionValue(context.Background(), "abc", "page_views", 1); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("IncrementSessionValue() error = %v, want ErrSessionExpired", err)
+		}
+	})
+
+	t.Run("missing session", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("FOR UPDATE"), mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 2))
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.IncrementSessionValue(context.Background(), "abc", "page_views", 1); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("IncrementSessionValue() error = %v, want ErrSessionNotFound", err)
+		}
+		tx.AssertCalled(t, "Rollback", mock.Anything)
+	})
+}
+
+func TestCycleKey(t *testing.T) {
+	secretKey := "test-secret"
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+	expireDate := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	t.Run("moves the session to a new key", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{"oldkey"}).
+			Return(newMockRow(sessionData, expireDate))
+		tx.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		session, err := client.CycleKey(context.Background(), "oldkey")
+		if err != nil {
+			t.Fatalf("CycleKey() error = %v", err)
+		}
+		if !IsValidSessionKey(session.SessionKey) || session.SessionKey == "oldkey" {
+			t.Errorf("CycleKey() key = %q, want a new Django session key", session.SessionKey)
+		}
+		if session.SessionData != sessionData || !session.ExpireDate.Equal(expireDate) {
+			t.Errorf("CycleKey() = %+v, want data and expiry preserved", session)
+		}
+		tx.AssertCalled(t, "Exec", mock.Anything, sqlContains("INSERT INTO django_session"),
+			[]interface{}{session.SessionKey, sessionData, expireDate})
+		tx.AssertCalled(t, "Commit", mock.Anything)
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("key collision regenerates the key in the transaction", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{"oldkey"}).
+			Return(newMockRow(sessionData, expireDate))
+		tx.On("Exec", mock.Anything, sqlContains("ON CONFLICT (session_key) DO NOTHING"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 0"), nil).Once()
+		tx.On("Exec", mock.Anything, sqlContains("ON CONFLICT (session_key) DO NOTHING"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.CycleKey(context.Background(), "oldkey"); err != nil {
+			t.Fatalf("CycleKey() error = %v", err)
+		}
+		tx.AssertNumberOfCalls(t, "Exec", 2)
+		tx.AssertCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("missing session", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("DELETE"), mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 2))
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.CycleKey(context.Background(), "oldkey"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("CycleKey() error = %v, want ErrSessionNotFound", err)
+		}
+		tx.AssertNotCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("expired session is not moved", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("QueryRow", mock.Anything, sqlContains("DELETE"), mock.Anything).
+			Return(newMockRow(sessionData, time.Now().Add(-time.Hour)))
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, err := client.CycleKey(context.Background(), "oldkey"); !errors.Is(err, ErrSessionExpired) {
+			t.Errorf("CycleKey() error = %v, want ErrSessionExpired", err)
+		}
+		tx.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+		tx.AssertCalled(t, "Rollback", mock.Anything)
+	})
+}
+
+func TestCreateSessionEnforcingLimit(t *testing.T) {
+	secretKey := "test-secret"
+	userData, _ := EncodeSessionData("42", secretKey, nil)
+	otherData, _ := EncodeSessionData("7", secretKey, nil)
+	expireDate := time.Now().Add(time.Hour)
+
+	// newLimitTx returns a MockTx whose session listing holds the given rows, in expiry order
+	newLimitTx := func(existing [][]interface{}) *MockTx {
+		tx := &MockTx{}
+		tx.On("Exec", mock.Anything, sqlContains("pg_advisory_xact_lock"), []interface{}{"42"}).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+		tx.On("Exec", mock.Anything, sqlContains("INSERT INTO django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Exec", mock.Anything, sqlContains("DELETE FROM django_session"), mock.Anything).
+			Return(pgconn.NewCommandTag("DELETE 1"), nil)
+		tx.On("Query", mock.Anything, sqlContains("ORDER BY expire_date"), mock.Anything).
+			Return(&MockRows{total: len(existing), row: func(i int) []interface{} { return existing[i] }}, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		return tx
+	}
+
+	t.Run("creating past the limit evicts the oldest session", func(t *testing.T) {
+		tx := newLimitTx([][]interface{}{
+			{"oldest", userData, expireDate},
+			{"someone-else", otherData, expireDate.Add(time.Minute)},
+			{"older", userData, expireDate.Add(2 * time.Minute)},
+			{"newer", userData, expireDate.Add(3 * time.Minute)},
+		})
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		session, evicted, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 3, map[string]interface{}{"theme": "dark"})
+		if err != nil {
+			t.Fatalf("CreateSessionEnforcingLimit() error = %v", err)
+		}
+		if !IsValidSessionKey(session.SessionKey) {
+			t.Errorf("CreateSessionEnforcingLimit() key = %q, want a Django session key", session.SessionKey)
+		}
+		if userID, _ := client.DecodeSessionUserID(session.SessionData); userID != "42" {
+			t.Errorf("new session user = %q, want 42", userID)
+		}
+		if len(evicted) != 1 || evicted[0] != "oldest" {
+			t.Errorf("CreateSessionEnforcingLimit() evicted = %v, want [oldest]", evicted)
+		}
+		tx.AssertCalled(t, "Exec", mock.Anything, sqlContains("DELETE FROM django_session"), []interface{}{[]string{"oldest"}})
+		tx.AssertCalled(t, "Commit", mock.Anything)
+		db.AssertNotCalled(t, "Exec", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("active sessions are judged by the client clock", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+		tx := newLimitTx(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey, Now: clock.Now})
+
+		session, _, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 1, nil)
+		if err != nil {
+			t.Fatalf("CreateSessionEnforcingLimit() error = %v", err)
+		}
+		tx.AssertCalled(t, "Query", mock.Anything, sqlContains("expire_date > $1 AND session_key <> $2"), []interface{}{clock.now, session.SessionKey})
+		if want := clock.now.Add(defaultSessionAge); !session.ExpireDate.Equal(want) {
+			t.Errorf("ExpireDate = %v, want %v", session.ExpireDate, want)
+		}
+	})
+
+	t.Run("writes and compares in UTC with a non-UTC clock", func(t *testing.T) {
+		clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+9", 9*60*60))}
+		tx := newLimitTx(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey, Now: clock.Now, MaxAge: time.Hour})
+
+		session, _, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 1, nil)
+		if err != nil {
+			t.Fatalf("CreateSessionEnforcingLimit() error = %v", err)
+		}
+		want := time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC)
+		if session.ExpireDate != want {
+			t.Errorf("ExpireDate = %v, want %v", session.ExpireDate, want)
+		}
+		tx.AssertCalled(t, "Exec", mock.Anything, sqlContains("INSERT INTO django_session"), []interface{}{session.SessionKey, session.SessionData, want})
+		tx.AssertCalled(t, "Query", mock.Anything, sqlContains("expire_date > $1"), []interface{}{clock.now.UTC(), session.SessionKey})
+	})
+
+	t.Run("key collision regenerates the key in the transaction", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("Exec", mock.Anything, sqlContains("pg_advisory_xact_lock"), mock.Anything).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+		tx.On("Exec", mock.Anything, sqlContains("ON CONFLICT (session_key) DO NOTHING"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 0"), nil).Once()
+		tx.On("Exec", mock.Anything, sqlContains("ON CONFLICT (session_key) DO NOTHING"), mock.Anything).
+			Return(pgconn.NewCommandTag("INSERT 0 1"), nil)
+		tx.On("Query", mock.Anything, sqlContains("ORDER BY expire_date"), mock.Anything).Return(&MockRows{}, nil)
+		tx.On("Commit", mock.Anything).Return(nil)
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, _, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 1, nil); err != nil {
+			t.Fatalf("CreateSessionEnforcingLimit() error = %v", err)
+		}
+		tx.AssertNumberOfCalls(t, "Exec", 3)
+		tx.AssertCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("under the limit nothing is evicted", func(t *testing.T) {
+		tx := newLimitTx([][]interface{}{{"existing", userData, expireDate}})
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		_, evicted, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 2, nil)
+		if err != nil {
+			t.Fatalf("CreateSessionEnforcingLimit() error = %v", err)
+		}
+		if len(evicted) != 0 {
+			t.Errorf("CreateSessionEnforcingLimit() evicted = %v, want none", evicted)
+		}
+		tx.AssertNotCalled(t, "Exec", mock.Anything, sqlContains("DELETE"), mock.Anything)
+		tx.AssertCalled(t, "Commit", mock.Anything)
+	})
+
+	t.Run("insert failure rolls back", func(t *testing.T) {
+		tx := &MockTx{}
+		tx.On("Exec", mock.Anything, sqlContains("pg_advisory_xact_lock"), mock.Anything).
+			Return(pgconn.NewCommandTag("SELECT 1"), nil)
+		tx.On("Exec", mock.Anything, sqlContains("INSERT"), mock.Anything).
+			Return(pgconn.CommandTag{}, errors.New("connection reset"))
+		tx.On("Rollback", mock.Anything).Return(nil)
+		db := &MockDBTX{}
+		db.On("Begin", mock.Anything).Return(tx, nil)
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, _, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 1, nil); err == nil {
+			t.Error("CreateSessionEnforcingLimit() error = nil, want insert error")
+		}
+		tx.AssertNotCalled(t, "Commit", mock.Anything)
+		tx.AssertCalled(t, "Rollback", mock.Anything)
+	})
+
+	t.Run("limit below one", func(t *testing.T) {
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: secretKey})
+
+		if _, _, err := client.CreateSessionEnforcingLimit(context.Background(), "42", 0, nil); err == nil {
+			t.Error("CreateSessionEnforcingLimit() error = nil, want error for max 0")
+		}
+		db.AssertNotCalled(t, "Begin", mock.Anything)
+	})
+}
@@ -0,0 +1,104 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserIDType describes the primary key type of the user model, which decides how
+// _auth_user_id is bound in user lookups
+type UserIDType string
+
+// Supported user primary key types
+const (
+	UserIDInt    UserIDType = "int"    // AutoField/BigAutoField (Django's default)
+	UserIDUUID   UserIDType = "uuid"   // UUIDField
+	UserIDString UserIDType = "string" // CharField or other text keys
+)
+
+// uuidPattern matches the canonical textual form of a UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// DjangoUser represents a row from Django's auth_user table
+type DjangoUser struct {
+	ID          int64  // Integer primary key (0 for UUID and string keys)
+	PK          string // Primary key as text, for every UserIDType
+	Username    string
+	Email       string
+	FirstName   string
+	LastName    string
+	IsActive    bool
+	IsStaff     bool
+	IsSuperuser bool
+}
+
+// GetUser loads a user from Django's auth_user table by ID. The ID is bound according to
+// ClientConfig.UserIDType; IDs that cannot be of that type return ErrUserNotFound
+// without querying.
+func (c *Client) GetUser(ctx context.Context, userID string) (*DjangoUser, error) {
+	if userID == "" {
+		return nil, ErrUserNotFound
+	}
+
+	var user DjangoUser
+	var param interface{}
+	var query string
+
+	switch c.userIDType {
+	case UserIDUUID:
+		if !uuidPattern.MatchString(userID) {
+			return nil, ErrUserNotFound
+		}
+		param = userID
+		query = `SELECT id::text, username, email, first_name, last_name, is_active, is_staff, is_superuser
+		         FROM auth_user
+		         WHERE id = $1::uuid`
+	case UserIDString:
+		param = userID
+		query = `SELECT id::text, username, email, first_name, last_name, is_active, is_staff, is_superuser
+		         FROM auth_user
+		         WHERE id = $1`
+	default:
+		id, err := strconv.ParseInt(userID, 10, 64)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		param = id
+		query = `SELECT id, username, email, first_name, last_name, is_active, is_staff, is_superuser
+		         FROM auth_user
+		         WHERE id = $1`
+	}
+
+	var id interface{} = &user.PK
+	if c.userIDType == UserIDInt {
+		id = &user.ID
+	}
+
+	err := c.readDB.QueryRow(ctx, query, param).Scan(
+		id,
+		&user.Username,
+		&user.Email,
+		&user.FirstName,
+		&user.LastName,
+		&user.IsActive,
+		&user.IsStaff,
+		&user.IsSuperuser,
+	)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+
+	if c.userIDType == UserIDInt {
+		user.PK = strconv.FormatInt(user.ID, 10)
+	}
+	return &user, nil
+}
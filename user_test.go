@@ -0,0 +1,124 @@
+package django_session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestGetUser tests loading a user from auth_user
+func TestGetUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("found", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		user, err := client.GetUser(ctx, "42")
+		if err != nil {
+			t.Fatalf("GetUser() error = %v", err)
+		}
+		if user.ID != 42 || user.Username != "alice" || user.Email != "alice@example.com" {
+			t.Errorf("GetUser() = %+v", user)
+		}
+		if !user.IsActive || user.IsStaff {
+			t.Errorf("GetUser() unexpected flags: %+v", user)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, mock.Anything, mock.Anything).Return(newErrorRow(pgx.ErrNoRows, 8))
+
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		_, err := client.GetUser(ctx, "42")
+		if !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUser() error = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("empty id", func(t *testing.T) {
+		client, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret"})
+
+		_, err := client.GetUser(ctx, "")
+		if !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUser() error = %v, want ErrUserNotFound", err)
+		}
+	})
+}
+
+// TestGetUserIDTypes tests parameter binding for each user primary key type
+func TestGetUserIDTypes(t *testing.T) {
+	ctx := context.Background()
+	uuid := "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+
+	t.Run("uuid session decodes and loads user", func(t *testing.T) {
+		sessionData, _ := EncodeSessionData(uuid, "test-secret", nil)
+
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("$1::uuid"), []interface{}{uuid}).
+			Return(newMockRow(uuid, "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", UserIDType: UserIDUUID})
+
+		userID, err := client.DecodeSessionUserID(sessionData)
+		if err != nil || userID != uuid {
+			t.Fatalf("DecodeSessionUserID() = %v, %v, want %s", userID, err, uuid)
+		}
+		user, err := client.GetUser(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUser() error = %v", err)
+		}
+		if user.PK != uuid || user.ID != 0 || user.Username != "alice" {
+			t.Errorf("GetUser() = %+v", user)
+		}
+	})
+
+	t.Run("malformed uuid is not queried", func(t *testing.T) {
+		db := &MockDBTX{}
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", UserIDType: UserIDUUID})
+
+		if _, err := client.GetUser(ctx, "42"); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUser() error = %v, want ErrUserNotFound", err)
+		}
+		db.AssertNotCalled(t, "QueryRow", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("string ids", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{"alice"}).
+			Return(newMockRow("alice", "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret", UserIDType: UserIDString})
+
+		user, err := client.GetUser(ctx, "alice")
+		if err != nil || user.PK != "alice" {
+			t.Errorf("GetUser() = %+v, %v", user, err)
+		}
+	})
+
+	t.Run("int ids bind as integers", func(t *testing.T) {
+		db := &MockDBTX{}
+		db.On("QueryRow", mock.Anything, sqlContains("auth_user"), []interface{}{int64(42)}).
+			Return(newMockRow(int64(42), "alice", "alice@example.com", "Alice", "Smith", true, false, false))
+		client, _ := NewClient(ClientConfig{DB: db, SecretKey: "test-secret"})
+
+		user, err := client.GetUser(ctx, "42")
+		if err != nil || user.ID != 42 || user.PK != "42" {
+			t.Errorf("GetUser() = %+v, %v", user, err)
+		}
+		if _, err := client.GetUser(ctx, uuid); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUser(uuid) error = %v, want ErrUserNotFound", err)
+		}
+		db.AssertNumberOfCalls(t, "QueryRow", 1)
+	})
+
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", UserIDType: "float"}); err == nil {
+		t.Error("NewClient() expected error for unsupported UserIDType")
+	}
+}
@@ -0,0 +1,64 @@
+package django_session
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DjangoVersion selects the session signing defaults of a Django release
+type DjangoVersion string
+
+// Supported Django versions
+const (
+	DjangoVersion32 DjangoVersion = "3.2"
+	DjangoVersion42 DjangoVersion = "4.2"
+	DjangoVersion50 DjangoVersion = "5.0"
+)
+
+// VersionDefaults holds the session signing settings a Django version uses out of the box
+type VersionDefaults struct {
+	Salt            string // Session signing salt (the session store's class path)
+	Algorithm       string // Signing algorithm for new signatures
+	LegacyAlgorithm string // Additional algorithm accepted when verifying, if any
+	Serializer      string // SESSION_SERIALIZER format (only "json" is supported)
+}
+
+// Defaults returns the session signing defaults for the version.
+// Django 3.2 signs with sha256 but still accepts sha1 signatures from older releases;
+// 4.0 dropped the sha1 fallback; 5.0 removed PickleSerializer, leaving JSON only.
+func (v DjangoVersion) Defaults() (VersionDefaults, error) {
+	defaults := VersionDefaults{
+		Salt:       "django.contrib.sessions.SessionStore",
+		Algorithm:  "sha256",
+		Serializer: "json",
+	}
+
+	switch v {
+	case DjangoVersion32:
+		defaults.LegacyAlgorithm = "sha1"
+	case DjangoVersion42, DjangoVersion50:
+	default:
+		return VersionDefaults{}, fmt.Errorf("unsupported Django version: %q", string(v))
+	}
+	return defaults, nil
+}
+
+// Django's SESSION_SERIALIZER class paths
+const (
+	JSONSerializerPath   = "django.contrib.sessions.serializers.JSONSerializer"
+	PickleSerializerPath = "django.contrib.sessions.serializers.PickleSerializer"
+)
+
+// serializerFormat maps a SESSION_SERIALIZER value, as read from Django settings, to the
+// format name used by VersionDefaults.Serializer. Pickled sessions are recognized but
+// rejected, since they cannot be decoded safely outside Python.
+func serializerFormat(serializer string) (string, error) {
+	switch serializer {
+	case "", "json", JSONSerializerPath, "django.core.signing.JSONSerializer":
+		return "json", nil
+	case "pickle", PickleSerializerPath:
+		return "", errors.New("PickleSerializer sessions are not supported; use JSONSerializer")
+	default:
+		return "", fmt.Errorf("unknown session serializer: %q", serializer)
+	}
+}
@@ -0,0 +1,106 @@
+package django_session
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDjangoVersionDefaults(t *testing.T) {
+	tests := []struct {
+		version    DjangoVersion
+		algorithm  string
+		legacy     string
+		serializer string
+	}{
+		{DjangoVersion32, "sha256", "sha1", "json"},
+		{DjangoVersion42, "sha256", "", "json"},
+		{DjangoVersion50, "sha256", "", "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.version), func(t *testing.T) {
+			defaults, err := tt.version.Defaults()
+			if err != nil {
+				t.Fatalf("Defaults() error = %v", err)
+			}
+			if defaults.Salt != "django.contrib.sessions.SessionStore" {
+				t.Errorf("Salt = %q, want django.contrib.sessions.SessionStore", defaults.Salt)
+			}
+			if defaults.Algorithm != tt.algorithm {
+				t.Errorf("Algorithm = %q, want %q", defaults.Algorithm, tt.algorithm)
+			}
+			if defaults.LegacyAlgorithm != tt.legacy {
+				t.Errorf("LegacyAlgorithm = %q, want %q", defaults.LegacyAlgorithm, tt.legacy)
+			}
+			if defaults.Serializer != tt.serializer {
+				t.Errorf("Serializer = %q, want %q", defaults.Serializer, tt.serializer)
+			}
+
+			client, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", DjangoVersion: tt.version})
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if client.signer.Salt != defaults.Salt || client.signer.Algorithm != defaults.Algorithm ||
+				client.signer.LegacyAlgorithm != defaults.LegacyAlgorithm {
+				t.Errorf("client signer = %+v, want defaults %+v", client.signer, defaults)
+			}
+		})
+	}
+
+	if _, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", DjangoVersion: "1.11"}); err == nil {
+		t.Error("NewClient() expected error for unsupported Django version")
+	}
+}
+
+func TestDjangoVersionLegacySignatures(t *testing.T) {
+	secretKey := "test-secret"
+	sha1Signer := &DjangoSigner{SecretKey: secretKey, Salt: "django.contrib.sessions.SessionStore", Sep: ":", Algorithm: "sha1"}
+	legacyData, err := sha1Signer.SignObject(map[string]interface{}{"_auth_user_id": "42"}, false)
+	if err != nil {
+		t.Fatalf("SignObject() error = %v", err)
+	}
+
+	client32, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, DjangoVersion: DjangoVersion32})
+	if userID, err := client32.DecodeSessionUserID(legacyData); err != nil || userID != "42" {
+		t.Errorf("3.2: DecodeSessionUserID() = %v, %v, want 42", userID, err)
+	}
+
+	client42, _ := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: secretKey, DjangoVersion: DjangoVersion42})
+	if _, err := client42.DecodeSessionUserID(legacyData); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("4.2: DecodeSessionUserID() error = %v, want ErrInvalidSignature", err)
+	}
+
+	// New sessions are always signed with sha256
+	sessionData, _ := EncodeSessionData("42", secretKey, nil)
+	if userID, err := client32.DecodeSessionUserID(sessionData); err != nil || userID != "42" {
+		t.Errorf("3.2: DecodeSessionUserID(sha256) = %v, %v, want 42", userID, err)
+	}
+}
+
+func TestClientSerializer(t *testing.T) {
+	tests := []struct {
+		serializer string
+		wantErr    bool
+	}{
+		{"", false},
+		{JSONSerializerPath, false},
+		{"django.core.signing.JSONSerializer", false},
+		{PickleSerializerPath, true},
+		{"myapp.serializers.MsgPackSerializer", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serializer, func(t *testing.T) {
+			_, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", Serializer: tt.serializer})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClient(Serializer: %q) error = %v, wantErr %v", tt.serializer, err, tt.wantErr)
+			}
+		})
+	}
+
+	_, err := NewClient(ClientConfig{DB: &MockDBTX{}, SecretKey: "test-secret", Serializer: PickleSerializerPath})
+	if err == nil || !strings.Contains(err.Error(), "PickleSerializer") {
+		t.Errorf("Expected a PickleSerializer-specific error, got %v", err)
+	}
+}